@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/nickbryan/go-template/service/app/password"
 	"github.com/spf13/viper"
 )
 
@@ -16,8 +17,79 @@ type Config struct {
 		IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
 		ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 		Address         string
+		MetricsAddress  string   `mapstructure:"metrics_address"`
+		TrustedProxies  []string `mapstructure:"trusted_proxies"`
+		AccessLog       struct {
+			SampleRate float64 `mapstructure:"sample_rate"`
+			Exclude    []string
+		} `mapstructure:"access_log"`
 	}
-	DatabaseURL string `mapstructure:"DATABASE_URL"`
+	Customers struct {
+		PurgeAfter                 time.Duration `mapstructure:"purge_after"`
+		VerificationLink           string        `mapstructure:"verification_link"`
+		VerificationTTL            time.Duration `mapstructure:"verification_ttl"`
+		VerificationResendInterval time.Duration `mapstructure:"verification_resend_interval"`
+	}
+	Auth struct {
+		Algorithm        string
+		Issuer           string
+		AccessTokenTTL   time.Duration `mapstructure:"access_token_ttl"`
+		RefreshTokenTTL  time.Duration `mapstructure:"refresh_token_ttl"`
+		PrivateKeyFile   string        `mapstructure:"private_key_file"`
+		PublicKeyFile    string        `mapstructure:"public_key_file"`
+		PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"`
+		Lockout          struct {
+			Username LockoutPolicy
+			IP       LockoutPolicy
+		}
+	}
+	Passwords password.Config
+	Mail      struct {
+		Driver    string
+		From      string
+		Directory string
+		SMTP      struct {
+			Host     string
+			Port     int
+			Username string
+		}
+	}
+	Outbox struct {
+		Driver  string
+		Webhook struct {
+			URL     string
+			Timeout time.Duration
+		}
+		PollInterval time.Duration `mapstructure:"poll_interval"`
+		BatchSize    int           `mapstructure:"batch_size"`
+		Lease        time.Duration
+		MaxAttempts  int           `mapstructure:"max_attempts"`
+		BaseDelay    time.Duration `mapstructure:"base_delay"`
+		MaxDelay     time.Duration `mapstructure:"max_delay"`
+	}
+	Tracing struct {
+		Exporter    string
+		ServiceName string  `mapstructure:"service_name"`
+		SampleRate  float64 `mapstructure:"sample_rate"`
+		OTLP        struct {
+			Endpoint string
+			Insecure bool
+		}
+	}
+	DatabaseURL         string `mapstructure:"DATABASE_URL"`
+	JWTSecret           string `mapstructure:"JWT_SECRET"`
+	SMTPPassword        string `mapstructure:"SMTP_PASSWORD"`
+	OutboxWebhookSecret string `mapstructure:"OUTBOX_WEBHOOK_SECRET"`
+}
+
+// LockoutPolicy configures how failed login attempts are throttled. Times are in seconds.
+type LockoutPolicy struct {
+	DelayAfter   int           `mapstructure:"delay_after"`
+	BaseDelay    time.Duration `mapstructure:"base_delay"`
+	MaxDelay     time.Duration `mapstructure:"max_delay"`
+	LockAfter    int           `mapstructure:"lock_after"`
+	LockDuration time.Duration `mapstructure:"lock_duration"`
+	ResetAfter   time.Duration `mapstructure:"reset_after"`
 }
 
 func createConfig() (*Config, error) {
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -12,16 +13,36 @@ import (
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/nickbryan/go-template/service/app/event"
+	"github.com/nickbryan/go-template/service/app/mail"
+	"github.com/nickbryan/go-template/service/app/outbox"
+	"github.com/nickbryan/go-template/service/app/password"
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
 
+var (
+	ErrUnsupportedMailDriver      = errors.New("unsupported mail driver")
+	ErrUnsupportedOutboxDriver    = errors.New("unsupported outbox driver")
+	ErrUnsupportedTracingExporter = errors.New("unsupported tracing exporter")
+)
+
 // Environment holds our main application state. This gives us a common place
 // to initialise key services for our default and test environments.
 type Environment struct {
-	config *Config
-	logger *zap.Logger
-	db     *DB
+	config    *Config
+	logger    *zap.Logger
+	db        *DB
+	jwt       *JWT
+	mailer    mail.Mailer
+	hasher    *password.Hasher
+	events    *event.Dispatcher
+	publisher outbox.Publisher
+	metrics   *prometheus.Registry
+	tracing   *sdktrace.TracerProvider
 }
 
 // Config is our application wide configuration struct.
@@ -39,6 +60,41 @@ func (e *Environment) DB() *DB {
 	return e.db
 }
 
+// JWT issues and verifies the access tokens used to authenticate requests.
+func (e *Environment) JWT() *JWT {
+	return e.jwt
+}
+
+// Mailer sends emails to customers.
+func (e *Environment) Mailer() mail.Mailer {
+	return e.mailer
+}
+
+// PasswordHasher hashes and verifies customer passwords.
+func (e *Environment) PasswordHasher() *password.Hasher {
+	return e.hasher
+}
+
+// Events dispatches domain events to the subscribers that react to them.
+func (e *Environment) Events() *event.Dispatcher {
+	return e.events
+}
+
+// Publisher publishes the messages relayed from the outbox.
+func (e *Environment) Publisher() outbox.Publisher {
+	return e.publisher
+}
+
+// Metrics is the registry that our application wide metrics are registered with and gathered from.
+func (e *Environment) Metrics() *prometheus.Registry {
+	return e.metrics
+}
+
+// TracerProvider creates the tracers used to start spans, such as those for each request and database query.
+func (e *Environment) TracerProvider() trace.TracerProvider {
+	return e.tracing
+}
+
 // CleanupFunc allows the caller to cleanup the environment once the application
 // is finished running.
 type CleanupFunc func() error
@@ -58,12 +114,55 @@ func NewDefaultEnvironment() (*Environment, CleanupFunc, error) {
 
 	zap.ReplaceGlobals(logger)
 
-	db, err := connectToDB(logger, os.Getenv("DATABASE_URL"))
+	tracing, err := newTracerProvider(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create tracer provider: %w", err)
+	}
+
+	db, err := connectToDB(logger, tracing.Tracer(tracerName), os.Getenv("DATABASE_URL"))
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
-	return &Environment{config, logger, db}, func() error {
+	j, err := NewJWT(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create jwt: %w", err)
+	}
+
+	mailer, err := newMailer(config, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create mailer: %w", err)
+	}
+
+	hasher, err := password.NewHasherFromConfig(config.Passwords)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create password hasher: %w", err)
+	}
+
+	publisher, err := newPublisher(config, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create outbox publisher: %w", err)
+	}
+
+	env := &Environment{
+		config:    config,
+		logger:    logger,
+		db:        db,
+		jwt:       j,
+		mailer:    mailer,
+		hasher:    hasher,
+		events:    event.NewDispatcher(logger),
+		publisher: publisher,
+		metrics:   newMetricsRegistry(logger, db),
+		tracing:   tracing,
+	}
+
+	return env, func() error {
+		// Shutting down the tracer provider sends the spans that are still waiting in the batch to the exporter.
+		if err := tracing.Shutdown(context.Background()); err != nil {
+			return fmt.Errorf("unable to shutdown tracer provider: %w", err)
+		}
+
 		return logger.Sync()
 	}, nil
 }
@@ -101,6 +200,13 @@ func NewTestEnvironmentWithLogger(t *testing.T, logger *zap.Logger, createDB boo
 		prevLogger()
 	})
 
+	// Spans are started so that trace ids are available to tests but they are never exported.
+	tracing := sdktrace.NewTracerProvider()
+
+	t.Cleanup(func() {
+		_ = tracing.Shutdown(context.Background())
+	})
+
 	var db *DB
 
 	if createDB {
@@ -109,7 +215,7 @@ func NewTestEnvironmentWithLogger(t *testing.T, logger *zap.Logger, createDB boo
 			t.Fatalf("could not parse DATABASE_URL: %v", err)
 		}
 
-		db, err = connectToDB(logger, pgURL.String())
+		db, err = connectToDB(logger, tracing.Tracer(tracerName), pgURL.String())
 		if err != nil {
 			t.Fatalf("unable to connect to database: %v", err)
 		}
@@ -136,5 +242,55 @@ func NewTestEnvironmentWithLogger(t *testing.T, logger *zap.Logger, createDB boo
 		}
 	}
 
-	return &Environment{config, logger, db}
+	j, err := NewJWT(config)
+	if err != nil {
+		t.Fatalf("unable to create jwt: %v", err)
+	}
+
+	hasher, err := password.NewHasherFromConfig(config.Passwords)
+	if err != nil {
+		t.Fatalf("unable to create password hasher: %v", err)
+	}
+
+	// Tests should never send real emails or publish real messages so the configured drivers are ignored.
+	return &Environment{
+		config:    config,
+		logger:    logger,
+		db:        db,
+		jwt:       j,
+		mailer:    mail.NewLogMailer(logger),
+		hasher:    hasher,
+		events:    event.NewDispatcher(logger),
+		publisher: outbox.NewLogPublisher(logger),
+		metrics:   newMetricsRegistry(logger, db),
+		tracing:   tracing,
+	}
+}
+
+// newMailer creates the mail.Mailer for the configured driver.
+func newMailer(c *Config, logger *zap.Logger) (mail.Mailer, error) {
+	switch c.Mail.Driver {
+	case mail.DriverLog:
+		return mail.NewLogMailer(logger), nil
+	case mail.DriverFile:
+		return mail.NewFileMailer(c.Mail.Directory, c.Mail.From)
+	case mail.DriverSMTP:
+		return mail.NewSMTPMailer(c.Mail.SMTP.Host, c.Mail.SMTP.Port, c.Mail.SMTP.Username, c.SMTPPassword, c.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMailDriver, c.Mail.Driver)
+	}
+}
+
+// newPublisher creates the outbox.Publisher for the configured driver.
+func newPublisher(c *Config, logger *zap.Logger) (outbox.Publisher, error) {
+	switch c.Outbox.Driver {
+	case outbox.DriverLog:
+		return outbox.NewLogPublisher(logger), nil
+	case outbox.DriverWebhook:
+		timeout := c.Outbox.Webhook.Timeout * time.Second
+
+		return outbox.NewWebhookPublisher(c.Outbox.Webhook.URL, c.OutboxWebhookSecret, timeout), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedOutboxDriver, c.Outbox.Driver)
+	}
 }
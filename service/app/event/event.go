@@ -0,0 +1,68 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Event is something that has happened within the domain that other parts of the application may want to
+// react to.
+type Event interface {
+	// Name identifies the kind of Event so that subscribers can choose which events they receive.
+	Name() string
+}
+
+// Handler reacts to an Event that has been dispatched.
+type Handler func(ctx context.Context, e Event) error
+
+// Dispatcher passes events to the handlers that have subscribed to them. Events are handled in process,
+// in the order that they were dispatched, once the change that raised them has been saved.
+type Dispatcher struct {
+	logger      *zap.Logger
+	mu          sync.RWMutex
+	subscribers map[string][]Handler
+}
+
+// NewDispatcher creates a Dispatcher without any subscribers.
+func NewDispatcher(logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{logger: logger, subscribers: make(map[string][]Handler)}
+}
+
+// Subscribe registers the Handler to be called for each dispatched Event with the given name.
+func (d *Dispatcher) Subscribe(name string, h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.subscribers[name] = append(d.subscribers[name], h)
+}
+
+// Dispatch calls the subscribed handlers for each of the events. The change that raised the events has
+// already been saved so a failing Handler is logged rather than stopping the remaining handlers.
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...Event) {
+	for _, e := range events {
+		d.mu.RLock()
+		handlers := d.subscribers[e.Name()]
+		d.mu.RUnlock()
+
+		for _, h := range handlers {
+			if err := d.handle(ctx, h, e); err != nil {
+				d.logger.Error("unable to handle event", zap.String("event", e.Name()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// handle calls the Handler, recovering from a panic so that one Handler can not take down the request
+// that dispatched the Event.
+func (d *Dispatcher) handle(ctx context.Context, h Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return h(ctx, e)
+}
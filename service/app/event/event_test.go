@@ -0,0 +1,47 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app/event"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type testEvent string
+
+func (e testEvent) Name() string {
+	return string(e)
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Parallel()
+
+	d := event.NewDispatcher(zaptest.NewLogger(t))
+
+	var handled []string
+
+	record := func(prefix string) event.Handler {
+		return func(_ context.Context, e event.Event) error {
+			handled = append(handled, prefix+e.Name())
+
+			return nil
+		}
+	}
+
+	d.Subscribe("first", record("a:"))
+	d.Subscribe("first", func(context.Context, event.Event) error {
+		return errors.New("failing handlers do not stop the others")
+	})
+	d.Subscribe("first", func(context.Context, event.Event) error {
+		panic("panicking handlers do not stop the others")
+	})
+	d.Subscribe("first", record("b:"))
+	d.Subscribe("second", record("a:"))
+
+	d.Dispatch(context.Background(), testEvent("first"), testEvent("unsubscribed"), testEvent("second"))
+
+	assert.Equal(t, []string{"a:first", "b:first", "a:second"}, handled)
+}
@@ -0,0 +1,198 @@
+package app_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "test-secret-that-is-at-least-32-characters"
+
+func hs256Config() *app.Config {
+	var c app.Config
+
+	c.JWTSecret = testSecret
+	c.Auth.Algorithm = app.AlgorithmHS256
+	c.Auth.Issuer = "gotemplate"
+	c.Auth.AccessTokenTTL = 900
+
+	return &c
+}
+
+func writeRSAKeys(t *testing.T) (privateFile, publicFile string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate rsa key: %v", err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("unable to marshal public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privateFile = filepath.Join(dir, "private.pem")
+	publicFile = filepath.Join(dir, "public.pem")
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(privateFile, privatePEM, 0o600); err != nil {
+		t.Fatalf("unable to write private key: %v", err)
+	}
+
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	if err := ioutil.WriteFile(publicFile, publicPEM, 0o600); err != nil {
+		t.Fatalf("unable to write public key: %v", err)
+	}
+
+	return privateFile, publicFile
+}
+
+func TestNewJWT(t *testing.T) {
+	t.Parallel()
+
+	t.Run("short secrets are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		c := hs256Config()
+		c.JWTSecret = "too-short"
+
+		_, err := app.NewJWT(c)
+		assert.True(t, errors.Is(err, app.ErrSecretTooShort), err)
+	})
+
+	t.Run("unknown algorithms are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		c := hs256Config()
+		c.Auth.Algorithm = "none"
+
+		_, err := app.NewJWT(c)
+		assert.True(t, errors.Is(err, app.ErrUnsupportedAlgorithm), err)
+	})
+
+	t.Run("missing key files are reported", func(t *testing.T) {
+		t.Parallel()
+
+		c := hs256Config()
+		c.Auth.Algorithm = app.AlgorithmRS256
+		c.Auth.PrivateKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+
+		_, err := app.NewJWT(c)
+		assert.Error(t, err)
+	})
+}
+
+func TestJWTIssueAndVerify(t *testing.T) {
+	t.Parallel()
+
+	privateFile, publicFile := writeRSAKeys(t)
+
+	rs256 := hs256Config()
+	rs256.Auth.Algorithm = app.AlgorithmRS256
+	rs256.Auth.PrivateKeyFile = privateFile
+	rs256.Auth.PublicKeyFile = publicFile
+
+	tests := []struct {
+		name   string
+		config *app.Config
+	}{
+		{name: "HS256", config: hs256Config()},
+		{name: "RS256", config: rs256},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := app.NewJWT(tc.config)
+			if err != nil {
+				t.Fatalf("unable to create jwt: %v", err)
+			}
+
+			token, err := j.Issue("subject")
+			if err != nil {
+				t.Fatalf("unable to issue token: %v", err)
+			}
+
+			claims, err := j.Verify(token)
+			if err != nil {
+				t.Fatalf("unable to verify token: %v", err)
+			}
+
+			assert.Equal(t, "subject", claims.Subject)
+			assert.Equal(t, "gotemplate", claims.Issuer)
+			assert.WithinDuration(t, time.Now().Add(900*time.Second), claims.ExpiresAt.Time, 5*time.Second)
+		})
+	}
+}
+
+func TestJWTVerifyRejectsInvalidTokens(t *testing.T) {
+	t.Parallel()
+
+	j, err := app.NewJWT(hs256Config())
+	if err != nil {
+		t.Fatalf("unable to create jwt: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.RegisteredClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("unable to sign token: %v", err)
+		}
+
+		return token
+	}
+
+	valid := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    "gotemplate",
+			Subject:   "subject",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}
+	}
+
+	expired := valid()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	otherIssuer := valid()
+	otherIssuer.Issuer = "someone-else"
+
+	noSubject := valid()
+	noSubject.Subject = ""
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "malformed", token: "not.a.token"},
+		{name: "expired", token: sign(jwt.SigningMethodHS256, []byte(testSecret), expired)},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodHS256, []byte(testSecret), otherIssuer)},
+		{name: "missing subject", token: sign(jwt.SigningMethodHS256, []byte(testSecret), noSubject)},
+		{name: "wrong secret", token: sign(jwt.SigningMethodHS256, []byte(testSecret+"-other"), valid())},
+		{name: "wrong algorithm", token: sign(jwt.SigningMethodHS512, []byte(testSecret), valid())},
+		{name: "unsigned", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid())},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := j.Verify(tc.token)
+			assert.True(t, errors.Is(err, app.ErrInvalidToken), err)
+		})
+	}
+}
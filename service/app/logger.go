@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/log/zapadapter"
+	"go.uber.org/zap"
+)
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a copy of the context holding the fields along with any it already holds. Loggers
+// created with ContextLogger add the fields to every entry, such as the id of the request being handled.
+func WithLogFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := LogFields(ctx)
+
+	return context.WithValue(ctx, logFieldsKey{}, append(existing[:len(existing):len(existing)], fields...))
+}
+
+// LogFields returns the fields added to the context with WithLogFields.
+func LogFields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(logFieldsKey{}).([]zap.Field)
+
+	return fields
+}
+
+// ContextLogger returns a child of the logger that adds the fields held by the context to every entry.
+func ContextLogger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := LogFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+
+	return logger.With(fields...)
+}
+
+// pgxLogger writes the logs of pgx to a zap.Logger along with the fields of the context that the query was run
+// in so that queries can be traced back to the request that ran them.
+type pgxLogger struct {
+	logger *zap.Logger
+}
+
+func newPGXLogger(logger *zap.Logger) *pgxLogger {
+	// The zapadapter skips its own frame, we skip ours so that the caller is still reported as pgx.
+	return &pgxLogger{logger: logger.WithOptions(zap.AddCallerSkip(1))}
+}
+
+func (l *pgxLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	zapadapter.NewLogger(ContextLogger(ctx, l.logger)).Log(ctx, level, msg, data)
+}
@@ -0,0 +1,40 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextLogger(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+
+	parent := app.WithLogFields(context.Background(), zap.String("request_id", "req-123"))
+	first := app.WithLogFields(parent, zap.String("customer", "first"))
+	second := app.WithLogFields(parent, zap.String("customer", "second"))
+
+	app.ContextLogger(context.Background(), logger).Info("without fields")
+	app.ContextLogger(first, logger).Info("first")
+	app.ContextLogger(second, logger).Info("second")
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+
+	assert.Empty(t, entries[0].Context)
+	assert.Equal(t, map[string]interface{}{"request_id": "req-123", "customer": "first"}, entries[1].ContextMap())
+	assert.Equal(
+		t,
+		map[string]interface{}{"request_id": "req-123", "customer": "second"},
+		entries[2].ContextMap(),
+		"contexts derived from the same parent do not share fields",
+	)
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer drops each message into a directory as an .eml file that can be opened by a mail client.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a FileMailer that writes messages sent by from into dir. The directory is
+// created if it does not exist.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes the message to a new file in the directory. Files are named by the time they were sent
+// so that they list in order.
+func (fm *FileMailer) Send(_ context.Context, m Message) error {
+	b, err := m.Bytes(fm.from)
+	if err != nil {
+		return fmt.Errorf("unable to format message: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.New())
+
+	if err := ioutil.WriteFile(filepath.Join(fm.dir, name), b, 0o600); err != nil {
+		return fmt.Errorf("unable to write message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer writes messages to the log instead of sending them. It is intended for local development
+// and tests where no mail server is available.
+type LogMailer struct {
+	logger *zap.Logger
+}
+
+// NewLogMailer creates a LogMailer that writes to the given logger.
+func NewLogMailer(logger *zap.Logger) *LogMailer {
+	return &LogMailer{logger}
+}
+
+// Send logs the message.
+func (lm *LogMailer) Send(_ context.Context, m Message) error {
+	if m.To == "" {
+		return ErrNoRecipient
+	}
+
+	lm.logger.Info(
+		"mail sent",
+		zap.String("to", m.To),
+		zap.String("subject", m.Subject),
+		zap.String("body", m.Body),
+	)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The drivers that can be configured to send mail.
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+var (
+	ErrNoRecipient   = errors.New("message must have a recipient")
+	ErrInvalidHeader = errors.New("message header must not contain line breaks")
+)
+
+// Mailer sends messages to their recipients.
+type Mailer interface {
+	// Send delivers the message to its recipient.
+	Send(ctx context.Context, m Message) error
+}
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Bytes formats the Message as an RFC 5322 email sent by the given address. An error is returned if
+// the recipient is missing or a header contains a line break, which would allow headers to be injected.
+func (m Message) Bytes(from string) ([]byte, error) {
+	if strings.TrimSpace(m.To) == "" {
+		return nil, ErrNoRecipient
+	}
+
+	headers := [][2]string{
+		{"From", from},
+		{"To", m.To},
+		{"Subject", m.Subject},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `text/plain; charset="UTF-8"`},
+	}
+
+	var b bytes.Buffer
+
+	for _, h := range headers {
+		if strings.ContainsAny(h[1], "\r\n") {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, h[0])
+		}
+
+		fmt.Fprintf(&b, "%s: %s\r\n", h[0], h[1])
+	}
+
+	b.WriteString("\r\n")
+
+	// SMTP requires lines to be terminated by CRLF.
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return b.Bytes(), nil
+}
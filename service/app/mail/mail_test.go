@@ -0,0 +1,119 @@
+package mail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app/mail"
+	"github.com/nickbryan/go-template/service/app/mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMessageBytes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("formats headers and body", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := mail.Message{To: "bob@example.org", Subject: "Hello", Body: "line one\nline two"}.Bytes("app@example.org")
+		if err != nil {
+			t.Fatalf("unable to format message: %v", err)
+		}
+
+		assert.Contains(t, string(b), "From: app@example.org\r\n")
+		assert.Contains(t, string(b), "To: bob@example.org\r\n")
+		assert.Contains(t, string(b), "Subject: Hello\r\n")
+		assert.Contains(t, string(b), "\r\n\r\nline one\r\nline two")
+	})
+
+	t.Run("headers with line breaks are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mail.Message{To: "bob@example.org", Subject: "Hello\r\nBcc: eve@example.org"}.Bytes("app@example.org")
+		assert.True(t, errors.Is(err, mail.ErrInvalidHeader), err)
+	})
+
+	t.Run("messages without a recipient are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mail.Message{Subject: "Hello"}.Bytes("app@example.org")
+		assert.True(t, errors.Is(err, mail.ErrNoRecipient), err)
+	})
+}
+
+func TestLogMailer(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+
+	err := mail.NewLogMailer(zap.New(core)).Send(context.Background(), mail.Message{
+		To:      "bob@example.org",
+		Subject: "Hello",
+		Body:    "Hi Bob",
+	})
+	if err != nil {
+		t.Fatalf("unable to send message: %v", err)
+	}
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "bob@example.org", logs.All()[0].ContextMap()["to"])
+	assert.Equal(t, "Hi Bob", logs.All()[0].ContextMap()["body"])
+}
+
+func TestFileMailer(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := mail.NewFileMailer(dir, "app@example.org")
+	if err != nil {
+		t.Fatalf("unable to create file mailer: %v", err)
+	}
+
+	if err := m.Send(context.Background(), mail.Message{To: "bob@example.org", Subject: "Hello", Body: "Hi Bob"}); err != nil {
+		t.Fatalf("unable to send message: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatalf("unable to list mail directory: %v", err)
+	}
+
+	if !assert.Len(t, files, 1) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unable to read message: %v", err)
+	}
+
+	assert.Contains(t, string(b), "To: bob@example.org\r\n")
+	assert.Contains(t, string(b), "Hi Bob")
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Parallel()
+
+	server := mailtest.NewSMTPServer(t)
+	m := mail.NewSMTPMailer(server.Host(), server.Port(), "", "", "app@example.org")
+
+	if err := m.Send(context.Background(), mail.Message{To: "bob@example.org", Subject: "Hello", Body: "Hi Bob"}); err != nil {
+		t.Fatalf("unable to send message: %v", err)
+	}
+
+	received := server.Received()
+	if !assert.Len(t, received, 1) {
+		return
+	}
+
+	assert.Equal(t, "app@example.org", received[0].From)
+	assert.Equal(t, []string{"bob@example.org"}, received[0].To)
+	assert.Contains(t, received[0].Data, "Subject: Hello\n")
+	assert.Contains(t, received[0].Data, "Hi Bob")
+}
@@ -0,0 +1,32 @@
+package mailtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nickbryan/go-template/service/app/mail"
+)
+
+// Mailer records the messages it is asked to send so that tests can make assertions against them.
+type Mailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+// Send records the message.
+func (m *Mailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns every message that has been sent in the order they were sent.
+func (m *Mailer) Messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]mail.Message(nil), m.messages...)
+}
@@ -0,0 +1,157 @@
+package mailtest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Envelope is a message received by the SMTPServer.
+type Envelope struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPServer is a fake SMTP server listening on a local port. It accepts every message without
+// authentication and records it so that the SMTP mailer can be tested without a real mail server.
+type SMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	received []Envelope
+}
+
+// NewSMTPServer starts an SMTPServer on a random local port. It is closed when the test finishes.
+func NewSMTPServer(t *testing.T) *SMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to start fake smtp server: %v", err)
+	}
+
+	s := &SMTPServer{listener: l}
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	go s.serve()
+
+	return s
+}
+
+// Host is the host the SMTPServer is listening on.
+func (s *SMTPServer) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port is the port the SMTPServer is listening on.
+func (s *SMTPServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Received returns every message that the SMTPServer has accepted.
+func (s *SMTPServer) Received() []Envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Envelope(nil), s.received...)
+}
+
+func (s *SMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *SMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(line string) bool {
+		return tp.PrintfLine("%s", line) == nil
+	}
+
+	if !reply("220 localhost fake smtp server ready") {
+		return
+	}
+
+	var env Envelope
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			if !reply("250 localhost") {
+				return
+			}
+		case "MAIL":
+			env = Envelope{From: addressFrom(line)}
+
+			if !reply("250 OK") {
+				return
+			}
+		case "RCPT":
+			env.To = append(env.To, addressFrom(line))
+
+			if !reply("250 OK") {
+				return
+			}
+		case "DATA":
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			env.Data = string(data)
+
+			s.mu.Lock()
+			s.received = append(s.received, env)
+			s.mu.Unlock()
+
+			if !reply("250 OK") {
+				return
+			}
+		case "RSET", "NOOP":
+			if !reply("250 OK") {
+				return
+			}
+		case "QUIT":
+			reply("221 bye")
+
+			return
+		default:
+			if !reply("502 command not implemented") {
+				return
+			}
+		}
+	}
+}
+
+// addressFrom extracts the address from a MAIL FROM:<address> or RCPT TO:<address> command.
+func addressFrom(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+
+	return line[start+1 : end]
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPMailer sends messages through an SMTP server. The connection is upgraded with STARTTLS when the
+// server supports it.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates an SMTPMailer that sends messages from the given address through the server at
+// host and port. Authentication is only attempted when a username is given.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		auth: auth,
+	}
+}
+
+// Send delivers the message to the SMTP server.
+func (sm *SMTPMailer) Send(_ context.Context, m Message) error {
+	b, err := m.Bytes(sm.from)
+	if err != nil {
+		return fmt.Errorf("unable to format message: %w", err)
+	}
+
+	if err := smtp.SendMail(sm.addr, sm.auth, sm.from, []string{m.To}, b); err != nil {
+		return fmt.Errorf("unable to send message: %w", err)
+	}
+
+	return nil
+}
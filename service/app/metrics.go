@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.uber.org/zap"
+)
+
+// migrationQueryTimeout limits how long a scrape waits for the migration version to be read.
+const migrationQueryTimeout = 5 * time.Second
+
+// newMetricsRegistry creates the registry holding our application wide metrics. It collects the Go runtime and
+// process metrics and, when there is a database, the stats of the connection pool and the migration version.
+func newMetricsRegistry(logger *zap.Logger, db *DB) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	if db != nil {
+		registry.MustRegister(newPoolCollector(db), newMigrationCollector(logger, db))
+	}
+
+	return registry
+}
+
+// poolCollector reports the stats of the database connection pool each time the metrics are gathered.
+type poolCollector struct {
+	db *DB
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	waits        *prometheus.Desc
+	waitDuration *prometheus.Desc
+	canceled     *prometheus.Desc
+}
+
+func newPoolCollector(db *DB) *poolCollector {
+	return &poolCollector{
+		db: db,
+		acquired: prometheus.NewDesc(
+			"db_pool_acquired_connections", "The number of connections currently in use.", nil, nil,
+		),
+		idle: prometheus.NewDesc(
+			"db_pool_idle_connections", "The number of connections currently idle.", nil, nil,
+		),
+		total: prometheus.NewDesc(
+			"db_pool_total_connections", "The number of connections currently open.", nil, nil,
+		),
+		max: prometheus.NewDesc(
+			"db_pool_max_connections", "The maximum number of connections the pool will open.", nil, nil,
+		),
+		acquires: prometheus.NewDesc(
+			"db_pool_acquires_total", "The number of connections acquired from the pool.", nil, nil,
+		),
+		waits: prometheus.NewDesc(
+			"db_pool_waits_total",
+			"The number of acquires that had to wait for a connection because none were idle.",
+			nil,
+			nil,
+		),
+		waitDuration: prometheus.NewDesc(
+			"db_pool_acquire_seconds_total", "The total time spent acquiring connections.", nil, nil,
+		),
+		canceled: prometheus.NewDesc(
+			"db_pool_canceled_acquires_total",
+			"The number of acquires that were canceled before a connection was available.",
+			nil,
+			nil,
+		),
+	}
+}
+
+// Describe sends the descriptors of the pool metrics to the channel.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.waits
+	ch <- c.waitDuration
+	ch <- c.canceled
+}
+
+// Collect sends the current stats of the pool to the channel.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.db.Conn().Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+
+// migrationCollector reports the version of the migrations applied to the database. The version is read each
+// time the metrics are gathered so that it stays correct when the migrations are run by another process.
+type migrationCollector struct {
+	logger  *zap.Logger
+	db      *DB
+	version *prometheus.Desc
+	dirty   *prometheus.Desc
+}
+
+func newMigrationCollector(logger *zap.Logger, db *DB) *migrationCollector {
+	return &migrationCollector{
+		logger: logger,
+		db:     db,
+		version: prometheus.NewDesc(
+			"db_migration_version", "The version of the latest migration applied to the database.", nil, nil,
+		),
+		dirty: prometheus.NewDesc(
+			"db_migration_dirty", "Whether the latest migration failed part way through, 1 if it did.", nil, nil,
+		),
+	}
+}
+
+// Describe sends the descriptors of the migration metrics to the channel.
+func (c *migrationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.version
+	ch <- c.dirty
+}
+
+// Collect sends the current migration version to the channel. Nothing is sent if the database has not been
+// migrated or the version can not be read, in which case the error is logged.
+func (c *migrationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationQueryTimeout)
+	defer cancel()
+
+	var rec struct {
+		Version int64
+		Dirty   bool
+	}
+
+	err := c.db.Get(ctx, &rec, c.db.QB().Select("version", "dirty").From("schema_migrations").Limit(1))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			c.logger.Error("unable to read migration version", zap.Error(err))
+		}
+
+		return
+	}
+
+	dirty := 0.0
+	if rec.Dirty {
+		dirty = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.version, prometheus.GaugeValue, float64(rec.Version))
+	ch <- prometheus.MustNewConstMetric(c.dirty, prometheus.GaugeValue, dirty)
+}
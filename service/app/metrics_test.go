@@ -0,0 +1,41 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without a database", func(t *testing.T) {
+		t.Parallel()
+
+		metrics := app.NewTestEnvironment(t, false).Metrics()
+
+		count, err := testutil.GatherAndCount(metrics, "go_goroutines", "db_pool_acquired_connections")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count, "only the runtime metrics are gathered")
+	})
+
+	t.Run("with a database", func(t *testing.T) {
+		t.Parallel()
+
+		metrics := app.NewTestEnvironment(t, true).Metrics()
+
+		count, err := testutil.GatherAndCount(
+			metrics,
+			"go_goroutines",
+			"db_pool_acquired_connections",
+			"db_pool_idle_connections",
+			"db_pool_waits_total",
+			"db_migration_version",
+			"db_migration_dirty",
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, count)
+	})
+}
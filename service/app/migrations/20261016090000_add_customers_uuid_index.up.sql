@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS customers_uuid_idx ON customers (uuid);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS customers_deleted_at_idx;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    family_uuid UUID NOT NULL,
+    customer_uuid UUID NOT NULL REFERENCES customers (uuid) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_uuid_idx ON refresh_tokens (family_uuid);
+CREATE INDEX IF NOT EXISTS refresh_tokens_customer_uuid_idx ON refresh_tokens (customer_uuid);
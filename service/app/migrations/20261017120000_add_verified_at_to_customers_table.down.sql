@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS customer_verifications;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,14 @@
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS customer_verifications (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    customer_uuid UUID NOT NULL REFERENCES customers (uuid) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS customer_verifications_token_hash_idx ON customer_verifications (token_hash);
+CREATE INDEX IF NOT EXISTS customer_verifications_customer_uuid_idx ON customer_verifications (customer_uuid, created_at);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS roles_name_idx ON roles (name);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS customer_roles (
+    customer_uuid UUID NOT NULL REFERENCES customers (uuid) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (customer_uuid, role_id)
+);
+
+INSERT INTO roles (name, created_at) VALUES ('admin', NOW()) ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles AS r, (VALUES ('customers:manage'), ('roles:manage'), ('logins:unlock')) AS p (permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
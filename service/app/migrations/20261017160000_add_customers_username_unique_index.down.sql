@@ -0,0 +1 @@
+DROP INDEX IF EXISTS customers_username_idx;
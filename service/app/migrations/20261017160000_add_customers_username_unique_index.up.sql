@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS customers_username_idx ON customers (username) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS customers_username_key_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS customers_username_idx ON customers (username) WHERE deleted_at IS NULL;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS username_key;
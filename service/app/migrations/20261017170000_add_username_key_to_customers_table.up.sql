@@ -0,0 +1,31 @@
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS username_key VARCHAR(255)
+    GENERATED ALWAYS AS (lower(normalize(btrim(username, E' \t\n\r\f\v'), NFC))) STORED;
+
+-- Customers that only differ by the case of their username must be merged or renamed by hand before the
+-- unique index can be created, so we fail with the conflicting usernames rather than picking one.
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(username_key, ', ') INTO conflicts FROM (
+        SELECT username_key FROM customers WHERE deleted_at IS NULL GROUP BY username_key HAVING count(*) > 1
+    ) AS duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'customers share usernames that only differ by case: %', conflicts;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS customers_username_idx;
+
+-- Usernames are stored normalized from now on, matching customer.NormalizeUsername: trimmed, NFC normalized
+-- and with a lower case domain.
+UPDATE customers SET username = CASE
+    WHEN strpos(normalized.username, '@') > 0
+        THEN substring(normalized.username FROM '^(.*@)') || lower(substring(normalized.username FROM '^.*@(.*)$'))
+    ELSE normalized.username
+END
+FROM (SELECT id, normalize(btrim(username, E' \t\n\r\f\v'), NFC) AS username FROM customers) AS normalized
+WHERE customers.id = normalized.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS customers_username_key_idx ON customers (username_key) WHERE deleted_at IS NULL;
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    code VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('asset', 'liability', 'revenue', 'expense')),
+    customer_uuid UUID NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_accounts_uuid_idx ON ledger_accounts (uuid);
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_accounts_code_idx ON ledger_accounts (code);
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_accounts_customer_uuid_idx ON ledger_accounts (customer_uuid);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entries_uuid_idx ON ledger_entries (uuid);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    entry_uuid UUID NOT NULL REFERENCES ledger_entries (uuid),
+    account_uuid UUID NOT NULL REFERENCES ledger_accounts (uuid),
+    amount BIGINT NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_postings_entry_uuid_idx ON ledger_postings (entry_uuid);
+CREATE INDEX IF NOT EXISTS ledger_postings_account_uuid_idx ON ledger_postings (account_uuid, id);
+
+-- Recorded entries are never changed, mistakes are corrected with a reversing entry.
+CREATE OR REPLACE FUNCTION ledger_prevent_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_prevent_change();
+
+CREATE TRIGGER ledger_postings_immutable BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_prevent_change();
+
+-- The postings of an entry are checked once the transaction commits so that they can be inserted one by one.
+CREATE OR REPLACE FUNCTION ledger_check_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_postings WHERE entry_uuid = NEW.entry_uuid) <> 0 THEN
+        RAISE EXCEPTION 'ledger entry % does not balance', NEW.entry_uuid;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
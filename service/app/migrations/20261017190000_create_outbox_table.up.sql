@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    aggregate_type VARCHAR(64) NOT NULL,
+    aggregate_uuid UUID NOT NULL,
+    event VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NULL,
+    last_error TEXT NULL,
+    published_at TIMESTAMP NULL,
+    dead_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_uuid_idx ON outbox (uuid);
+
+-- Only pending messages are searched when relaying so they are indexed by aggregate to keep the ordering
+-- check cheap.
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (aggregate_type, aggregate_uuid, id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission = 'personal_data:manage';
+
+DROP TABLE IF EXISTS personal_data_requests;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS erased_at;
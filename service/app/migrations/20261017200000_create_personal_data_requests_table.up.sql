@@ -0,0 +1,24 @@
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS personal_data_requests (
+    id SERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    customer_uuid UUID NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('export', 'erasure')),
+    requested_by_uuid UUID NOT NULL,
+    sources TEXT[] NOT NULL,
+    completed_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS personal_data_requests_uuid_idx ON personal_data_requests (uuid);
+CREATE INDEX IF NOT EXISTS personal_data_requests_customer_uuid_idx ON personal_data_requests (customer_uuid);
+
+-- Requests are the proof that personal data was exported or erased so, like the ledger, they are never changed.
+CREATE TRIGGER personal_data_requests_immutable BEFORE UPDATE OR DELETE ON personal_data_requests
+    FOR EACH ROW EXECUTE FUNCTION ledger_prevent_change();
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'personal_data:manage'
+FROM roles AS r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
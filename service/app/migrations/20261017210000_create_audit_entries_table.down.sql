@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+
+DROP TABLE IF EXISTS audit_entries;
+
+DROP FUNCTION IF EXISTS audit_entries_append_only();
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID NOT NULL,
+    actor_uuid UUID NULL,
+    action VARCHAR(64) NOT NULL,
+    resource_type VARCHAR(64) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    changes JSONB NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    redacted_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS audit_entries_uuid_idx ON audit_entries (uuid);
+CREATE INDEX IF NOT EXISTS audit_entries_actor_uuid_idx ON audit_entries (actor_uuid, id);
+CREATE INDEX IF NOT EXISTS audit_entries_resource_idx ON audit_entries (resource_type, resource_id, id);
+CREATE INDEX IF NOT EXISTS audit_entries_occurred_at_idx ON audit_entries (occurred_at);
+
+-- Entries are append only. The only change allowed is redacting the values of an entry, once, when the personal
+-- data of the customer it is about is erased.
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND OLD.redacted_at IS NULL
+        AND NEW.redacted_at IS NOT NULL
+        AND (NEW.uuid, NEW.actor_uuid, NEW.action, NEW.resource_type, NEW.resource_id, NEW.request_id, NEW.occurred_at)
+            IS NOT DISTINCT FROM
+            (OLD.uuid, OLD.actor_uuid, OLD.action, OLD.resource_type, OLD.resource_id, OLD.request_id, OLD.occurred_at)
+        AND (SELECT array_agg(key ORDER BY key) FROM jsonb_object_keys(NEW.changes) AS key)
+            IS NOT DISTINCT FROM
+            (SELECT array_agg(key ORDER BY key) FROM jsonb_object_keys(OLD.changes) AS key)
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION '% is append only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'audit:read'
+FROM roles AS r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE customer_verifications DROP COLUMN IF EXISTS username_key;
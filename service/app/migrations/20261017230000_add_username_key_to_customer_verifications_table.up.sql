@@ -0,0 +1,11 @@
+ALTER TABLE customer_verifications ADD COLUMN IF NOT EXISTS username_key VARCHAR(255) NULL;
+
+-- Tokens issued before now can not be tied to the username they were sent to, so outstanding ones are expired
+-- and customers have to ask for another.
+UPDATE customer_verifications SET expires_at = NOW() WHERE used_at IS NULL AND expires_at > NOW();
+
+UPDATE customer_verifications SET username_key = customers.username_key
+FROM customers
+WHERE customer_verifications.customer_uuid = customers.uuid;
+
+ALTER TABLE customer_verifications ALTER COLUMN username_key SET NOT NULL;
@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogPublisher writes messages to the application log instead of publishing them. It is intended for
+// local development.
+type LogPublisher struct {
+	logger *zap.Logger
+}
+
+// NewLogPublisher creates a LogPublisher that writes to the given logger.
+func NewLogPublisher(logger *zap.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+// Publish writes the Message to the log.
+func (p *LogPublisher) Publish(_ context.Context, m Message) error {
+	p.logger.Info(
+		"published outbox message",
+		zap.String("message", m.ID.String()),
+		zap.String("aggregate_type", m.AggregateType),
+		zap.String("aggregate_id", m.AggregateID.String()),
+		zap.String("event", m.Event),
+		zap.ByteString("payload", m.Payload),
+	)
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/event"
+	"go.uber.org/zap"
+)
+
+// The drivers that can be configured to publish messages.
+const (
+	DriverLog     = "log"
+	DriverWebhook = "webhook"
+)
+
+var ErrUnexpectedStatus = errors.New("unexpected response status")
+
+// Message is an event that has been written to the outbox in the same transaction as the change that raised
+// it, waiting to be published.
+type Message struct {
+	ID            uuid.UUID       `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+
+	// Attempts is the number of times publishing the Message has failed.
+	Attempts int `json:"-"`
+}
+
+// NewMessage creates a Message for the event raised by the aggregate with the given type and id.
+func NewMessage(aggregateType string, aggregateID uuid.UUID, e event.Event) (Message, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return Message{}, fmt.Errorf("unable to marshal %s event: %w", e.Name(), err)
+	}
+
+	return Message{
+		ID:            uuid.New(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Event:         e.Name(),
+		Payload:       payload,
+		OccurredAt:    time.Now(),
+	}, nil
+}
+
+// Publisher delivers messages to the systems that integrate with us. A Message may be published more than
+// once if the Relay fails before recording that it was published so receivers should use its id to ignore
+// duplicates.
+type Publisher interface {
+	// Publish delivers the Message, returning an error if it should be retried.
+	Publish(ctx context.Context, m Message) error
+}
+
+// Store holds the messages waiting in the outbox.
+type Store interface {
+	// Claim hides up to limit messages that are due to be published from other relays until the given time
+	// and returns them. Only the oldest pending Message of each aggregate is claimed so that the messages
+	// of an aggregate are published in the order they were written.
+	Claim(ctx context.Context, limit int, until time.Time) ([]Message, error)
+
+	// MarkPublished records that the Message with the given id has been published.
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+
+	// MarkFailed records a failed attempt at publishing the Message with the given id, which is retried
+	// at the given time.
+	MarkFailed(ctx context.Context, id uuid.UUID, retryAt time.Time, reason string) error
+
+	// MarkDead moves the Message with the given id to the dead letters, it will not be retried again.
+	MarkDead(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// RetryPolicy decides how failed messages are retried. The delay between attempts starts at BaseDelay and
+// doubles up to MaxDelay. A Message is dead lettered after MaxAttempts failed attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay returns how long to wait before retrying a Message that has failed the given number of times.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
+
+// Relay moves messages from the Store to the Publisher.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	policy    RetryPolicy
+	batchSize int
+	lease     time.Duration
+	logger    *zap.Logger
+}
+
+// NewRelay creates a Relay that publishes batches of up to batchSize messages. Claimed messages are hidden
+// from other relays for the lease, which must be longer than it takes to publish a batch.
+func NewRelay(
+	store Store,
+	publisher Publisher,
+	policy RetryPolicy,
+	batchSize int,
+	lease time.Duration,
+	logger *zap.Logger,
+) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		policy:    policy,
+		batchSize: batchSize,
+		lease:     lease,
+		logger:    logger,
+	}
+}
+
+// Run relays messages until the context is cancelled, waiting for the interval whenever the outbox has
+// nothing left to publish.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		claimed, err := r.RelayOnce(ctx)
+		if err != nil {
+			r.logger.Error("unable to relay outbox", zap.Error(err))
+		}
+
+		// A full batch means there are likely more messages waiting so we carry straight on.
+		if err == nil && claimed == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RelayOnce claims a batch of messages and publishes them, returning the number of messages claimed. A
+// Message that fails is retried according to the RetryPolicy.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	messages, err := r.store.Claim(ctx, r.batchSize, time.Now().Add(r.lease))
+	if err != nil {
+		return 0, fmt.Errorf("unable to claim outbox messages: %w", err)
+	}
+
+	for _, m := range messages {
+		if err := r.relay(ctx, m); err != nil {
+			return len(messages), err
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (r *Relay) relay(ctx context.Context, m Message) error {
+	err := r.publisher.Publish(ctx, m)
+	if err == nil {
+		return r.store.MarkPublished(ctx, m.ID)
+	}
+
+	attempts := m.Attempts + 1
+	fields := []zap.Field{
+		zap.String("message", m.ID.String()),
+		zap.String("event", m.Event),
+		zap.Int("attempts", attempts),
+		zap.Error(err),
+	}
+
+	if attempts >= r.policy.MaxAttempts {
+		r.logger.Error("dead lettering outbox message", fields...)
+
+		return r.store.MarkDead(ctx, m.ID, err.Error())
+	}
+
+	r.logger.Warn("unable to publish outbox message", fields...)
+
+	return r.store.MarkFailed(ctx, m.ID, time.Now().Add(r.policy.Delay(attempts)), err.Error())
+}
@@ -0,0 +1,212 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/outbox"
+	"github.com/nickbryan/go-template/service/app/outbox/outboxtest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type testEvent struct {
+	Value string `json:"value"`
+}
+
+func (testEvent) Name() string {
+	return "test.happened"
+}
+
+// memoryStore is an outbox.Store that claims every pending message that is due.
+type memoryStore struct {
+	mu        sync.Mutex
+	messages  []outbox.Message
+	retryAt   map[uuid.UUID]time.Time
+	published map[uuid.UUID]bool
+	dead      map[uuid.UUID]string
+}
+
+func newMemoryStore(messages ...outbox.Message) *memoryStore {
+	return &memoryStore{
+		messages:  messages,
+		retryAt:   make(map[uuid.UUID]time.Time),
+		published: make(map[uuid.UUID]bool),
+		dead:      make(map[uuid.UUID]string),
+	}
+}
+
+func (s *memoryStore) Claim(_ context.Context, limit int, _ time.Time) ([]outbox.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []outbox.Message
+
+	for _, m := range s.messages {
+		if len(claimed) == limit {
+			break
+		}
+
+		if s.published[m.ID] || s.dead[m.ID] != "" || s.retryAt[m.ID].After(time.Now()) {
+			continue
+		}
+
+		claimed = append(claimed, m)
+	}
+
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkPublished(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.published[id] = true
+
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(_ context.Context, id uuid.UUID, retryAt time.Time, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retryAt[id] = retryAt
+
+	for i := range s.messages {
+		if s.messages[i].ID == id {
+			s.messages[i].Attempts++
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) MarkDead(_ context.Context, id uuid.UUID, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dead[id] = reason
+
+	return nil
+}
+
+func newMessage(t *testing.T, value string) outbox.Message {
+	t.Helper()
+
+	m, err := outbox.NewMessage("test", uuid.New(), testEvent{Value: value})
+	if err != nil {
+		t.Fatalf("unable to create message: %v", err)
+	}
+
+	return m
+}
+
+func TestNewMessage(t *testing.T) {
+	t.Parallel()
+
+	aggregateID := uuid.New()
+
+	m, err := outbox.NewMessage("test", aggregateID, testEvent{Value: "payload"})
+	if err != nil {
+		t.Fatalf("unable to create message: %v", err)
+	}
+
+	assert.Equal(t, "test", m.AggregateType)
+	assert.Equal(t, aggregateID, m.AggregateID)
+	assert.Equal(t, "test.happened", m.Event)
+	assert.JSONEq(t, `{"value":"payload"}`, string(m.Payload))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := outbox.RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	testCases := map[string]struct {
+		attempts int
+		want     time.Duration
+	}{
+		"first failure waits for the base delay": {attempts: 1, want: time.Second},
+		"delay doubles for each failure":         {attempts: 3, want: 4 * time.Second},
+		"delay is capped at the max delay":       {attempts: 4, want: 5 * time.Second},
+		"large attempts do not overflow":         {attempts: 100, want: 5 * time.Second},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, policy.Delay(tc.attempts))
+		})
+	}
+}
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	policy := outbox.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond}
+
+	t.Run("published messages are marked as published", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := newMessage(t, "first"), newMessage(t, "second")
+		store := newMemoryStore(first, second)
+		publisher := &outboxtest.Publisher{}
+
+		relay := outbox.NewRelay(store, publisher, policy, 10, time.Minute, zaptest.NewLogger(t))
+
+		claimed, err := relay.RelayOnce(ctx)
+		if err != nil {
+			t.Fatalf("unable to relay: %v", err)
+		}
+
+		assert.Equal(t, 2, claimed)
+		assert.Equal(t, []outbox.Message{first, second}, publisher.Published())
+		assert.True(t, store.published[first.ID])
+		assert.True(t, store.published[second.ID])
+	})
+
+	t.Run("failed messages are retried and then dead lettered", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMessage(t, "failing")
+		store := newMemoryStore(m)
+		publisher := &outboxtest.Publisher{}
+		publisher.FailWith(m.Event, errors.New("receiver unavailable"))
+
+		relay := outbox.NewRelay(store, publisher, policy, 10, time.Minute, zaptest.NewLogger(t))
+
+		for i := 0; i < 3; i++ {
+			if _, err := relay.RelayOnce(ctx); err != nil {
+				t.Fatalf("unable to relay: %v", err)
+			}
+		}
+
+		assert.Empty(t, publisher.Published())
+		assert.Equal(t, "receiver unavailable", store.dead[m.ID])
+		assert.Equal(t, 1, store.messages[0].Attempts)
+	})
+
+	t.Run("run stops when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMessage(t, "run")
+		store := newMemoryStore(m)
+		publisher := &outboxtest.Publisher{}
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		relay := outbox.NewRelay(store, publisher, policy, 10, time.Minute, zaptest.NewLogger(t))
+
+		assert.NoError(t, relay.Run(ctx, time.Millisecond))
+		assert.Equal(t, []outbox.Message{m}, publisher.Published())
+	})
+}
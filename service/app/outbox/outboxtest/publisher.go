@@ -0,0 +1,50 @@
+package outboxtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nickbryan/go-template/service/app/outbox"
+)
+
+// Publisher records the messages it is asked to publish so that tests can make assertions against them.
+type Publisher struct {
+	mu        sync.Mutex
+	published []outbox.Message
+	failures  map[string]error
+}
+
+// FailWith makes every attempt to publish a message for the given event return err until it is called
+// again with a nil error.
+func (p *Publisher) FailWith(event string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures == nil {
+		p.failures = make(map[string]error)
+	}
+
+	p.failures[event] = err
+}
+
+// Publish records the message unless FailWith has been called for its event.
+func (p *Publisher) Publish(_ context.Context, m outbox.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.failures[m.Event]; err != nil {
+		return err
+	}
+
+	p.published = append(p.published, m)
+
+	return nil
+}
+
+// Published returns every message that has been published in the order they were published.
+func (p *Publisher) Published() []outbox.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]outbox.Message(nil), p.published...)
+}
@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader holds the hex encoded HMAC-SHA256 of the request body when a WebhookPublisher has a secret
+// so that receivers can check that a Message came from us.
+const SignatureHeader = "X-Outbox-Signature"
+
+// WebhookPublisher publishes messages by POSTing them as JSON to a url. Any response other than a 2xx is
+// treated as a failure so that the Message is retried.
+type WebhookPublisher struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a WebhookPublisher that posts to the url. Requests are signed with the secret
+// unless it is empty and give up after the timeout.
+func NewWebhookPublisher(url, secret string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{url: url, secret: secret, client: &http.Client{Timeout: timeout}}
+}
+
+// Publish posts the Message to the webhook.
+func (p *WebhookPublisher) Publish(ctx context.Context, m Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("unable to marshal outbox message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if p.secret != "" {
+		mac := hmac.New(sha256.New, []byte(p.secret))
+		_, _ = mac.Write(body)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send webhook request: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with %d: %w", resp.StatusCode, ErrUnexpectedStatus)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package outbox_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("posts signed messages", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMessage(t, "webhook")
+
+		var (
+			body      []byte
+			signature string
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = ioutil.ReadAll(r.Body)
+			signature = r.Header.Get(outbox.SignatureHeader)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		publisher := outbox.NewWebhookPublisher(server.URL, "secret", time.Second)
+
+		if err := publisher.Publish(context.Background(), m); err != nil {
+			t.Fatalf("unable to publish message: %v", err)
+		}
+
+		var received outbox.Message
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Fatalf("unable to unmarshal message: %v", err)
+		}
+
+		assert.Equal(t, m.ID, received.ID)
+		assert.Equal(t, m.Event, received.Event)
+		assert.JSONEq(t, string(m.Payload), string(received.Payload))
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write(body)
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), signature)
+	})
+
+	t.Run("error responses fail", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(outbox.SignatureHeader))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		publisher := outbox.NewWebhookPublisher(server.URL, "", time.Second)
+
+		err := publisher.Publish(context.Background(), newMessage(t, "webhook"))
+		assert.True(t, errors.Is(err, outbox.ErrUnexpectedStatus), err)
+	})
+}
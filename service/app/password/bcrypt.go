@@ -0,0 +1,40 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt at the given cost.
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash returns a new bcrypt hash of the password.
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", fmt.Errorf("unable to hash password with bcrypt: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Recognises returns true if the hash is a bcrypt hash.
+func (b Bcrypt) Recognises(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Verify returns true if the bcrypt hash is a hash of the password.
+func (b Bcrypt) Verify(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsCurrent returns true if the bcrypt hash was made with the configured cost.
+func (b Bcrypt) IsCurrent(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+
+	return err == nil && cost == b.Cost
+}
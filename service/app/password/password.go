@@ -0,0 +1,90 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The algorithms that can be configured for hashing passwords.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported password hashing algorithm")
+	ErrMalformedHash        = errors.New("password hash is malformed")
+)
+
+// Algorithm hashes passwords in a self describing format so that hashes made by different algorithms
+// and parameters can be told apart.
+type Algorithm interface {
+	// Hash returns a new salted hash of the password.
+	Hash(password string) (string, error)
+
+	// Recognises returns true if the hash was made by this Algorithm with any parameters.
+	Recognises(hash string) bool
+
+	// Verify returns true if the hash is a hash of the password.
+	Verify(hash, password string) bool
+
+	// IsCurrent returns true if the hash was made with the parameters the Algorithm is configured with.
+	IsCurrent(hash string) bool
+}
+
+// Hasher hashes new passwords with its preferred Algorithm and verifies passwords hashed by any of the
+// Algorithms it knows about. This allows stored hashes to be moved to a new algorithm or parameters as
+// customers login rather than forcing every customer to reset their password.
+type Hasher struct {
+	preferred Algorithm
+	known     []Algorithm
+}
+
+// NewHasher creates a Hasher that hashes with preferred and can also verify hashes made by legacy.
+func NewHasher(preferred Algorithm, legacy ...Algorithm) *Hasher {
+	return &Hasher{
+		preferred: preferred,
+		known:     append([]Algorithm{preferred}, legacy...),
+	}
+}
+
+// Config holds the parameters for each of the supported algorithms.
+type Config struct {
+	Algorithm string
+	Bcrypt    Bcrypt
+	Argon2id  Argon2id
+}
+
+// NewHasherFromConfig creates a Hasher that prefers the configured algorithm and can verify hashes made
+// by any supported algorithm.
+func NewHasherFromConfig(c Config) (*Hasher, error) {
+	switch c.Algorithm {
+	case AlgorithmBcrypt:
+		return NewHasher(c.Bcrypt, c.Argon2id), nil
+	case AlgorithmArgon2id:
+		return NewHasher(c.Argon2id, c.Bcrypt), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, c.Algorithm)
+	}
+}
+
+// Hash returns a new hash of the password made with the preferred Algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify returns true if the hash is a hash of the password made by any known Algorithm.
+func (h *Hasher) Verify(hash, password string) bool {
+	for _, a := range h.known {
+		if a.Recognises(hash) {
+			return a.Verify(hash, password)
+		}
+	}
+
+	return false
+}
+
+// NeedsRehash returns true if the hash was not made by the preferred Algorithm with its current parameters.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	return !h.preferred.Recognises(hash) || !h.preferred.IsCurrent(hash)
+}
@@ -0,0 +1,111 @@
+package password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app/password"
+	"github.com/stretchr/testify/assert"
+)
+
+// Parameters are kept low so that the tests run quickly.
+var (
+	testBcrypt   = password.Bcrypt{Cost: 4}                                                                      //nolint:gochecknoglobals
+	testArgon2id = password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32} //nolint:gochecknoglobals
+)
+
+func TestAlgorithms(t *testing.T) {
+	t.Parallel()
+
+	for name, a := range map[string]password.Algorithm{"bcrypt": testBcrypt, "argon2id": testArgon2id} {
+		a := a
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hash, err := a.Hash("S3cr3tP4ss")
+			if err != nil {
+				t.Fatalf("unable to hash password: %v", err)
+			}
+
+			other, err := a.Hash("S3cr3tP4ss")
+			if err != nil {
+				t.Fatalf("unable to hash password: %v", err)
+			}
+
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+			assert.True(t, a.Recognises(hash))
+			assert.True(t, a.Verify(hash, "S3cr3tP4ss"))
+			assert.False(t, a.Verify(hash, "wrong-password"))
+			assert.True(t, a.IsCurrent(hash))
+		})
+	}
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	t.Parallel()
+
+	hash, err := testArgon2id.Hash("S3cr3tP4ss")
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+	assert.False(t, testArgon2id.Verify("$argon2id$v=19$m=1024,t=1,p=1$not-base64!$", "S3cr3tP4ss"))
+
+	stronger := testArgon2id
+	stronger.Iterations = 2
+
+	assert.False(t, stronger.IsCurrent(hash))
+	assert.True(t, stronger.Verify(hash, "S3cr3tP4ss"), "hashes must be verified with their own parameters")
+}
+
+func TestHasher(t *testing.T) {
+	t.Parallel()
+
+	legacy, err := testBcrypt.Hash("S3cr3tP4ss")
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+
+	h := password.NewHasher(testArgon2id, testBcrypt)
+
+	hash, err := h.Hash("S3cr3tP4ss")
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+
+	assert.True(t, testArgon2id.Recognises(hash))
+	assert.True(t, h.Verify(hash, "S3cr3tP4ss"))
+	assert.False(t, h.NeedsRehash(hash))
+
+	assert.True(t, h.Verify(legacy, "S3cr3tP4ss"))
+	assert.False(t, h.Verify(legacy, "wrong-password"))
+	assert.True(t, h.NeedsRehash(legacy))
+
+	assert.True(t, password.NewHasher(password.Bcrypt{Cost: 5}).NeedsRehash(legacy))
+	assert.False(t, password.NewHasher(testArgon2id).Verify(legacy, "S3cr3tP4ss"), "unknown algorithms are rejected")
+}
+
+func TestNewHasherFromConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := password.NewHasherFromConfig(password.Config{Algorithm: "md5"})
+	assert.True(t, errors.Is(err, password.ErrUnsupportedAlgorithm), err)
+
+	h, err := password.NewHasherFromConfig(password.Config{
+		Algorithm: password.AlgorithmArgon2id,
+		Bcrypt:    testBcrypt,
+		Argon2id:  testArgon2id,
+	})
+	if err != nil {
+		t.Fatalf("unable to create hasher: %v", err)
+	}
+
+	hash, err := h.Hash("S3cr3tP4ss")
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+
+	assert.True(t, testArgon2id.Recognises(hash))
+}
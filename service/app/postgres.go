@@ -6,26 +6,70 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	qb "github.com/Masterminds/squirrel"
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/log/zapadapter"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // DB is our encapsulated database environment.
 type DB struct {
-	conn *pgxpool.Pool
+	conn *Pool
 	qb   qb.StatementBuilderType
 }
 
+// Pool is the underlying postgres connection pool. Statements run with Exec are traced as a child of the span
+// in their context.
+type Pool struct {
+	*pgxpool.Pool
+	tracer trace.Tracer
+}
+
+// Exec runs the sql within a span. The sql is run in the transaction started by DB.InTransaction if the context
+// has one.
+func (p *Pool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startQuerySpan(ctx, p.tracer, sql)
+
+	var (
+		tag pgconn.CommandTag
+		err error
+	)
+
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		tag, err = tx.Exec(ctx, sql, args...)
+	} else {
+		tag, err = p.Pool.Exec(ctx, sql, args...)
+	}
+
+	endQuerySpan(span, err)
+
+	return tag, err
+}
+
+// txKey holds the transaction started by DB.InTransaction in a context.
+type txKey struct{}
+
+// querier returns the transaction started by DB.InTransaction if the context has one, otherwise the pool.
+func (p *Pool) querier(ctx context.Context) pgxscan.Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return p.Pool
+}
+
 // Conn gives us access to the underlying postgres connection.
-func (db *DB) Conn() *pgxpool.Pool {
+func (db *DB) Conn() *Pool {
 	return db.conn
 }
 
@@ -41,7 +85,12 @@ func (db *DB) Select(ctx context.Context, dst interface{}, query qb.Sqlizer) err
 		return fmt.Errorf("unable to convert query to SQL: %w", err)
 	}
 
-	return pgxscan.Select(ctx, db.Conn(), dst, sql, args...)
+	ctx, span := startQuerySpan(ctx, db.conn.tracer, sql)
+
+	err = pgxscan.Select(ctx, db.conn.querier(ctx), dst, sql, args...)
+	endQuerySpan(span, err)
+
+	return err
 }
 
 // Get is a convenience method for scanning one result of the given query into the dst.
@@ -51,16 +100,89 @@ func (db *DB) Get(ctx context.Context, dst interface{}, query qb.Sqlizer) error
 		return fmt.Errorf("unable to convert query to SQL: %w", err)
 	}
 
-	return pgxscan.Get(ctx, db.Conn(), dst, sql, args...)
+	ctx, span := startQuerySpan(ctx, db.conn.tracer, sql)
+
+	err = pgxscan.Get(ctx, db.conn.querier(ctx), dst, sql, args...)
+	endQuerySpan(span, err)
+
+	return err
+}
+
+// Transaction runs fn within a database transaction. The transaction is committed if fn succeeds
+// and rolled back if it returns an error. When the context already has a transaction from InTransaction
+// fn runs within a savepoint of it instead, so that it is only committed along with the outer transaction.
+func (db *DB) Transaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	begin := db.Conn().Begin
+	if outer, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		begin = outer.Begin
+	}
+
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	// Rolling back a committed transaction is a no-op so this only takes effect if fn fails.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// InTransaction runs fn within a database transaction like Transaction. Every statement run through the DB with
+// the context given to fn joins the transaction so that changes made by separate repositories are committed
+// together, or not at all.
+func (db *DB) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.Transaction(ctx, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// startQuerySpan starts a span for running the sql. The span is named after the operation, such as SELECT,
+// rather than the whole statement which is added as an attribute instead.
+func startQuerySpan(ctx context.Context, tracer trace.Tracer, sql string) (context.Context, trace.Span) {
+	operation := sql
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	return tracer.Start(
+		ctx,
+		"postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBStatementKey.String(sql),
+		),
+	)
+}
+
+// endQuerySpan ends the span of a query, recording the error it failed with. pgx.ErrNoRows is not recorded as
+// finding nothing is not a failure of the query.
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
 }
 
-func connectToDB(logger *zap.Logger, dbURL string) (*DB, error) {
+func connectToDB(logger *zap.Logger, tracer trace.Tracer, dbURL string) (*DB, error) {
 	conf, err := pgxpool.ParseConfig(dbURL)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse connection string config: %w", err)
 	}
 
-	conf.ConnConfig.Logger = zapadapter.NewLogger(logger)
+	conf.ConnConfig.Logger = newPGXLogger(logger)
 	conf.ConnConfig.LogLevel = pgx.LogLevelDebug
 	conf.MaxConns = 4
 
@@ -70,7 +192,7 @@ func connectToDB(logger *zap.Logger, dbURL string) (*DB, error) {
 	}
 
 	return &DB{
-		conn: pool,
+		conn: &Pool{Pool: pool, tracer: tracer},
 		qb:   qb.StatementBuilder.PlaceholderFormat(qb.Dollar),
 	}, nil
 }
@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// Length is the number of random bytes in a generated secret. 32 bytes gives 256 bits of
+// entropy which can not be guessed or brute forced.
+const Length = 32
+
+var ErrGenerate = errors.New("unable to generate secret")
+
+// Generate creates a new random secret that is safe to use in URLs and headers.
+func Generate() (string, error) {
+	b := make([]byte, Length)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", ErrGenerate
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex encoded SHA-256 hash of the secret. Generated secrets have enough
+// entropy that a fast hash is safe to use, and unlike bcrypt it allows the hash to be looked
+// up in an index. Secrets should only ever be stored in their hashed form.
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,33 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	a, err := secret.Generate()
+	if err != nil {
+		t.Fatalf("unable to generate secret: %v", err)
+	}
+
+	b, err := secret.Generate()
+	if err != nil {
+		t.Fatalf("unable to generate secret: %v", err)
+	}
+
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, secret.Hash("secret"), secret.Hash("secret"))
+	assert.NotEqual(t, secret.Hash("secret"), secret.Hash("other"))
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", secret.Hash("secret"))
+}
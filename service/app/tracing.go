@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// The exporters that spans can be sent to.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// tracerName identifies the spans started by the app package, such as those for database queries.
+const tracerName = "github.com/nickbryan/go-template/service/app"
+
+// newTracerProvider creates the sdktrace.TracerProvider that sends sampled spans to the configured exporter.
+// Spans are still started, and carry trace ids, when there is no exporter but they are not sent anywhere.
+func newTracerProvider(c *Config) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		// Requests that carry a sampled traceparent are always sampled so that their traces are complete.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.Tracing.SampleRate))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(c.Tracing.ServiceName),
+		)),
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone:
+	case TracingExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create stdout exporter: %w", err)
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case TracingExporterOTLP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Tracing.OTLP.Endpoint)}
+		if c.Tracing.OTLP.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create otlp exporter: %w", err)
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedTracingExporter, c.Tracing.Exporter)
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
@@ -0,0 +1,51 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueriesAreTraced(t *testing.T) {
+	t.Parallel()
+
+	e := app.NewTestEnvironment(t, true)
+	db := e.DB()
+
+	recorder := tracetest.NewSpanRecorder()
+	e.TracerProvider().(*sdktrace.TracerProvider).RegisterSpanProcessor(recorder)
+
+	ctx, parent := e.TracerProvider().Tracer("test").Start(context.Background(), "parent")
+
+	var count int
+
+	if err := db.Get(ctx, &count, db.QB().Select("COUNT(*)").From("customers")); err != nil {
+		t.Fatalf("unable to count customers: %v", err)
+	}
+
+	var usernames []string
+
+	if err := db.Select(ctx, &usernames, db.QB().Select("username").From("customers")); err != nil {
+		t.Fatalf("unable to select usernames: %v", err)
+	}
+
+	if _, err := db.Conn().Exec(ctx, "DELETE FROM customers"); err != nil {
+		t.Fatalf("unable to delete customers: %v", err)
+	}
+
+	parent.End()
+
+	var names []string
+
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			names = append(names, span.Name())
+		}
+	}
+
+	assert.Equal(t, []string{"postgres SELECT", "postgres SELECT", "postgres DELETE"}, names)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(grantRoleCmd)
+}
+
+var grantRoleCmd = &cobra.Command{ //nolint:gochecknoglobals
+	Use:   "grant-role <username> <role>",
+	Short: "Grant a role to a customer.",
+	Long: "Grant a role to the customer with the given username. This is how the first admin is created, " +
+		"after that admins can grant roles through the api.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defaultEnv, cleanup, er := app.NewDefaultEnvironment()
+		if er != nil {
+			return fmt.Errorf("unable to initialise default environment: %w", er)
+		}
+		defer func() {
+			if cerr := cleanup(); err == nil {
+				err = cerr
+			}
+		}()
+
+		username, role := args[0], args[1]
+		customerRepo := postgres.NewCustomerRepository(defaultEnv.DB())
+
+		cust, err := customerRepo.FindByUsername(context.Background(), username)
+		if err != nil {
+			return fmt.Errorf("unable to find customer: %w", err)
+		}
+
+		if cust == nil {
+			return fmt.Errorf("unable to grant role to %s: %w", username, customer.ErrNotFound)
+		}
+
+		if err := customerRepo.GrantRole(context.Background(), cust.ID(), role); err != nil {
+			return fmt.Errorf("unable to grant role to %s: %w", username, err)
+		}
+
+		defaultEnv.Logger().Info("granted role", zap.String("customer", cust.ID().String()), zap.String("role", role))
+
+		return nil
+	},
+}
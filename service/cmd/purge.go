@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(purgeCmd)
+}
+
+var purgeCmd = &cobra.Command{ //nolint:gochecknoglobals
+	Use:   "purge",
+	Short: "Purge deleted customers.",
+	Long: "Permanently delete customers that were deleted longer ago than the configured retention period. " +
+		"This is intended to be run on a schedule.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defaultEnv, cleanup, er := app.NewDefaultEnvironment()
+		if er != nil {
+			return fmt.Errorf("unable to initialise default environment: %w", er)
+		}
+		defer func() {
+			if cerr := cleanup(); err == nil {
+				err = cerr
+			}
+		}()
+
+		retention := defaultEnv.Config().Customers.PurgeAfter * 24 * time.Hour
+		customerRepo := postgres.NewCustomerRepository(defaultEnv.DB())
+
+		purged, err := customerRepo.Purge(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			return fmt.Errorf("unable to purge customers: %w", err)
+		}
+
+		defaultEnv.Logger().Info("purged deleted customers", zap.Int64("count", purged), zap.Duration("retention", retention))
+
+		return nil
+	},
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/outbox"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/spf13/cobra"
+)
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(relayCmd)
+}
+
+var relayCmd = &cobra.Command{ //nolint:gochecknoglobals
+	Use:   "relay",
+	Short: "Relay the outbox.",
+	Long: "Publish the events written to the outbox to the configured publisher until interrupted. " +
+		"Several relays can be run at once, each message is claimed by a single relay.",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defaultEnv, cleanup, er := app.NewDefaultEnvironment()
+		if er != nil {
+			return fmt.Errorf("unable to initialise default environment: %w", er)
+		}
+		defer func() {
+			if cerr := cleanup(); err == nil {
+				err = cerr
+			}
+		}()
+
+		c := defaultEnv.Config().Outbox
+		relay := outbox.NewRelay(
+			postgres.NewOutboxRepository(defaultEnv.DB()),
+			defaultEnv.Publisher(),
+			outbox.RetryPolicy{
+				MaxAttempts: c.MaxAttempts,
+				BaseDelay:   c.BaseDelay * time.Second,
+				MaxDelay:    c.MaxDelay * time.Second,
+			},
+			c.BatchSize,
+			c.Lease*time.Second,
+			defaultEnv.Logger(),
+		)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		defaultEnv.Logger().Info("relaying outbox")
+
+		return relay.Run(ctx, c.PollInterval*time.Second)
+	},
+}
@@ -3,12 +3,24 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+	"github.com/nickbryan/go-template/service/domain/verification"
 	"github.com/nickbryan/go-template/service/infrastructure/postgres"
 	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/nickbryan/go-template/service/transport/rest/apikeys"
+	"github.com/nickbryan/go-template/service/transport/rest/audits"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
 	"github.com/nickbryan/go-template/service/transport/rest/customers"
 	"github.com/nickbryan/go-template/service/transport/rest/health"
+	"github.com/nickbryan/go-template/service/transport/rest/ledgers"
+	"github.com/nickbryan/go-template/service/transport/rest/metrics"
 	"github.com/spf13/cobra"
 )
 
@@ -29,7 +41,9 @@ var serverCmd = &cobra.Command{ //nolint:gochecknoglobals
 			return fmt.Errorf("unable to initialise default environment: %w", er)
 		}
 		defer func() {
-			err = cleanup()
+			if cerr := cleanup(); err == nil {
+				err = cerr
+			}
 		}()
 
 		// You can use the migration commands in the make file for local development.
@@ -40,15 +54,127 @@ var serverCmd = &cobra.Command{ //nolint:gochecknoglobals
 			}
 		}
 
-		s := rest.NewServer(defaultEnv)
+		auditRepo := postgres.NewAuditRepository(defaultEnv.DB())
+		auditor := rest.NewAuditRecorder(auditRepo)
+
+		// Customers are saved through the dispatcher so that subscribers can react to the events they raise, and
+		// through the auditor so that every change is recorded in the audit log in the same transaction. Changes
+		// made to customers by other repositories, such as verifying or erasing them, are recorded the same way.
+		customerStore := postgres.NewCustomerRepository(defaultEnv.DB())
+		auditedCustomers := customer.NewAuditingRepository(customerStore, auditor, defaultEnv.DB())
+		customerRepo := customer.NewDispatchingRepository(auditedCustomers, defaultEnv.Events())
+		s := rest.NewServer(defaultEnv, customer.NewAuthorizer(customerRepo))
+		trustedProxies, err := rest.ParseTrustedProxies(defaultEnv.Config().Server.TrustedProxies)
+		if err != nil {
+			return fmt.Errorf("unable to parse trusted proxies: %w", err)
+		}
+
+		accessLogConf := defaultEnv.Config().Server.AccessLog
+		accessLog := rest.AccessLogOptions{SampleRate: accessLogConf.SampleRate, Exclude: accessLogConf.Exclude}
+		s.Use(rest.DefaultMiddleware(defaultEnv, accessLog, trustedProxies)...)
+
+		// Metrics are served on the internal listener when one is configured so that they are not public.
+		scrape := metrics.NewScrapeHandler(defaultEnv.Metrics())
+		if addr := defaultEnv.Config().Server.MetricsAddress; addr != "" {
+			s.ListenInternal(addr, scrape)
+		} else {
+			s.RegisterHandlers(scrape)
+		}
+
+		refreshTokenRepo := postgres.NewRefreshTokenRepository(defaultEnv.DB())
+		passwordResetRepo := postgres.NewPasswordResetRepository(defaultEnv.DB())
+		verificationRepo := postgres.NewVerificationRepository(defaultEnv.DB())
+		lockoutRepo := postgres.NewLockoutRepository(defaultEnv.DB())
+		apiKeyRepo := postgres.NewAPIKeyRepository(defaultEnv.DB())
+		ledgerRepo := postgres.NewLedgerRepository(defaultEnv.DB())
+		customerLedger := ledger.NewLedger(ledgerRepo)
 
-		customerRepo := postgres.NewCustomerRepository(defaultEnv.DB())
+		// Every repository holding personal data is registered so that it is included in exports and erasures.
+		// The customer is registered first as it is erased last.
+		personalData := privacy.NewService(postgres.NewPersonalDataRequestRepository(defaultEnv.DB()))
+		personalData.Register("customer", customer.NewAuditingPersonalDataStore(customerStore, auditor, defaultEnv.DB()))
+		personalData.Register("sessions", refreshTokenRepo)
+		personalData.Register("password_resets", passwordResetRepo)
+		personalData.Register("verifications", verificationRepo)
+		personalData.Register("failed_logins", lockoutRepo)
+		personalData.Register("api_keys", apiKeyRepo)
+		personalData.Register("ledger", ledgerRepo)
+		personalData.Register("audit", auditRepo)
+		personalData.Register("events", postgres.NewOutboxRepository(defaultEnv.DB()))
+
+		jwt := defaultEnv.JWT()
+		keys := apikey.NewAuthenticator(apiKeyRepo)
+		hasher := defaultEnv.PasswordHasher()
+		refreshTTL := defaultEnv.Config().Auth.RefreshTokenTTL * time.Second
+		passwordResetTTL := defaultEnv.Config().Auth.PasswordResetTTL * time.Second
+
+		lockoutConf := defaultEnv.Config().Auth.Lockout
+		guard := lockout.NewGuard(lockoutRepo, lockoutPolicy(lockoutConf.Username), lockoutPolicy(lockoutConf.IP))
+
+		customersConf := defaultEnv.Config().Customers
+		verificationSender := verification.NewSender(
+			verificationRepo,
+			defaultEnv.Mailer(),
+			customersConf.VerificationLink,
+			customersConf.VerificationTTL*time.Second,
+			customersConf.VerificationResendInterval*time.Second,
+		)
 
 		s.RegisterHandlers(
 			health.NewCheckHandler(),
-			customers.NewCreateHandler(customerRepo),
+			auth.NewLoginHandler(
+				customerRepo,
+				hasher,
+				guard,
+				refreshTokenRepo,
+				jwt,
+				refreshTTL,
+				auditor,
+			),
+			auth.NewUnlockHandler(guard, jwt, keys),
+			auth.NewRefreshHandler(customerRepo, refreshTokenRepo, jwt, refreshTTL),
+			auth.NewLogoutHandler(refreshTokenRepo, auditor),
+			auth.NewLogoutEverywhereHandler(refreshTokenRepo, jwt, auditor),
+			auth.NewPasswordResetHandler(
+				customerRepo,
+				passwordResetRepo,
+				defaultEnv.Mailer(),
+				passwordResetTTL,
+			),
+			auth.NewPasswordResetConfirmHandler(customerRepo, hasher, passwordResetRepo, refreshTokenRepo, defaultEnv.DB()),
+			apikeys.NewCreateHandler(apiKeyRepo, customerRepo, jwt),
+			apikeys.NewIndexHandler(apiKeyRepo, jwt),
+			apikeys.NewRevokeHandler(apiKeyRepo, jwt),
+			customers.NewIndexHandler(customerRepo, jwt, keys),
+			customers.NewCreateHandler(customerRepo, hasher, verificationSender),
+			// The verify routes must be registered before /customers/{id} so that "verify" is not read as an id.
+			customers.NewVerifyHandler(verification.NewAuditingRepository(verificationRepo, auditedCustomers)),
+			customers.NewResendVerificationHandler(customerRepo, verificationSender, jwt),
+			customers.NewShowHandler(customerRepo, jwt, keys),
+			customers.NewUpdateHandler(customerRepo, hasher, refreshTokenRepo, jwt),
+			customers.NewDeleteHandler(customerRepo, jwt),
+			customers.NewRestoreHandler(customerRepo, jwt, keys),
+			customers.NewGrantRoleHandler(customerRepo, jwt, keys),
+			customers.NewRevokeRoleHandler(customerRepo, jwt, keys),
+			customers.NewExportPersonalDataHandler(personalData, jwt, keys),
+			customers.NewErasePersonalDataHandler(personalData, jwt, keys),
+			ledgers.NewBalanceHandler(customerLedger, jwt, keys),
+			ledgers.NewHistoryHandler(customerLedger, jwt, keys),
+			audits.NewIndexHandler(auditRepo, jwt, keys),
 		)
 
 		return s.Start()
 	},
 }
+
+// lockoutPolicy converts the configured policy, which holds its times in seconds, to a lockout.Policy.
+func lockoutPolicy(c app.LockoutPolicy) lockout.Policy {
+	return lockout.Policy{
+		DelayAfter:   c.DelayAfter,
+		BaseDelay:    c.BaseDelay * time.Second,
+		MaxDelay:     c.MaxDelay * time.Second,
+		LockAfter:    c.LockAfter,
+		LockDuration: c.LockDuration * time.Second,
+		ResetAfter:   c.ResetAfter * time.Second,
+	}
+}
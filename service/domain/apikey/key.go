@@ -0,0 +1,208 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/secret"
+)
+
+// Prefix starts every Key so that leaked keys can be recognised by secret scanners and the Authorization
+// header can be told apart from other credentials.
+const Prefix = "gtk_"
+
+// hintLength is the number of characters of the secret that are kept in plain text so that customers can
+// tell their keys apart.
+const hintLength = 8
+
+var (
+	ErrNotFound  = errors.New("api key not found")
+	ErrEmptyName = errors.New("api key name can not be empty")
+)
+
+// Repository represents our entity storage for a Key. Keys are stored by the hash of their secret, the
+// secret itself is never persisted.
+type Repository interface {
+	// Add a new Key to the repository.
+	Add(ctx context.Context, k *Key) error
+
+	// Revoke revokes the Key with the given id that belongs to the customer. ErrNotFound is returned if
+	// the customer has no Key with the given id.
+	Revoke(ctx context.Context, customerID, id uuid.UUID) error
+
+	// Touch records that the Key with the given id was used at the given time.
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// FindByHash searches the repository for a Key with the given hashed secret.
+	// If none is found then nil is returned.
+	FindByHash(ctx context.Context, hash string) (*Key, error)
+
+	// ListByCustomer returns every Key belonging to the customer that has not been revoked, newest first.
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*Key, error)
+}
+
+// Key lets a machine client authenticate as the customer that created it without logging in. A Key can
+// be restricted to a set of scopes and can expire.
+type Key struct {
+	id         uuid.UUID
+	customerID uuid.UUID
+	name       string
+	hint       string
+	hash       string
+	scopes     []string
+	expiresAt  *time.Time
+	lastUsedAt *time.Time
+	revokedAt  *time.Time
+	createdAt  time.Time
+}
+
+// New creates a Key for the customer. The returned string is the key that should be given to the customer,
+// it can not be retrieved again. Keys without scopes can use every permission of the customer and keys
+// without an expiry are valid until they are revoked.
+func New(customerID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*Key, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", fmt.Errorf("unable to create api key: %w", ErrEmptyName)
+	}
+
+	s, err := secret.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create api key: %w", err)
+	}
+
+	key := Prefix + s
+
+	return &Key{
+		id:         uuid.New(),
+		customerID: customerID,
+		name:       name,
+		hint:       key[:len(Prefix)+hintLength],
+		hash:       secret.Hash(key),
+		scopes:     scopes,
+		expiresAt:  expiresAt,
+		createdAt:  time.Now(),
+	}, key, nil
+}
+
+// State holds the persisted values of a Key so that a Repository can Restore it.
+type State struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Name       string
+	Hint       string
+	Hash       string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Restore creates a Key from existing state held in a Repository.
+func Restore(s State) *Key {
+	return &Key{
+		id:         s.ID,
+		customerID: s.CustomerID,
+		name:       s.Name,
+		hint:       s.Hint,
+		hash:       s.Hash,
+		scopes:     s.Scopes,
+		expiresAt:  s.ExpiresAt,
+		lastUsedAt: s.LastUsedAt,
+		revokedAt:  s.RevokedAt,
+		createdAt:  s.CreatedAt,
+	}
+}
+
+// ID uniquely identifies a Key within the application.
+func (k *Key) ID() uuid.UUID {
+	return k.id
+}
+
+// CustomerID is the id of the customer the Key authenticates as.
+func (k *Key) CustomerID() uuid.UUID {
+	return k.customerID
+}
+
+// Name is chosen by the customer to describe what the Key is used for.
+func (k *Key) Name() string {
+	return k.name
+}
+
+// Hint is the start of the key, which is safe to show so that customers can tell their keys apart.
+func (k *Key) Hint() string {
+	return k.hint
+}
+
+// Hash is the hash of the key.
+func (k *Key) Hash() string {
+	return k.hash
+}
+
+// Scopes are the permissions the Key is restricted to. The Key is not restricted if there are none.
+func (k *Key) Scopes() []string {
+	return k.scopes
+}
+
+// ExpiresAt is the time after which the Key can no longer be used. It is nil if the Key does not expire.
+func (k *Key) ExpiresAt() *time.Time {
+	return k.expiresAt
+}
+
+// LastUsedAt is the time the Key was last used to authenticate. It is nil if the Key has not been used.
+func (k *Key) LastUsedAt() *time.Time {
+	return k.lastUsedAt
+}
+
+// RevokedAt is the time the Key was revoked. It is nil if the Key has not been revoked.
+func (k *Key) RevokedAt() *time.Time {
+	return k.revokedAt
+}
+
+// CreatedAt is the time the Key was created.
+func (k *Key) CreatedAt() time.Time {
+	return k.createdAt
+}
+
+// IsUsable returns true if the Key has not been revoked or expired at the given time.
+func (k *Key) IsUsable(now time.Time) bool {
+	return k.revokedAt == nil && (k.expiresAt == nil || now.Before(*k.expiresAt))
+}
+
+// Authenticator exchanges keys given by clients for the Key they belong to.
+type Authenticator struct {
+	repo Repository
+}
+
+// NewAuthenticator creates an Authenticator that looks keys up in the Repository.
+func NewAuthenticator(repo Repository) *Authenticator {
+	return &Authenticator{repo: repo}
+}
+
+// Authenticate returns the usable Key for the given key and records that it has been used. If there is
+// no such Key, or it can not be used, nil is returned.
+func (a *Authenticator) Authenticate(ctx context.Context, key string, now time.Time) (*Key, error) {
+	if !strings.HasPrefix(key, Prefix) {
+		return nil, nil
+	}
+
+	k, err := a.repo.FindByHash(ctx, secret.Hash(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil || !k.IsUsable(now) {
+		return nil, nil
+	}
+
+	if err := a.repo.Touch(ctx, k.ID(), now); err != nil {
+		return nil, err
+	}
+
+	k.lastUsedAt = &now
+
+	return k, nil
+}
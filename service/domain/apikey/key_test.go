@@ -0,0 +1,124 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := apikey.New(uuid.New(), " ", nil, nil)
+	assert.True(t, errors.Is(err, apikey.ErrEmptyName), err)
+
+	k, key, err := apikey.New(uuid.New(), "partner", []string{"roles:manage"}, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	assert.True(t, strings.HasPrefix(key, apikey.Prefix), key)
+	assert.True(t, strings.HasPrefix(key, k.Hint()), "the hint must be the start of the key")
+	assert.Less(t, len(k.Hint()), len(key))
+	assert.Equal(t, secret.Hash(key), k.Hash())
+	assert.Equal(t, []string{"roles:manage"}, k.Scopes())
+}
+
+func TestKeyIsUsable(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.True(t, apikey.Restore(apikey.State{}).IsUsable(now))
+	assert.True(t, apikey.Restore(apikey.State{ExpiresAt: &future}).IsUsable(now))
+	assert.False(t, apikey.Restore(apikey.State{ExpiresAt: &past}).IsUsable(now))
+	assert.False(t, apikey.Restore(apikey.State{RevokedAt: &past}).IsUsable(now))
+}
+
+// memoryRepository is an in memory apikey.Repository.
+type memoryRepository struct {
+	keys    []*apikey.Key
+	touched map[uuid.UUID]time.Time
+}
+
+func (m *memoryRepository) Add(_ context.Context, k *apikey.Key) error {
+	m.keys = append(m.keys, k)
+
+	return nil
+}
+
+func (m *memoryRepository) Revoke(context.Context, uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (m *memoryRepository) Touch(_ context.Context, id uuid.UUID, at time.Time) error {
+	m.touched[id] = at
+
+	return nil
+}
+
+func (m *memoryRepository) FindByHash(_ context.Context, hash string) (*apikey.Key, error) {
+	for _, k := range m.keys {
+		if k.Hash() == hash {
+			return k, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *memoryRepository) ListByCustomer(context.Context, uuid.UUID) ([]*apikey.Key, error) {
+	return m.keys, nil
+}
+
+func TestAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	repo := &memoryRepository{touched: map[uuid.UUID]time.Time{}}
+
+	k, key, err := apikey.New(uuid.New(), "partner", nil, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	expiresAt := now.Add(-time.Minute)
+
+	expired, expiredKey, err := apikey.New(uuid.New(), "expired", nil, &expiresAt)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	_ = repo.Add(ctx, k)
+	_ = repo.Add(ctx, expired)
+
+	a := apikey.NewAuthenticator(repo)
+
+	found, err := a.Authenticate(ctx, key, now)
+	if err != nil {
+		t.Fatalf("unable to authenticate: %v", err)
+	}
+
+	assert.Equal(t, k.ID(), found.ID())
+	assert.Equal(t, now, repo.touched[k.ID()], "the use of the key must be recorded")
+
+	for _, invalid := range []string{expiredKey, "gtk_unknown", strings.TrimPrefix(key, apikey.Prefix)} {
+		found, err := a.Authenticate(ctx, invalid, now)
+		if err != nil {
+			t.Fatalf("unable to authenticate: %v", err)
+		}
+
+		assert.Nil(t, found)
+	}
+
+	assert.NotContains(t, repo.touched, expired.ID())
+}
@@ -0,0 +1,254 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The actions that are recorded.
+const (
+	ActionCreate           = "create"
+	ActionUpdate           = "update"
+	ActionDelete           = "delete"
+	ActionRestore          = "restore"
+	ActionErase            = "erase"
+	ActionGrantRole        = "grant_role"
+	ActionRevokeRole       = "revoke_role"
+	ActionLogin            = "login"
+	ActionLoginFailed      = "login_failed"
+	ActionLogout           = "logout"
+	ActionLogoutEverywhere = "logout_everywhere"
+)
+
+// The types of resource that actions are performed on.
+const (
+	ResourceCustomer = "customer"
+	ResourceSession  = "session"
+)
+
+// Redacted replaces values that must never be written to the audit log, such as password hashes, so that the
+// Entry still shows that they changed.
+const Redacted = "[redacted]"
+
+// The limits on the number of entries returned in a Page.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Change is the value of a field before and after an action. A nil value means the field was not set.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the Change of each field whose value differs between before and after. Either may be nil when
+// the resource did not exist before or after the action.
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+
+	for field, from := range before {
+		if to := after[field]; !reflect.DeepEqual(from, to) {
+			changes[field] = Change{From: from, To: to}
+		}
+	}
+
+	for field, to := range after {
+		if _, ok := before[field]; !ok && to != nil {
+			changes[field] = Change{To: to}
+		}
+	}
+
+	return changes
+}
+
+// Entry records who performed an action on a resource, when and what it changed. Entries can not be changed
+// once they are added.
+type Entry struct {
+	id           uuid.UUID
+	actorID      *uuid.UUID
+	action       string
+	resourceType string
+	resourceID   string
+	changes      map[string]Change
+	requestID    string
+	occurredAt   time.Time
+}
+
+// NewEntry creates an Entry for an action that has just been performed. The actor is nil when the action was
+// not performed by an authenticated customer and the request id is empty when it was not made in a request.
+func NewEntry(
+	actorID *uuid.UUID,
+	action, resourceType, resourceID string,
+	changes map[string]Change,
+	requestID string,
+) *Entry {
+	if changes == nil {
+		changes = make(map[string]Change)
+	}
+
+	return &Entry{
+		id:           uuid.New(),
+		actorID:      actorID,
+		action:       action,
+		resourceType: resourceType,
+		resourceID:   resourceID,
+		changes:      changes,
+		requestID:    requestID,
+		occurredAt:   time.Now(),
+	}
+}
+
+// State is the persisted state of an Entry, used to restore one from a datastore.
+type State struct {
+	ID           uuid.UUID
+	ActorID      *uuid.UUID
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Changes      map[string]Change
+	RequestID    string
+	OccurredAt   time.Time
+}
+
+// Restore recreates an Entry from its persisted state.
+func Restore(s State) *Entry {
+	return &Entry{
+		id:           s.ID,
+		actorID:      s.ActorID,
+		action:       s.Action,
+		resourceType: s.ResourceType,
+		resourceID:   s.ResourceID,
+		changes:      s.Changes,
+		requestID:    s.RequestID,
+		occurredAt:   s.OccurredAt,
+	}
+}
+
+// ID of the Entry.
+func (e *Entry) ID() uuid.UUID {
+	return e.id
+}
+
+// ActorID is the id of the customer that performed the action, or nil if it was not an authenticated customer.
+func (e *Entry) ActorID() *uuid.UUID {
+	return e.actorID
+}
+
+// Action that was performed.
+func (e *Entry) Action() string {
+	return e.action
+}
+
+// ResourceType is the type of resource that the action was performed on.
+func (e *Entry) ResourceType() string {
+	return e.resourceType
+}
+
+// ResourceID is the id of the resource that the action was performed on. It is empty if the resource is unknown,
+// such as when logging in with a username that does not exist.
+func (e *Entry) ResourceID() string {
+	return e.resourceID
+}
+
+// Changes are the fields of the resource that the action changed.
+func (e *Entry) Changes() map[string]Change {
+	return e.changes
+}
+
+// ChangedFields are the names of the Changes in alphabetical order.
+func (e *Entry) ChangedFields() []string {
+	fields := make([]string, 0, len(e.changes))
+	for field := range e.changes {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+// RequestID is the id of the request that performed the action.
+func (e *Entry) RequestID() string {
+	return e.requestID
+}
+
+// OccurredAt is the time that the action was performed.
+func (e *Entry) OccurredAt() time.Time {
+	return e.occurredAt
+}
+
+// Filter narrows the entries returned by Repository.List. Empty fields match every Entry and the time range
+// includes From but excludes To.
+type Filter struct {
+	ActorID      *uuid.UUID
+	ResourceType string
+	ResourceID   string
+	From         *time.Time
+	To           *time.Time
+
+	// Limit is the maximum number of entries to return in the Page.
+	Limit int
+
+	// Cursor is an opaque value taken from a previous Page. An empty Cursor returns the first page.
+	Cursor string
+}
+
+// Page is a subset of the entries returned from the Repository, newest first.
+type Page struct {
+	Entries []*Entry
+
+	// NextCursor retrieves the following, older, Page. It is empty when there are no more entries.
+	NextCursor string
+}
+
+// Repository stores entries. Entries can only be added, never changed or removed.
+type Repository interface {
+	// Add a new Entry to the repository.
+	Add(ctx context.Context, e *Entry) error
+
+	// List returns a Page of the entries matching the Filter, newest first.
+	// ErrInvalidCursor is returned if the Filter.Cursor can not be used.
+	List(ctx context.Context, f Filter) (*Page, error)
+}
+
+// Recorder adds entries to a Repository for actions performed within a context, which holds the actor and the
+// id of the request.
+type Recorder struct {
+	repo      Repository
+	actor     func(ctx context.Context) (uuid.UUID, bool)
+	requestID func(ctx context.Context) string
+}
+
+// NewRecorder creates a Recorder that adds entries to the Repository. The actor and requestID functions find
+// the authenticated customer and the id of the request in the context.
+func NewRecorder(
+	repo Repository,
+	actor func(ctx context.Context) (uuid.UUID, bool),
+	requestID func(ctx context.Context) string,
+) *Recorder {
+	return &Recorder{repo: repo, actor: actor, requestID: requestID}
+}
+
+// Record adds an Entry for the action performed on the resource with the given type and id.
+func (r *Recorder) Record(ctx context.Context, action, resourceType, resourceID string, changes map[string]Change) error {
+	var actorID *uuid.UUID
+
+	if id, ok := r.actor(ctx); ok {
+		actorID = &id
+	}
+
+	if err := r.repo.Add(ctx, NewEntry(actorID, action, resourceType, resourceID, changes, r.requestID(ctx))); err != nil {
+		return fmt.Errorf("unable to record %s of %s: %w", action, resourceType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/audit/audittest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		before, after map[string]interface{}
+		want          map[string]audit.Change
+	}{
+		"created resources include every set field": {
+			after: map[string]interface{}{"username": "bob@example.org", "verified_at": nil},
+			want:  map[string]audit.Change{"username": {To: "bob@example.org"}},
+		},
+		"deleted resources include every field": {
+			before: map[string]interface{}{"username": "bob@example.org", "version": 2},
+			want: map[string]audit.Change{
+				"username": {From: "bob@example.org"},
+				"version":  {From: 2},
+			},
+		},
+		"unchanged fields are left out": {
+			before: map[string]interface{}{"username": "bob@example.org", "roles": []string{"admin"}},
+			after:  map[string]interface{}{"username": "alice@example.org", "roles": []string{"admin"}},
+			want:   map[string]audit.Change{"username": {From: "bob@example.org", To: "alice@example.org"}},
+		},
+		"identical resources have no changes": {
+			before: map[string]interface{}{"username": "bob@example.org"},
+			after:  map[string]interface{}{"username": "bob@example.org"},
+			want:   map[string]audit.Change{},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, audit.Diff(tc.before, tc.after))
+		})
+	}
+}
+
+func TestEntryChangedFieldsAreSorted(t *testing.T) {
+	t.Parallel()
+
+	e := audit.NewEntry(nil, audit.ActionUpdate, audit.ResourceCustomer, "1", map[string]audit.Change{
+		"version":  {From: 1, To: 2},
+		"password": {From: audit.Redacted, To: audit.Redacted},
+		"username": {From: "a", To: "b"},
+	}, "")
+
+	assert.Equal(t, []string{"password", "username", "version"}, e.ChangedFields())
+}
+
+type contextKey string
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	actorID := uuid.New()
+
+	actor := func(ctx context.Context) (uuid.UUID, bool) {
+		id, ok := ctx.Value(contextKey("actor")).(uuid.UUID)
+
+		return id, ok
+	}
+
+	requestID := func(ctx context.Context) string {
+		id, _ := ctx.Value(contextKey("request")).(string)
+
+		return id
+	}
+
+	t.Run("records the actor and request from the context", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &audittest.Repository{}
+		ctx := context.WithValue(context.Background(), contextKey("actor"), actorID)
+		ctx = context.WithValue(ctx, contextKey("request"), "req-1")
+
+		changes := map[string]audit.Change{"username": {To: "bob@example.org"}}
+		err := audit.NewRecorder(repo, actor, requestID).
+			Record(ctx, audit.ActionCreate, audit.ResourceCustomer, "c-1", changes)
+
+		assert.NoError(t, err)
+
+		if assert.Len(t, repo.Entries(), 1) {
+			e := repo.Entries()[0]
+			assert.Equal(t, &actorID, e.ActorID())
+			assert.Equal(t, audit.ActionCreate, e.Action())
+			assert.Equal(t, audit.ResourceCustomer, e.ResourceType())
+			assert.Equal(t, "c-1", e.ResourceID())
+			assert.Equal(t, changes, e.Changes())
+			assert.Equal(t, "req-1", e.RequestID())
+			assert.False(t, e.OccurredAt().IsZero())
+		}
+	})
+
+	t.Run("records anonymous actions without an actor", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &audittest.Repository{}
+		err := audit.NewRecorder(repo, actor, requestID).
+			Record(context.Background(), audit.ActionLoginFailed, audit.ResourceCustomer, "", nil)
+
+		assert.NoError(t, err)
+
+		if assert.Len(t, repo.Entries(), 1) {
+			assert.Nil(t, repo.Entries()[0].ActorID())
+			assert.Empty(t, repo.Entries()[0].RequestID())
+			assert.NotNil(t, repo.Entries()[0].Changes())
+		}
+	})
+
+	t.Run("returns repository errors", func(t *testing.T) {
+		t.Parallel()
+
+		repoErr := errors.New("unavailable")
+		repo := &audittest.Repository{}
+		repo.FailWith(repoErr)
+
+		err := audit.NewRecorder(repo, actor, requestID).
+			Record(context.Background(), audit.ActionLogout, audit.ResourceSession, "s-1", nil)
+
+		assert.True(t, errors.Is(err, repoErr))
+	})
+}
@@ -0,0 +1,71 @@
+package audittest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nickbryan/go-template/service/domain/audit"
+)
+
+// Repository holds entries in memory so that tests can make assertions against what was recorded.
+type Repository struct {
+	mu      sync.Mutex
+	entries []*audit.Entry
+	err     error
+}
+
+// FailWith makes every following call to Add return err until it is called again with a nil error.
+func (r *Repository) FailWith(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+}
+
+// Add records the audit.Entry unless FailWith has been called.
+func (r *Repository) Add(_ context.Context, e *audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.entries = append(r.entries, e)
+
+	return nil
+}
+
+// List returns every recorded audit.Entry matching the actor and resource of the audit.Filter, newest first.
+// The time range, limit and cursor are ignored.
+func (r *Repository) List(_ context.Context, f audit.Filter) (*audit.Page, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	page := &audit.Page{}
+
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+
+		if f.ActorID != nil && (e.ActorID() == nil || *e.ActorID() != *f.ActorID) {
+			continue
+		}
+
+		if (f.ResourceType != "" && e.ResourceType() != f.ResourceType) ||
+			(f.ResourceID != "" && e.ResourceID() != f.ResourceID) {
+			continue
+		}
+
+		page.Entries = append(page.Entries, e)
+	}
+
+	return page, nil
+}
+
+// Entries returns every recorded audit.Entry in the order they were added.
+func (r *Repository) Entries() []*audit.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*audit.Entry(nil), r.entries...)
+}
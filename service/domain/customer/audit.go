@@ -0,0 +1,226 @@
+package customer
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/domain/audit"
+)
+
+// Auditor records the actions performed on customers in the audit log.
+type Auditor interface {
+	Record(ctx context.Context, action, resourceType, resourceID string, changes map[string]audit.Change) error
+}
+
+// Transactor runs fn within a transaction. Everything saved with the context given to fn is committed together
+// when fn succeeds and discarded when it returns an error.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AuditingRepository wraps a Repository so that every change made to a Customer is recorded by the Auditor in
+// the same transaction that saves it. A change is never saved without being recorded, and nothing is recorded
+// if saving fails.
+type AuditingRepository struct {
+	Repository
+	auditor    Auditor
+	transactor Transactor
+}
+
+// NewAuditingRepository creates an AuditingRepository that saves to the Repository and records with the Auditor
+// within transactions run by the Transactor.
+func NewAuditingRepository(repo Repository, a Auditor, t Transactor) *AuditingRepository {
+	return &AuditingRepository{Repository: repo, auditor: a, transactor: t}
+}
+
+// Add a new Customer to the Repository and record its creation.
+func (r *AuditingRepository) Add(ctx context.Context, c *Customer) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := r.Repository.Add(ctx, c); err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionCreate, c.ID(), audit.Diff(nil, snapshot(c)))
+	})
+}
+
+// Update persists the changes made to an existing Customer and records the fields that changed. The password
+// hash is never recorded, only that it changed.
+func (r *AuditingRepository) Update(ctx context.Context, c *Customer) (*Customer, error) {
+	var updated *Customer
+
+	err := r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.Repository.FindByID(ctx, c.ID())
+		if err != nil {
+			return err
+		}
+
+		if updated, err = r.Repository.Update(ctx, c); err != nil {
+			return err
+		}
+
+		changes := audit.Diff(snapshot(before), snapshot(updated))
+		if before != nil && before.PasswordHash() != updated.PasswordHash() {
+			changes["password"] = audit.Change{From: audit.Redacted, To: audit.Redacted}
+		}
+
+		return r.record(ctx, audit.ActionUpdate, c.ID(), changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Delete soft deletes the Customer with the given id and records the Customer as it was before it was deleted.
+func (r *AuditingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.Repository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := r.Repository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionDelete, id, audit.Diff(snapshot(before), nil))
+	})
+}
+
+// Undelete restores a deleted Customer, records its restoration and returns it.
+func (r *AuditingRepository) Undelete(ctx context.Context, id uuid.UUID) (*Customer, error) {
+	var restored *Customer
+
+	err := r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if restored, err = r.Repository.Undelete(ctx, id); err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionRestore, id, audit.Diff(nil, snapshot(restored)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// GrantRole grants the role to the Customer with the given id and records the grant.
+func (r *AuditingRepository) GrantRole(ctx context.Context, id uuid.UUID, role string) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := r.Repository.GrantRole(ctx, id, role); err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionGrantRole, id, map[string]audit.Change{"role": {To: role}})
+	})
+}
+
+// RevokeRole revokes the role from the Customer with the given id and records the revocation.
+func (r *AuditingRepository) RevokeRole(ctx context.Context, id uuid.UUID, role string) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := r.Repository.RevokeRole(ctx, id, role); err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionRevokeRole, id, map[string]audit.Change{"role": {From: role}})
+	})
+}
+
+// RecordUpdate runs save, which changes the Customer with the given id without going through the Repository,
+// and records the fields that it changed in the same transaction.
+func (r *AuditingRepository) RecordUpdate(
+	ctx context.Context,
+	id uuid.UUID,
+	save func(ctx context.Context) error,
+) error {
+	return r.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.Repository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := save(ctx); err != nil {
+			return err
+		}
+
+		after, err := r.Repository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return r.record(ctx, audit.ActionUpdate, id, audit.Diff(snapshot(before), snapshot(after)))
+	})
+}
+
+func (r *AuditingRepository) record(
+	ctx context.Context,
+	action string,
+	id uuid.UUID,
+	changes map[string]audit.Change,
+) error {
+	return r.auditor.Record(ctx, action, audit.ResourceCustomer, id.String(), changes)
+}
+
+// PersonalDataStore exports and erases the personal data held about customers, see privacy.Source.
+type PersonalDataStore interface {
+	ExportPersonalData(ctx context.Context, id uuid.UUID) (interface{}, error)
+	ErasePersonalData(ctx context.Context, id uuid.UUID) error
+}
+
+// AuditingPersonalDataStore wraps a PersonalDataStore so that erasing a Customer is recorded by the Auditor in
+// the same transaction that erases it. Only the names of the erased fields are recorded, never their values.
+type AuditingPersonalDataStore struct {
+	PersonalDataStore
+	auditor    Auditor
+	transactor Transactor
+}
+
+// NewAuditingPersonalDataStore creates an AuditingPersonalDataStore that erases from the PersonalDataStore and
+// records with the Auditor within transactions run by the Transactor.
+func NewAuditingPersonalDataStore(s PersonalDataStore, a Auditor, t Transactor) *AuditingPersonalDataStore {
+	return &AuditingPersonalDataStore{PersonalDataStore: s, auditor: a, transactor: t}
+}
+
+// ErasePersonalData erases the personal data held about the Customer with the given id and records the erasure.
+func (s *AuditingPersonalDataStore) ErasePersonalData(ctx context.Context, id uuid.UUID) error {
+	return s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.PersonalDataStore.ErasePersonalData(ctx, id); err != nil {
+			return err
+		}
+
+		redacted := audit.Change{From: audit.Redacted, To: audit.Redacted}
+		changes := map[string]audit.Change{
+			"username":    redacted,
+			"password":    redacted,
+			"verified_at": redacted,
+			"roles":       redacted,
+		}
+
+		return s.auditor.Record(ctx, audit.ActionErase, audit.ResourceCustomer, id.String(), changes)
+	})
+}
+
+// snapshot returns the fields of the Customer that are recorded in the audit log. Unset times are left as nil
+// so that they compare equal to fields that are missing.
+func snapshot(c *Customer) map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+
+	fields := map[string]interface{}{
+		"username":    c.Username(),
+		"verified_at": nil,
+		"version":     c.Version(),
+	}
+
+	if c.VerifiedAt() != nil {
+		fields["verified_at"] = c.VerifiedAt().UTC().Format(time.RFC3339Nano)
+	}
+
+	return fields
+}
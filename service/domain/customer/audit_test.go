@@ -0,0 +1,271 @@
+package customer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/audit/audittest"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRepository keeps a copy of the latest version of each Customer in memory.
+type memoryRepository struct {
+	customer.Repository
+	customers map[uuid.UUID]*customer.Customer
+}
+
+func (r *memoryRepository) Add(_ context.Context, c *customer.Customer) error {
+	r.customers[c.ID()] = restoreAtVersion(c, c.Version())
+
+	return nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, c *customer.Customer) (*customer.Customer, error) {
+	r.customers[c.ID()] = restoreAtVersion(c, c.Version()+1)
+
+	return r.customers[c.ID()], nil
+}
+
+func (r *memoryRepository) FindByID(_ context.Context, id uuid.UUID) (*customer.Customer, error) {
+	return r.customers[id], nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.customers, id)
+
+	return nil
+}
+
+// memoryTransactor discards the changes made to the memoryRepository when fn fails, as a rollback would.
+type memoryTransactor struct {
+	repo *memoryRepository
+}
+
+func (t memoryTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := make(map[uuid.UUID]*customer.Customer, len(t.repo.customers))
+	for id, c := range t.repo.customers {
+		saved[id] = c
+	}
+
+	if err := fn(ctx); err != nil {
+		t.repo.customers = saved
+
+		return err
+	}
+
+	return nil
+}
+
+func restoreAtVersion(c *customer.Customer, version int) *customer.Customer {
+	return customer.Restore(customer.State{
+		ID:           c.ID(),
+		Username:     c.Username(),
+		PasswordHash: c.PasswordHash(),
+		Version:      version,
+		CreatedAt:    c.CreatedAt(),
+		VerifiedAt:   c.VerifiedAt(),
+	})
+}
+
+func TestAuditingRepository(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	entries := &audittest.Repository{}
+	auditor := audit.NewRecorder(
+		entries,
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+	store := &memoryRepository{customers: make(map[uuid.UUID]*customer.Customer)}
+	repo := customer.NewAuditingRepository(store, auditor, memoryTransactor{store})
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := repo.Add(ctx, c); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	if err := c.ChangeUsername("alice@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	if err := c.ChangePassword("N3wS3cr3t", plainHasher{}); err != nil {
+		t.Fatalf("unable to change password: %v", err)
+	}
+
+	if _, err := repo.Update(ctx, c); err != nil {
+		t.Fatalf("unable to update customer: %v", err)
+	}
+
+	if err := repo.Delete(ctx, c.ID()); err != nil {
+		t.Fatalf("unable to delete customer: %v", err)
+	}
+
+	recorded := entries.Entries()
+	if !assert.Len(t, recorded, 3) {
+		return
+	}
+
+	assert.Equal(t, audit.ActionCreate, recorded[0].Action())
+	assert.Equal(t, c.ID().String(), recorded[0].ResourceID())
+	assert.Equal(t, audit.ResourceCustomer, recorded[0].ResourceType())
+	assert.Equal(t, []string{"username", "version"}, recorded[0].ChangedFields(), "unset fields are left out")
+
+	assert.Equal(t, audit.ActionUpdate, recorded[1].Action())
+	assert.Equal(t, map[string]audit.Change{
+		"username": {From: "bob@example.org", To: "alice@example.org"},
+		"password": {From: audit.Redacted, To: audit.Redacted},
+		"version":  {From: 1, To: 2},
+	}, recorded[1].Changes())
+
+	assert.Equal(t, audit.ActionDelete, recorded[2].Action())
+	assert.Equal(t, audit.Change{From: "alice@example.org"}, recorded[2].Changes()["username"])
+}
+
+func TestAuditingRepositoryDoesNotRecordFailedChanges(t *testing.T) {
+	t.Parallel()
+
+	entries := &audittest.Repository{}
+	auditor := audit.NewRecorder(
+		entries,
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	store := &memoryRepository{customers: make(map[uuid.UUID]*customer.Customer)}
+	repo := customer.NewAuditingRepository(failingRepository{}, auditor, memoryTransactor{store})
+
+	assert.Error(t, repo.Add(context.Background(), c))
+	assert.Empty(t, entries.Entries())
+}
+
+func TestAuditingRepositoryDoesNotSaveUnrecordedChanges(t *testing.T) {
+	t.Parallel()
+
+	entries := &audittest.Repository{}
+	entries.FailWith(errors.New("audit log unavailable"))
+
+	auditor := audit.NewRecorder(
+		entries,
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+	store := &memoryRepository{customers: make(map[uuid.UUID]*customer.Customer)}
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	assert.Error(t, customer.NewAuditingRepository(store, auditor, memoryTransactor{store}).Add(context.Background(), c))
+	assert.Empty(t, store.customers, "the customer is rolled back with the audit entry")
+}
+
+func TestAuditingRepositoryRecordUpdate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	entries := &audittest.Repository{}
+	auditor := audit.NewRecorder(
+		entries,
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+	store := &memoryRepository{customers: make(map[uuid.UUID]*customer.Customer)}
+	repo := customer.NewAuditingRepository(store, auditor, memoryTransactor{store})
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	store.customers[c.ID()] = c
+
+	// The change is saved by something other than the Repository, as verifying a customer is.
+	err = repo.RecordUpdate(ctx, c.ID(), func(context.Context) error {
+		store.customers[c.ID()] = restoreAtVersion(c, c.Version()+1)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to record update: %v", err)
+	}
+
+	recorded := entries.Entries()
+	if !assert.Len(t, recorded, 1) {
+		return
+	}
+
+	assert.Equal(t, audit.ActionUpdate, recorded[0].Action())
+	assert.Equal(t, map[string]audit.Change{"version": {From: 1, To: 2}}, recorded[0].Changes())
+
+	failed := errors.New("unable to save")
+	assert.Equal(t, failed, repo.RecordUpdate(ctx, c.ID(), func(context.Context) error { return failed }))
+	assert.Len(t, entries.Entries(), 1, "failed changes are not recorded")
+}
+
+// memoryPersonalDataStore erases customers from a memoryRepository.
+type memoryPersonalDataStore struct {
+	repo *memoryRepository
+}
+
+func (s memoryPersonalDataStore) ExportPersonalData(context.Context, uuid.UUID) (interface{}, error) {
+	return nil, nil
+}
+
+func (s memoryPersonalDataStore) ErasePersonalData(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func TestAuditingPersonalDataStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	entries := &audittest.Repository{}
+	auditor := audit.NewRecorder(
+		entries,
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+	repo := &memoryRepository{customers: make(map[uuid.UUID]*customer.Customer)}
+	store := customer.NewAuditingPersonalDataStore(memoryPersonalDataStore{repo}, auditor, memoryTransactor{repo})
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	repo.customers[c.ID()] = c
+
+	if err := store.ErasePersonalData(ctx, c.ID()); err != nil {
+		t.Fatalf("unable to erase customer: %v", err)
+	}
+
+	assert.Empty(t, repo.customers)
+
+	recorded := entries.Entries()
+	if !assert.Len(t, recorded, 1) {
+		return
+	}
+
+	assert.Equal(t, audit.ActionErase, recorded[0].Action())
+	assert.Equal(t, c.ID().String(), recorded[0].ResourceID())
+	assert.Equal(t, []string{"password", "roles", "username", "verified_at"}, recorded[0].ChangedFields())
+
+	for _, change := range recorded[0].Changes() {
+		assert.Equal(t, audit.Change{From: audit.Redacted, To: audit.Redacted}, change, "erased values are never recorded")
+	}
+}
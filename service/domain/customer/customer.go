@@ -4,21 +4,91 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/nickbryan/go-template/service/app/event"
 )
 
-var ErrGeneratePassword = errors.New("password generation failed")
+// Limits on the length of a password. The upper bound stops ddos through long password hashing.
+const (
+	MinPasswordLength = 6
+	MaxPasswordLength = 256
+)
+
+var (
+	ErrGeneratePassword = errors.New("password generation failed")
+	ErrPasswordLength   = fmt.Errorf("password must be between %d and %d characters", MinPasswordLength, MaxPasswordLength)
+	ErrEmptyUsername    = errors.New("username can not be empty")
+	ErrVersionConflict  = errors.New("customer has been modified since it was retrieved")
+	ErrNotFound         = errors.New("customer not found")
+	ErrUsernameTaken    = errors.New("username is already taken")
+)
+
+// PasswordHasher hashes and verifies customer passwords. Implementations may support several algorithms
+// so that hashes made with outdated algorithms or parameters can still be verified and then replaced.
+type PasswordHasher interface {
+	// Hash returns a new hash of the password.
+	Hash(password string) (string, error)
+
+	// Verify returns true if the hash is a hash of the password.
+	Verify(hash, password string) bool
 
-// Repository represents our entity storage for a Customer.
+	// NeedsRehash returns true if the hash was made with an outdated algorithm or parameters.
+	NeedsRehash(hash string) bool
+}
+
+// Repository represents our entity storage for a Customer. Customers that have been deleted are
+// ignored by all methods unless stated otherwise.
 type Repository interface {
 	// Add a new customer to the repository.
 	Add(ctx context.Context, c *Customer) error
 
-	// FindByUsername searches the repository for a Customer with the given username.
+	// Update persists the changes made to an existing Customer and returns it at its new version.
+	// ErrVersionConflict is returned if the Customer has changed since it was retrieved.
+	Update(ctx context.Context, c *Customer) (*Customer, error)
+
+	// UpdatePasswordHash persists a Customer whose password has been rehashed with RehashPassword. Nothing
+	// is written if the stored hash is no longer previousHash, as the password has been changed since.
+	// The version is not incremented as the password itself has not changed.
+	UpdatePasswordHash(ctx context.Context, c *Customer, previousHash string) error
+
+	// Delete soft deletes the Customer with the given id so that it can be restored with Undelete
+	// until it is purged. ErrNotFound is returned if there is no Customer with the given id.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Undelete restores a deleted Customer and returns it. ErrNotFound is returned if there is no
+	// deleted Customer with the given id and ErrUsernameTaken if its username has since been reused.
+	Undelete(ctx context.Context, id uuid.UUID) (*Customer, error)
+
+	// Purge permanently removes all customers that were deleted before the given time and returns
+	// the number of customers removed. Customers whose personal data was erased are kept as they are
+	// still referenced by the ledger and prove that the erasure happened.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// FindByID searches the repository for a Customer with the given id.
+	// If none is found then nil is returned.
+	FindByID(ctx context.Context, id uuid.UUID) (*Customer, error)
+
+	// FindByUsername searches the repository for a Customer with the given username. Usernames are compared
+	// by their UsernameKey so that they are case insensitive.
 	// If none is found then nil is returned.
 	FindByUsername(ctx context.Context, username string) (*Customer, error)
+
+	// List returns a Page of customers matching the given ListOptions.
+	// ErrInvalidCursor is returned if the ListOptions.Cursor can not be used.
+	List(ctx context.Context, opts ListOptions) (*Page, error)
+
+	// GrantRole grants the role to the Customer with the given id. Granting a role the Customer already has
+	// does nothing. ErrNotFound is returned if there is no Customer with the given id and ErrUnknownRole
+	// if there is no such role.
+	GrantRole(ctx context.Context, id uuid.UUID, role string) error
+
+	// RevokeRole revokes the role from the Customer with the given id. Revoking a role the Customer does not
+	// have does nothing. ErrNotFound is returned if there is no Customer with the given id and ErrUnknownRole
+	// if there is no such role.
+	RevokeRole(ctx context.Context, id uuid.UUID, role string) error
 }
 
 // Customer is the main user of our application.
@@ -26,29 +96,68 @@ type Customer struct {
 	id           uuid.UUID
 	username     string
 	passwordHash string
+	version      int
+	createdAt    time.Time
+	verifiedAt   *time.Time
+	deletedAt    *time.Time
+	roles        []string
+	permissions  []string
+	events       []event.Event
 }
 
-// New will create a new Customer and assign a new uuid.
-func New(username, password string) (*Customer, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+// New will create a new Customer and assign a new uuid. The username is normalized with NormalizeUsername
+// and the password is hashed with the given hasher.
+func New(username, password string, hasher PasswordHasher) (*Customer, error) {
+	username = NormalizeUsername(username)
+	if username == "" {
+		return nil, fmt.Errorf("unable to create customer: %w", ErrEmptyUsername)
+	}
+
+	hash, err := hashPassword(password, hasher)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create customer: %w", ErrGeneratePassword)
+		return nil, fmt.Errorf("unable to create customer: %w", err)
 	}
 
-	return &Customer{
+	c := &Customer{
 		id:           uuid.New(),
 		username:     username,
-		passwordHash: string(bytes),
-	}, nil
+		passwordHash: hash,
+		version:      1,
+		createdAt:    time.Now(),
+	}
+
+	c.record(Registered{CustomerID: c.id, Username: c.username, OccurredAt: c.createdAt})
+
+	return c, nil
+}
+
+// State holds the persisted values of a Customer so that a Repository can Restore it.
+type State struct {
+	ID           uuid.UUID
+	Username     string
+	PasswordHash string
+	Version      int
+	CreatedAt    time.Time
+	VerifiedAt   *time.Time
+	DeletedAt    *time.Time
+	Roles        []string
+	Permissions  []string
 }
 
-// Restore creates an Customer from existing credentials.
-// The password wll not be hashed and an existing id is required.
-func Restore(id uuid.UUID, username, hashedPassword string) *Customer {
+// Restore creates an Customer from existing state held in a Repository.
+// The password wll not be hashed and an existing id is required. The username is normalized as it may have
+// been stored before usernames were normalized.
+func Restore(s State) *Customer {
 	return &Customer{
-		id:           id,
-		username:     username,
-		passwordHash: hashedPassword,
+		id:           s.ID,
+		username:     NormalizeUsername(s.Username),
+		passwordHash: s.PasswordHash,
+		version:      s.Version,
+		createdAt:    s.CreatedAt,
+		verifiedAt:   s.VerifiedAt,
+		deletedAt:    s.DeletedAt,
+		roles:        s.Roles,
+		permissions:  s.Permissions,
 	}
 }
 
@@ -67,9 +176,108 @@ func (c *Customer) PasswordHash() string {
 	return c.passwordHash
 }
 
+// Version is incremented each time the Customer is updated in the Repository. It allows
+// us to detect concurrent modifications.
+func (c *Customer) Version() int {
+	return c.version
+}
+
+// CreatedAt is the time the Customer signed up.
+func (c *Customer) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// VerifiedAt is the time the Customer proved that they own their username. It is nil if the Customer
+// has not been verified.
+func (c *Customer) VerifiedAt() *time.Time {
+	return c.verifiedAt
+}
+
+// IsVerified returns true if the Customer has proved that they own their username. Handlers that
+// should only be used by genuine owners of an email address can require this.
+func (c *Customer) IsVerified() bool {
+	return c.verifiedAt != nil
+}
+
+// DeletedAt is the time the Customer was soft deleted. It is nil if the Customer has not been deleted.
+func (c *Customer) DeletedAt() *time.Time {
+	return c.deletedAt
+}
+
+// IsDeleted returns true if the Customer has been soft deleted.
+func (c *Customer) IsDeleted() bool {
+	return c.deletedAt != nil
+}
+
 // HasPassword returns true if the internal password hash matches the given password.
-func (c *Customer) HasPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(c.passwordHash), []byte(password))
+func (c *Customer) HasPassword(password string, hasher PasswordHasher) bool {
+	return hasher.Verify(c.passwordHash, password)
+}
+
+// PasswordNeedsRehash returns true if the password hash was made with an outdated algorithm or parameters.
+func (c *Customer) PasswordNeedsRehash(hasher PasswordHasher) bool {
+	return hasher.NeedsRehash(c.passwordHash)
+}
+
+// RehashPassword replaces the password hash with a new hash of the same password. The password must have
+// been checked with HasPassword first. Unlike ChangePassword the length rules are not applied so that
+// customers with passwords from before a rule was introduced are not locked out.
+func (c *Customer) RehashPassword(password string, hasher PasswordHasher) error {
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("unable to rehash password: %w", ErrGeneratePassword)
+	}
+
+	c.passwordHash = hash
+
+	return nil
+}
+
+// ChangeUsername replaces the username of the Customer. Uniqueness must be checked against the Repository.
+// Ownership of a new username has not been proven so the Customer is no longer verified, unless the new
+// username only differs by case.
+func (c *Customer) ChangeUsername(username string) error {
+	username = NormalizeUsername(username)
+	if username == "" {
+		return ErrEmptyUsername
+	}
+
+	if username == c.username {
+		return nil
+	}
+
+	if UsernameKey(username) != UsernameKey(c.username) {
+		c.verifiedAt = nil
+	}
+
+	c.record(UsernameChanged{CustomerID: c.id, From: c.username, To: username, OccurredAt: time.Now()})
+	c.username = username
+
+	return nil
+}
+
+// ChangePassword hashes the given password and replaces the existing password hash.
+func (c *Customer) ChangePassword(password string, hasher PasswordHasher) error {
+	hash, err := hashPassword(password, hasher)
+	if err != nil {
+		return fmt.Errorf("unable to change password: %w", err)
+	}
+
+	c.passwordHash = hash
+	c.record(PasswordChanged{CustomerID: c.id, OccurredAt: time.Now()})
+
+	return nil
+}
+
+func hashPassword(password string, hasher PasswordHasher) (string, error) {
+	if n := utf8.RuneCountInString(password); n < MinPasswordLength || n > MaxPasswordLength {
+		return "", ErrPasswordLength
+	}
+
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		return "", ErrGeneratePassword
+	}
 
-	return err == nil
+	return hash, nil
 }
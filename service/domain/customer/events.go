@@ -0,0 +1,127 @@
+package customer
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/event"
+)
+
+// The names of the events raised by a Customer.
+const (
+	EventRegistered      = "customer.registered"
+	EventUsernameChanged = "customer.username_changed"
+	EventPasswordChanged = "customer.password_changed"
+	EventErased          = "customer.erased"
+)
+
+// Registered is raised when a new Customer is created.
+type Registered struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	Username   string    `json:"username"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Name identifies the Registered event.
+func (Registered) Name() string {
+	return EventRegistered
+}
+
+// UsernameChanged is raised when a Customer changes their username.
+type UsernameChanged struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Name identifies the UsernameChanged event.
+func (UsernameChanged) Name() string {
+	return EventUsernameChanged
+}
+
+// PasswordChanged is raised when a Customer chooses a new password. Rehashing an existing password does
+// not raise it.
+type PasswordChanged struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Name identifies the PasswordChanged event.
+func (PasswordChanged) Name() string {
+	return EventPasswordChanged
+}
+
+// Erased is raised when the personal data held about a Customer is erased so that the systems we publish
+// events to can erase their copies.
+type Erased struct {
+	CustomerID uuid.UUID `json:"customer_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Name identifies the Erased event.
+func (Erased) Name() string {
+	return EventErased
+}
+
+func (c *Customer) record(e event.Event) {
+	c.events = append(c.events, e)
+}
+
+// Events returns the events raised by the Customer since they were last pulled without forgetting them so
+// that they can be written to the outbox alongside the Customer.
+func (c *Customer) Events() []event.Event {
+	return append([]event.Event(nil), c.events...)
+}
+
+// PullEvents returns the events raised by the Customer since they were last pulled and forgets them so
+// that each event is only dispatched once.
+func (c *Customer) PullEvents() []event.Event {
+	events := c.events
+	c.events = nil
+
+	return events
+}
+
+// Dispatcher passes the events raised by a Customer on to the subscribers that react to them.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, events ...event.Event)
+}
+
+// DispatchingRepository wraps a Repository so that the events raised by a Customer are dispatched once the
+// Customer has been saved. Nothing is dispatched if saving fails so subscribers never react to changes that
+// did not happen.
+type DispatchingRepository struct {
+	Repository
+	dispatcher Dispatcher
+}
+
+// NewDispatchingRepository creates a DispatchingRepository that saves to the Repository and dispatches to the
+// Dispatcher.
+func NewDispatchingRepository(repo Repository, d Dispatcher) *DispatchingRepository {
+	return &DispatchingRepository{Repository: repo, dispatcher: d}
+}
+
+// Add a new Customer to the Repository and dispatch the events it has raised.
+func (r *DispatchingRepository) Add(ctx context.Context, c *Customer) error {
+	if err := r.Repository.Add(ctx, c); err != nil {
+		return err
+	}
+
+	r.dispatcher.Dispatch(ctx, c.PullEvents()...)
+
+	return nil
+}
+
+// Update persists the changes made to an existing Customer and dispatches the events it has raised.
+func (r *DispatchingRepository) Update(ctx context.Context, c *Customer) (*Customer, error) {
+	updated, err := r.Repository.Update(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	r.dispatcher.Dispatch(ctx, c.PullEvents()...)
+
+	return updated, nil
+}
@@ -0,0 +1,122 @@
+package customer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app/event"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/stretchr/testify/assert"
+)
+
+// plainHasher stores passwords as they are, which is good enough for testing the Customer.
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error) {
+	return password, nil
+}
+
+func (plainHasher) Verify(hash, password string) bool {
+	return hash == password
+}
+
+func (plainHasher) NeedsRehash(string) bool {
+	return false
+}
+
+func eventNames(events []event.Event) []string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.Name())
+	}
+
+	return names
+}
+
+func TestCustomerEvents(t *testing.T) {
+	t.Parallel()
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := c.ChangeUsername("bob@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	if err := c.ChangeUsername("alice@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	if err := c.ChangePassword("N3wS3cr3t", plainHasher{}); err != nil {
+		t.Fatalf("unable to change password: %v", err)
+	}
+
+	events := c.PullEvents()
+
+	assert.Equal(
+		t,
+		[]string{customer.EventRegistered, customer.EventUsernameChanged, customer.EventPasswordChanged},
+		eventNames(events),
+		"unchanged usernames do not raise an event",
+	)
+
+	if changed, ok := events[1].(customer.UsernameChanged); assert.True(t, ok) {
+		assert.Equal(t, c.ID(), changed.CustomerID)
+		assert.Equal(t, "bob@example.org", changed.From)
+		assert.Equal(t, "alice@example.org", changed.To)
+	}
+
+	assert.Empty(t, c.PullEvents(), "events are only pulled once")
+}
+
+type dispatcherFunc func(ctx context.Context, events ...event.Event)
+
+func (f dispatcherFunc) Dispatch(ctx context.Context, events ...event.Event) {
+	f(ctx, events...)
+}
+
+// failingRepository fails to save every Customer.
+type failingRepository struct {
+	customer.Repository
+}
+
+func (failingRepository) Add(context.Context, *customer.Customer) error {
+	return errors.New("unable to add customer")
+}
+
+func TestDispatchingRepository(t *testing.T) {
+	t.Parallel()
+
+	var dispatched []string
+
+	d := dispatcherFunc(func(_ context.Context, events ...event.Event) {
+		dispatched = append(dispatched, eventNames(events)...)
+	})
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", plainHasher{})
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	err = customer.NewDispatchingRepository(failingRepository{}, d).Add(context.Background(), c)
+	assert.Error(t, err)
+	assert.Empty(t, dispatched, "events are not dispatched if the customer was not saved")
+
+	if err := customer.NewDispatchingRepository(savingRepository{}, d).Add(context.Background(), c); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	assert.Equal(t, []string{customer.EventRegistered}, dispatched)
+}
+
+// savingRepository saves every Customer without storing it anywhere.
+type savingRepository struct {
+	customer.Repository
+}
+
+func (savingRepository) Add(context.Context, *customer.Customer) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package customer
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor given in the ListOptions can not be used.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// The orders that a list of customers can be sorted by. A leading - denotes descending order.
+const (
+	SortCreatedAt     = "created_at"
+	SortCreatedAtDesc = "-created_at"
+	SortUsername      = "username"
+	SortUsernameDesc  = "-username"
+)
+
+// Limits applied to the number of customers returned in a single Page.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListOptions control which customers are returned by the Repository when listing.
+type ListOptions struct {
+	// Limit is the maximum number of customers to return in the Page.
+	Limit int
+
+	// Cursor is an opaque value taken from a previous Page. An empty Cursor returns the first Page.
+	Cursor string
+
+	// Sort is one of the Sort constants. Defaults to SortCreatedAt.
+	Sort string
+
+	// CreatedAfter only includes customers created at or after the given time when set.
+	CreatedAfter *time.Time
+
+	// CreatedBefore only includes customers created before the given time when set.
+	CreatedBefore *time.Time
+
+	// IncludeDeleted includes customers that have been soft deleted in the Page.
+	IncludeDeleted bool
+}
+
+// Page is a subset of customers returned from the Repository when listing.
+type Page struct {
+	Customers []*Customer
+
+	// NextCursor retrieves the following Page. It is empty when there are no more customers.
+	NextCursor string
+
+	// PrevCursor retrieves the preceding Page. It is empty when on the first Page.
+	PrevCursor string
+}
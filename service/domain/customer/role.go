@@ -0,0 +1,78 @@
+package customer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// RoleAdmin is created by the migrations with every permission. Other roles can be added to the roles table.
+const RoleAdmin = "admin"
+
+// Permissions that can be granted to a role. Handlers declare the permissions they require.
+const (
+	PermissionManageCustomers    = "customers:manage"
+	PermissionManageRoles        = "roles:manage"
+	PermissionUnlockLogins       = "logins:unlock"
+	PermissionManagePersonalData = "personal_data:manage"
+	PermissionReadAuditLog       = "audit:read"
+)
+
+var ErrUnknownRole = errors.New("role does not exist")
+
+// Authorizer checks the permissions of customers held in a Repository.
+type Authorizer struct {
+	repo Repository
+}
+
+// NewAuthorizer creates an Authorizer that looks customers up in the Repository.
+func NewAuthorizer(repo Repository) *Authorizer {
+	return &Authorizer{repo: repo}
+}
+
+// Authorize returns true if the Customer with the given id exists and has every one of the permissions.
+func (a *Authorizer) Authorize(ctx context.Context, id uuid.UUID, permissions []string) (bool, error) {
+	c, err := a.repo.FindByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return c != nil && c.HasPermissions(permissions...), nil
+}
+
+// Roles are the names of the roles that have been granted to the Customer.
+func (c *Customer) Roles() []string {
+	return c.roles
+}
+
+// Permissions are the permissions granted to the Customer through their roles.
+func (c *Customer) Permissions() []string {
+	return c.permissions
+}
+
+// HasRole returns true if the role has been granted to the Customer.
+func (c *Customer) HasRole(role string) bool {
+	return contains(c.roles, role)
+}
+
+// HasPermissions returns true if every one of the permissions has been granted to the Customer.
+func (c *Customer) HasPermissions(permissions ...string) bool {
+	for _, p := range permissions {
+		if !contains(c.permissions, p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
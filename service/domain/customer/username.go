@@ -0,0 +1,32 @@
+package customer
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// usernameSpace is the whitespace trimmed from around usernames. It must match the characters trimmed by the
+// username_key column in the database so that both compute the same key.
+const usernameSpace = " \t\n\r\f\v"
+
+// NormalizeUsername returns the username in the form it is stored in. Surrounding whitespace is removed,
+// the username is put into Unicode NFC so that visually identical usernames are stored the same way and
+// the domain, which is case insensitive, is lowercased. The case of the local part is kept for display.
+func NormalizeUsername(username string) string {
+	username = norm.NFC.String(strings.Trim(username, usernameSpace))
+
+	at := strings.LastIndex(username, "@")
+	if at < 0 {
+		return username
+	}
+
+	return username[:at+1] + strings.ToLower(username[at+1:])
+}
+
+// UsernameKey returns the key that usernames are looked up and compared by. Mail providers treat the
+// local part of an address as case insensitive so usernames that only differ by case share a key and
+// belong to the same Customer.
+func UsernameKey(username string) string {
+	return strings.ToLower(NormalizeUsername(username))
+}
@@ -0,0 +1,83 @@
+package customer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		username string
+		want     string
+		wantKey  string
+	}{
+		{
+			name:     "surrounding whitespace is removed",
+			username: "  bob@example.org\t",
+			want:     "bob@example.org",
+			wantKey:  "bob@example.org",
+		},
+		{
+			name:     "only the whitespace trimmed by the database is removed",
+			username: "\u00a0bob@example.org\v",
+			want:     "\u00a0bob@example.org",
+			wantKey:  "\u00a0bob@example.org",
+		},
+		{
+			name:     "the domain is lowercased and the local part keeps its case",
+			username: "Bob@Example.ORG",
+			want:     "Bob@example.org",
+			wantKey:  "bob@example.org",
+		},
+		{
+			name:     "decomposed characters are composed",
+			username: "jose\u0301@example.org",
+			want:     "jos\u00e9@example.org",
+			wantKey:  "jos\u00e9@example.org",
+		},
+		{
+			name:     "usernames without a domain are only trimmed",
+			username: " Bob ",
+			want:     "Bob",
+			wantKey:  "bob",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, customer.NormalizeUsername(tc.username))
+			assert.Equal(t, tc.wantKey, customer.UsernameKey(tc.username))
+		})
+	}
+}
+
+func TestChangeUsernameOnlyUnverifiesNewUsernames(t *testing.T) {
+	t.Parallel()
+
+	verifiedAt := time.Now()
+	c := customer.Restore(customer.State{Username: "bob@example.org", VerifiedAt: &verifiedAt})
+
+	if err := c.ChangeUsername(" Bob@EXAMPLE.org "); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	assert.Equal(t, "Bob@example.org", c.Username())
+	assert.True(t, c.IsVerified())
+
+	if err := c.ChangeUsername("alice@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	assert.False(t, c.IsVerified())
+	assert.True(t, errors.Is(c.ChangeUsername("  "), customer.ErrEmptyUsername))
+}
@@ -0,0 +1,417 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/money"
+)
+
+// The types of Account. Asset and expense accounts grow with debits while liability and revenue accounts
+// grow with credits.
+const (
+	TypeAsset     = "asset"
+	TypeLiability = "liability"
+	TypeRevenue   = "revenue"
+	TypeExpense   = "expense"
+)
+
+// AccountCash is the code of the asset account that money enters and leaves the service through.
+const AccountCash = "cash"
+
+// Limits applied to the number of lines returned in a single HistoryPage.
+const (
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 100
+)
+
+var (
+	ErrTooFewPostings = errors.New("journal entry must have at least two postings")
+	ErrInvalidAmount  = errors.New("posting amount must be greater than zero")
+	ErrUnbalanced     = errors.New("journal entry debits and credits must balance")
+	ErrEmptyCode      = errors.New("account code can not be empty")
+	ErrUnknownType    = errors.New("unknown account type")
+	ErrUnknownAccount = errors.New("account not found")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+)
+
+// Repository represents our entity storage for accounts and journal entries. Entries are immutable once
+// they have been recorded, mistakes are corrected by recording a reversing Entry.
+type Repository interface {
+	// EnsureAccount returns the stored Account with the same code as the given Account, adding the given
+	// Account if there is none.
+	EnsureAccount(ctx context.Context, a *Account) (*Account, error)
+
+	// FindAccountByCustomer searches the repository for the Account belonging to the customer.
+	// If none is found then nil is returned.
+	FindAccountByCustomer(ctx context.Context, customerID uuid.UUID) (*Account, error)
+
+	// Record persists the Entry along with all of its postings or nothing at all. ErrUnknownAccount is
+	// returned if a posting is made to an Account that does not exist.
+	Record(ctx context.Context, e *Entry) error
+
+	// Net returns the debits minus the credits posted to the Account with the given id.
+	Net(ctx context.Context, accountID uuid.UUID) (money.Amount, error)
+
+	// History returns a HistoryPage of the postings made to the Account with the given id, newest first.
+	// ErrInvalidCursor is returned if the HistoryOptions.Cursor can not be used.
+	History(ctx context.Context, accountID uuid.UUID, opts HistoryOptions) (*HistoryPage, error)
+}
+
+// Account holds a running balance in the ledger. Each customer has their own liability Account as the
+// money it holds is owed to the customer.
+type Account struct {
+	id          uuid.UUID
+	code        string
+	accountType string
+	customerID  *uuid.UUID
+	createdAt   time.Time
+}
+
+// NewAccount creates an Account with the given unique code and one of the Type constants.
+func NewAccount(code, accountType string) (*Account, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("unable to create account: %w", ErrEmptyCode)
+	}
+
+	switch accountType {
+	case TypeAsset, TypeLiability, TypeRevenue, TypeExpense:
+	default:
+		return nil, fmt.Errorf("unable to create account %q: %w", accountType, ErrUnknownType)
+	}
+
+	return &Account{
+		id:          uuid.New(),
+		code:        code,
+		accountType: accountType,
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// NewCustomerAccount creates the liability Account that holds the balance of the customer.
+func NewCustomerAccount(customerID uuid.UUID) *Account {
+	return &Account{
+		id:          uuid.New(),
+		code:        "customer:" + customerID.String(),
+		accountType: TypeLiability,
+		customerID:  &customerID,
+		createdAt:   time.Now(),
+	}
+}
+
+// AccountState holds the persisted values of an Account so that a Repository can RestoreAccount it.
+type AccountState struct {
+	ID         uuid.UUID
+	Code       string
+	Type       string
+	CustomerID *uuid.UUID
+	CreatedAt  time.Time
+}
+
+// RestoreAccount creates an Account from existing state held in a Repository.
+func RestoreAccount(s AccountState) *Account {
+	return &Account{
+		id:          s.ID,
+		code:        s.Code,
+		accountType: s.Type,
+		customerID:  s.CustomerID,
+		createdAt:   s.CreatedAt,
+	}
+}
+
+// ID uniquely identifies an Account within the application.
+func (a *Account) ID() uuid.UUID {
+	return a.id
+}
+
+// Code uniquely identifies an Account within the ledger in a human readable way.
+func (a *Account) Code() string {
+	return a.code
+}
+
+// Type is one of the Type constants.
+func (a *Account) Type() string {
+	return a.accountType
+}
+
+// CustomerID is the id of the customer that owns the Account. It is nil for accounts of the service.
+func (a *Account) CustomerID() *uuid.UUID {
+	return a.customerID
+}
+
+// CreatedAt is the time the Account was opened.
+func (a *Account) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// Balance converts the net debits posted to the Account into its balance. Accounts that grow with credits
+// have a positive balance when they have been credited more than debited.
+func (a *Account) Balance(net money.Amount) money.Amount {
+	if a.accountType == TypeLiability || a.accountType == TypeRevenue {
+		return -net
+	}
+
+	return net
+}
+
+// Posting is a single debit or credit to an Account within an Entry.
+type Posting struct {
+	accountID uuid.UUID
+	amount    money.Amount
+}
+
+// Debit creates a Posting that debits the amount from the Account with the given id.
+func Debit(accountID uuid.UUID, amount money.Amount) Posting {
+	if amount <= 0 {
+		return Posting{accountID: accountID}
+	}
+
+	return Posting{accountID: accountID, amount: amount}
+}
+
+// Credit creates a Posting that credits the amount to the Account with the given id.
+func Credit(accountID uuid.UUID, amount money.Amount) Posting {
+	if amount <= 0 {
+		return Posting{accountID: accountID}
+	}
+
+	return Posting{accountID: accountID, amount: -amount}
+}
+
+// RestorePosting creates a Posting from the signed amount held in a Repository.
+func RestorePosting(accountID uuid.UUID, amount money.Amount) Posting {
+	return Posting{accountID: accountID, amount: amount}
+}
+
+// AccountID is the id of the Account the Posting is made to.
+func (p Posting) AccountID() uuid.UUID {
+	return p.accountID
+}
+
+// Amount is positive for debits and negative for credits.
+func (p Posting) Amount() money.Amount {
+	return p.amount
+}
+
+// Entry is a journal entry recording a single transaction. The debits and credits of its postings always
+// balance so that money is never created or lost.
+type Entry struct {
+	id          uuid.UUID
+	description string
+	postings    []Posting
+	createdAt   time.Time
+}
+
+// NewEntry creates an Entry from the given postings. The postings must balance and each must have an amount
+// greater than zero.
+func NewEntry(description string, postings ...Posting) (*Entry, error) {
+	if len(postings) < 2 {
+		return nil, fmt.Errorf("unable to create journal entry: %w", ErrTooFewPostings)
+	}
+
+	var debits, credits money.Amount
+
+	for _, p := range postings {
+		switch {
+		case p.amount == 0:
+			return nil, fmt.Errorf("unable to create journal entry: %w", ErrInvalidAmount)
+		case p.amount > 0:
+			debits += p.amount
+		default:
+			credits -= p.amount
+		}
+
+		// The totals only overflow if the postings could never be stored.
+		if debits < 0 || credits < 0 {
+			return nil, fmt.Errorf("unable to create journal entry: %w", ErrInvalidAmount)
+		}
+	}
+
+	if debits != credits {
+		return nil, fmt.Errorf("unable to create journal entry, debits %s credits %s: %w", debits, credits, ErrUnbalanced)
+	}
+
+	return &Entry{
+		id:          uuid.New(),
+		description: description,
+		postings:    postings,
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// EntryState holds the persisted values of an Entry so that a Repository can RestoreEntry it.
+type EntryState struct {
+	ID          uuid.UUID
+	Description string
+	Postings    []Posting
+	CreatedAt   time.Time
+}
+
+// RestoreEntry creates an Entry from existing state held in a Repository.
+func RestoreEntry(s EntryState) *Entry {
+	return &Entry{
+		id:          s.ID,
+		description: s.Description,
+		postings:    s.Postings,
+		createdAt:   s.CreatedAt,
+	}
+}
+
+// ID uniquely identifies an Entry within the application.
+func (e *Entry) ID() uuid.UUID {
+	return e.id
+}
+
+// Description explains the transaction that the Entry records.
+func (e *Entry) Description() string {
+	return e.description
+}
+
+// Postings are the debits and credits that make up the Entry.
+func (e *Entry) Postings() []Posting {
+	return e.postings
+}
+
+// CreatedAt is the time the Entry was recorded.
+func (e *Entry) CreatedAt() time.Time {
+	return e.createdAt
+}
+
+// HistoryOptions control which postings are returned by the Repository when fetching the history of an Account.
+type HistoryOptions struct {
+	// Limit is the maximum number of lines to return in the HistoryPage.
+	Limit int
+
+	// Cursor is an opaque value taken from a previous HistoryPage. An empty Cursor returns the first page.
+	Cursor string
+}
+
+// Line is a single Posting to an Account along with the Entry it belongs to.
+type Line struct {
+	EntryID     uuid.UUID
+	Description string
+	Amount      money.Amount
+	CreatedAt   time.Time
+}
+
+// HistoryPage is a subset of the lines of an Account returned from the Repository.
+type HistoryPage struct {
+	Lines []Line
+
+	// NextCursor retrieves the following, older, HistoryPage. It is empty when there are no more lines.
+	NextCursor string
+}
+
+// Ledger records the credits and debits of customers against the cash Account of the service.
+type Ledger struct {
+	repo Repository
+}
+
+// NewLedger creates a Ledger that records entries in the Repository.
+func NewLedger(repo Repository) *Ledger {
+	return &Ledger{repo: repo}
+}
+
+// Credit records that the amount has been paid to the customer, increasing their balance.
+func (l *Ledger) Credit(
+	ctx context.Context,
+	customerID uuid.UUID,
+	amount money.Amount,
+	description string,
+) (*Entry, error) {
+	return l.transfer(ctx, customerID, description, func(cash, cust *Account) []Posting {
+		return []Posting{Debit(cash.ID(), amount), Credit(cust.ID(), amount)}
+	})
+}
+
+// Debit records that the amount has been taken from the customer, decreasing their balance.
+func (l *Ledger) Debit(
+	ctx context.Context,
+	customerID uuid.UUID,
+	amount money.Amount,
+	description string,
+) (*Entry, error) {
+	return l.transfer(ctx, customerID, description, func(cash, cust *Account) []Posting {
+		return []Posting{Debit(cust.ID(), amount), Credit(cash.ID(), amount)}
+	})
+}
+
+func (l *Ledger) transfer(
+	ctx context.Context,
+	customerID uuid.UUID,
+	description string,
+	postings func(cash, cust *Account) []Posting,
+) (*Entry, error) {
+	cashAccount, err := NewAccount(AccountCash, TypeAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	cash, err := l.repo.EnsureAccount(ctx, cashAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	cust, err := l.repo.EnsureAccount(ctx, NewCustomerAccount(customerID))
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := NewEntry(description, postings(cash, cust)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.repo.Record(ctx, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Balance returns the balance of the customer. Customers without an Account have a zero balance.
+func (l *Ledger) Balance(ctx context.Context, customerID uuid.UUID) (money.Amount, error) {
+	a, err := l.repo.FindAccountByCustomer(ctx, customerID)
+	if err != nil || a == nil {
+		return money.Zero(), err
+	}
+
+	net, err := l.repo.Net(ctx, a.ID())
+	if err != nil {
+		return money.Zero(), err
+	}
+
+	return a.Balance(net), nil
+}
+
+// History returns a HistoryPage of the transactions of the customer. The amount of each Line is given as the
+// change it made to the balance of the customer so credits are positive and debits are negative.
+func (l *Ledger) History(ctx context.Context, customerID uuid.UUID, opts HistoryOptions) (*HistoryPage, error) {
+	a, err := l.repo.FindAccountByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a == nil {
+		if opts.Cursor != "" {
+			return nil, ErrInvalidCursor
+		}
+
+		return &HistoryPage{Lines: []Line{}}, nil
+	}
+
+	page, err := l.repo.History(ctx, a.ID(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range page.Lines {
+		page.Lines[i].Amount = a.Balance(page.Lines[i].Amount)
+	}
+
+	return page, nil
+}
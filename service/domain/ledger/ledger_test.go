@@ -0,0 +1,210 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEntry(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		postings []ledger.Posting
+		err      error
+	}{
+		{
+			name:     "a single posting can not balance",
+			postings: []ledger.Posting{ledger.Debit(a, 100)},
+			err:      ledger.ErrTooFewPostings,
+		},
+		{
+			name:     "debits must equal credits",
+			postings: []ledger.Posting{ledger.Debit(a, 100), ledger.Credit(b, 99)},
+			err:      ledger.ErrUnbalanced,
+		},
+		{
+			name:     "amounts must be greater than zero",
+			postings: []ledger.Posting{ledger.Debit(a, -100), ledger.Credit(b, -100)},
+			err:      ledger.ErrInvalidAmount,
+		},
+		{
+			name:     "totals must not overflow",
+			postings: []ledger.Posting{ledger.Debit(a, money.MaxAmount), ledger.Debit(b, 1), ledger.Credit(c, 1)},
+			err:      ledger.ErrInvalidAmount,
+		},
+		{
+			name:     "balanced postings create an entry",
+			postings: []ledger.Posting{ledger.Debit(a, 100), ledger.Credit(b, 60), ledger.Credit(c, 40)},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := ledger.NewEntry("test", tc.postings...)
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err), err)
+				assert.Nil(t, e)
+
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.postings, e.Postings())
+			}
+		})
+	}
+}
+
+func TestAccountBalance(t *testing.T) {
+	t.Parallel()
+
+	cash, err := ledger.NewAccount(ledger.AccountCash, ledger.TypeAsset)
+	if err != nil {
+		t.Fatalf("unable to create account: %v", err)
+	}
+
+	assert.Equal(t, money.Amount(100), cash.Balance(100))
+	assert.Equal(t, money.Amount(-100), ledger.NewCustomerAccount(uuid.New()).Balance(100))
+
+	_, err = ledger.NewAccount("unknown", "unknown")
+	assert.True(t, errors.Is(err, ledger.ErrUnknownType))
+}
+
+func TestLedger(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository()
+	l := ledger.NewLedger(repo)
+	ctx := context.Background()
+	customerID := uuid.New()
+
+	balance, err := l.Balance(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to fetch balance: %v", err)
+	}
+
+	assert.Equal(t, money.Zero(), balance)
+
+	if _, err := l.Credit(ctx, customerID, 10*money.Pound, "top up"); err != nil {
+		t.Fatalf("unable to credit customer: %v", err)
+	}
+
+	if _, err := l.Debit(ctx, customerID, 250*money.Penny, "purchase"); err != nil {
+		t.Fatalf("unable to debit customer: %v", err)
+	}
+
+	_, err = l.Debit(ctx, customerID, 0, "nothing")
+	assert.True(t, errors.Is(err, ledger.ErrInvalidAmount))
+
+	balance, err = l.Balance(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to fetch balance: %v", err)
+	}
+
+	assert.Equal(t, 750*money.Penny, balance)
+
+	page, err := l.History(ctx, customerID, ledger.HistoryOptions{})
+	if err != nil {
+		t.Fatalf("unable to fetch history: %v", err)
+	}
+
+	if assert.Len(t, page.Lines, 2) {
+		assert.Equal(t, "purchase", page.Lines[0].Description)
+		assert.Equal(t, -250*money.Penny, page.Lines[0].Amount)
+		assert.Equal(t, "top up", page.Lines[1].Description)
+		assert.Equal(t, 10*money.Pound, page.Lines[1].Amount)
+	}
+
+	// Money taken from customers is held by the cash account so the ledger as a whole always balances.
+	var total money.Amount
+	for _, p := range repo.postings {
+		total += p.posting.Amount()
+	}
+
+	assert.Equal(t, money.Zero(), total)
+}
+
+type recordedPosting struct {
+	entry   *ledger.Entry
+	posting ledger.Posting
+}
+
+// repository is an in memory ledger.Repository.
+type repository struct {
+	accounts map[string]*ledger.Account
+	postings []recordedPosting
+}
+
+func newRepository() *repository {
+	return &repository{accounts: make(map[string]*ledger.Account)}
+}
+
+func (r *repository) EnsureAccount(_ context.Context, a *ledger.Account) (*ledger.Account, error) {
+	if existing, ok := r.accounts[a.Code()]; ok {
+		return existing, nil
+	}
+
+	r.accounts[a.Code()] = a
+
+	return a, nil
+}
+
+func (r *repository) FindAccountByCustomer(_ context.Context, customerID uuid.UUID) (*ledger.Account, error) {
+	for _, a := range r.accounts {
+		if a.CustomerID() != nil && *a.CustomerID() == customerID {
+			return a, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *repository) Record(_ context.Context, e *ledger.Entry) error {
+	for _, p := range e.Postings() {
+		r.postings = append(r.postings, recordedPosting{entry: e, posting: p})
+	}
+
+	return nil
+}
+
+func (r *repository) Net(_ context.Context, accountID uuid.UUID) (money.Amount, error) {
+	var net money.Amount
+
+	for _, p := range r.postings {
+		if p.posting.AccountID() == accountID {
+			net += p.posting.Amount()
+		}
+	}
+
+	return net, nil
+}
+
+// History returns every line of the account, newest first.
+func (r *repository) History(_ context.Context, accountID uuid.UUID, _ ledger.HistoryOptions) (*ledger.HistoryPage, error) {
+	page := &ledger.HistoryPage{}
+
+	for i := len(r.postings) - 1; i >= 0; i-- {
+		if p := r.postings[i]; p.posting.AccountID() == accountID {
+			page.Lines = append(page.Lines, ledger.Line{
+				EntryID:     p.entry.ID(),
+				Description: p.entry.Description(),
+				Amount:      p.posting.Amount(),
+				CreatedAt:   p.entry.CreatedAt(),
+			})
+		}
+	}
+
+	return page, nil
+}
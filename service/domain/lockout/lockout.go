@@ -0,0 +1,179 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/nickbryan/go-template/service/domain/customer"
+)
+
+// The scopes that failed login attempts are counted in.
+const (
+	ScopeUsername = "username"
+	ScopeIP       = "ip"
+)
+
+// Repository represents our entity storage for Attempts.
+type Repository interface {
+	// Find returns the Attempts recorded for the key within the scope. If none is found then nil is returned.
+	Find(ctx context.Context, scope, key string) (*Attempts, error)
+
+	// RecordFailure atomically adds a failed attempt for the key within the scope at the given time. Failures
+	// before resetBefore are forgotten so that the count starts again.
+	RecordFailure(ctx context.Context, scope, key string, at, resetBefore time.Time) error
+
+	// Reset forgets every failed attempt for the key within the scope.
+	Reset(ctx context.Context, scope, key string) error
+}
+
+// Policy decides how failed login attempts are punished. After DelayAfter consecutive failures each
+// further attempt has to wait for a delay that starts at BaseDelay and doubles with every failure up to
+// MaxDelay. After LockAfter failures no attempts are allowed until LockDuration has passed. Failures are
+// forgotten once ResetAfter has passed since the last one.
+type Policy struct {
+	DelayAfter   int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockDuration time.Duration
+	ResetAfter   time.Duration
+}
+
+// Attempts counts the consecutive failed login attempts for a key such as a username or client IP.
+type Attempts struct {
+	failures     int
+	lastFailedAt time.Time
+}
+
+// State holds the persisted values of Attempts so that a Repository can Restore them.
+type State struct {
+	Failures     int
+	LastFailedAt time.Time
+}
+
+// Restore creates Attempts from existing state held in a Repository.
+func Restore(s State) *Attempts {
+	return &Attempts{failures: s.Failures, lastFailedAt: s.LastFailedAt}
+}
+
+// Failures is the number of consecutive failed attempts.
+func (a *Attempts) Failures() int {
+	return a.failures
+}
+
+// LastFailedAt is the time of the most recent failed attempt.
+func (a *Attempts) LastFailedAt() time.Time {
+	return a.lastFailedAt
+}
+
+// RetryAfter returns how long must pass from now before another attempt is allowed under the Policy.
+// Zero is returned if an attempt is allowed straight away.
+func (a *Attempts) RetryAfter(p Policy, now time.Time) time.Duration {
+	if a == nil || now.Sub(a.lastFailedAt) >= p.ResetAfter {
+		return 0
+	}
+
+	var wait time.Duration
+
+	switch {
+	case p.LockAfter > 0 && a.failures >= p.LockAfter:
+		wait = p.LockDuration
+	case a.failures >= p.DelayAfter:
+		wait = p.BaseDelay
+
+		for i := p.DelayAfter; i < a.failures && wait < p.MaxDelay; i++ {
+			wait *= 2
+		}
+
+		if wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+	}
+
+	if remaining := a.lastFailedAt.Add(wait).Sub(now); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
+
+// Guard applies a Policy to the failed login attempts made for each username and from each client IP.
+// Usernames are tracked whether or not a customer has them so that the Guard behaves the same for
+// accounts that do not exist.
+type Guard struct {
+	repo     Repository
+	username Policy
+	ip       Policy
+}
+
+// NewGuard creates a Guard that applies the username Policy per username and the ip Policy per client IP.
+func NewGuard(repo Repository, username, ip Policy) *Guard {
+	return &Guard{repo: repo, username: username, ip: ip}
+}
+
+// Check returns how long must pass before the username can attempt to login from the IP. Zero is returned
+// if an attempt is allowed.
+func (g *Guard) Check(ctx context.Context, username, ip string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+
+	for _, k := range g.keys(username, ip) {
+		attempts, err := g.repo.Find(ctx, k.scope, k.key)
+		if err != nil {
+			return 0, err
+		}
+
+		if w := attempts.RetryAfter(k.policy, now); w > wait {
+			wait = w
+		}
+	}
+
+	return wait, nil
+}
+
+// Fail records a failed attempt for the username and the IP.
+func (g *Guard) Fail(ctx context.Context, username, ip string, now time.Time) error {
+	for _, k := range g.keys(username, ip) {
+		if err := g.repo.RecordFailure(ctx, k.scope, k.key, now, now.Add(-k.policy.ResetAfter)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Succeed forgets the failed attempts for the username. Failures from the IP are left to expire so that an
+// attacker can not clear them by logging into an account of their own.
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.repo.Reset(ctx, ScopeUsername, customer.UsernameKey(username))
+}
+
+// Unlock forgets the failed attempts for the username or IP. Empty values are ignored.
+func (g *Guard) Unlock(ctx context.Context, username, ip string) error {
+	for _, k := range g.keys(username, ip) {
+		if err := g.repo.Reset(ctx, k.scope, k.key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type guardKey struct {
+	scope  string
+	key    string
+	policy Policy
+}
+
+func (g *Guard) keys(username, ip string) []guardKey {
+	keys := make([]guardKey, 0, 2)
+
+	if username = customer.UsernameKey(username); username != "" {
+		keys = append(keys, guardKey{ScopeUsername, username, g.username})
+	}
+
+	if ip != "" {
+		keys = append(keys, guardKey{ScopeIP, ip, g.ip})
+	}
+
+	return keys
+}
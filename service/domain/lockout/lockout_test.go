@@ -0,0 +1,179 @@
+package lockout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPolicy() lockout.Policy {
+	return lockout.Policy{
+		DelayAfter:   3,
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+		LockAfter:    10,
+		LockDuration: time.Hour,
+		ResetAfter:   2 * time.Hour,
+	}
+}
+
+func TestAttemptsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		attempts *lockout.Attempts
+		expected time.Duration
+	}{
+		{
+			name:     "no attempts are allowed",
+			attempts: nil,
+			expected: 0,
+		},
+		{
+			name:     "failures below the delay threshold are allowed",
+			attempts: lockout.Restore(lockout.State{Failures: 2, LastFailedAt: now}),
+			expected: 0,
+		},
+		{
+			name:     "failures at the delay threshold wait for the base delay",
+			attempts: lockout.Restore(lockout.State{Failures: 3, LastFailedAt: now}),
+			expected: time.Second,
+		},
+		{
+			name:     "the delay doubles with each failure",
+			attempts: lockout.Restore(lockout.State{Failures: 5, LastFailedAt: now}),
+			expected: 4 * time.Second,
+		},
+		{
+			name:     "the delay is capped",
+			attempts: lockout.Restore(lockout.State{Failures: 9, LastFailedAt: now}),
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "time since the last failure counts towards the delay",
+			attempts: lockout.Restore(lockout.State{Failures: 5, LastFailedAt: now.Add(-3 * time.Second)}),
+			expected: time.Second,
+		},
+		{
+			name:     "failures at the lock threshold are locked",
+			attempts: lockout.Restore(lockout.State{Failures: 10, LastFailedAt: now}),
+			expected: time.Hour,
+		},
+		{
+			name:     "locks expire",
+			attempts: lockout.Restore(lockout.State{Failures: 10, LastFailedAt: now.Add(-time.Hour)}),
+			expected: 0,
+		},
+		{
+			name:     "old failures are forgotten",
+			attempts: lockout.Restore(lockout.State{Failures: 50, LastFailedAt: now.Add(-3 * time.Hour)}),
+			expected: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.attempts.RetryAfter(testPolicy(), now))
+		})
+	}
+}
+
+// memoryRepository is an in memory lockout.Repository.
+type memoryRepository map[string]*lockout.Attempts
+
+func (m memoryRepository) Find(_ context.Context, scope, key string) (*lockout.Attempts, error) {
+	return m[scope+":"+key], nil
+}
+
+func (m memoryRepository) RecordFailure(_ context.Context, scope, key string, at, resetBefore time.Time) error {
+	failures := 1
+	if a, ok := m[scope+":"+key]; ok && !a.LastFailedAt().Before(resetBefore) {
+		failures = a.Failures() + 1
+	}
+
+	m[scope+":"+key] = lockout.Restore(lockout.State{Failures: failures, LastFailedAt: at})
+
+	return nil
+}
+
+func (m memoryRepository) Reset(_ context.Context, scope, key string) error {
+	delete(m, scope+":"+key)
+
+	return nil
+}
+
+func TestGuard(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	repo := memoryRepository{}
+
+	ipPolicy := testPolicy()
+	ipPolicy.DelayAfter = 100
+
+	g := lockout.NewGuard(repo, testPolicy(), ipPolicy)
+
+	for i := 0; i < 3; i++ {
+		if err := g.Fail(ctx, " Bob@Example.org", "10.0.0.1", now); err != nil {
+			t.Fatalf("unable to record failure: %v", err)
+		}
+	}
+
+	wait, err := g.Check(ctx, "bob@example.org", "10.0.0.2", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.Equal(t, time.Second, wait, "usernames are throttled regardless of case or IP")
+
+	wait, err = g.Check(ctx, "alice@example.org", "10.0.0.1", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.Zero(t, wait, "the IP has its own policy")
+
+	if err := g.Succeed(ctx, "bob@example.org"); err != nil {
+		t.Fatalf("unable to record success: %v", err)
+	}
+
+	wait, err = g.Check(ctx, "bob@example.org", "10.0.0.1", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.Zero(t, wait)
+	assert.Equal(t, 3, repo["ip:10.0.0.1"].Failures(), "a success does not clear failures from the IP")
+}
+
+func TestGuardKeysUsernamesLikeCustomers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	g := lockout.NewGuard(memoryRepository{}, testPolicy(), testPolicy())
+
+	for i := 0; i < 3; i++ {
+		// The decomposed form of "josé" must share a key with the composed form.
+		if err := g.Fail(ctx, "Jose\u0301@example.org", "", now); err != nil {
+			t.Fatalf("unable to record failure: %v", err)
+		}
+	}
+
+	wait, err := g.Check(ctx, "jos\u00e9@EXAMPLE.org", "", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.Equal(t, time.Second, wait)
+}
@@ -0,0 +1,105 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/secret"
+)
+
+var ErrTokenUsed = errors.New("password reset token has already been used")
+
+// Repository represents our entity storage for a Token. Tokens are stored by the hash of their
+// secret, the secret itself is never persisted.
+type Repository interface {
+	// Add a new Token to the repository.
+	Add(ctx context.Context, t *Token) error
+
+	// Use marks the Token as used so that it can not be used again. ErrTokenUsed is returned if the
+	// Token has already been used or has expired, which happens when the same token is used twice at
+	// the same time.
+	Use(ctx context.Context, t *Token) error
+
+	// FindByHash searches the repository for a Token with the given hashed secret.
+	// If none is found then nil is returned.
+	FindByHash(ctx context.Context, hash string) (*Token, error)
+}
+
+// Token allows a customer to choose a new password without knowing their current one. Tokens are
+// emailed to the customer, expire quickly and can only be used once.
+type Token struct {
+	id         uuid.UUID
+	customerID uuid.UUID
+	hash       string
+	expiresAt  time.Time
+	usedAt     *time.Time
+}
+
+// New creates a Token for the customer. The returned string is the secret that should be sent to the
+// customer, it can not be retrieved again.
+func New(customerID uuid.UUID, ttl time.Duration) (*Token, string, error) {
+	s, err := secret.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create password reset token: %w", err)
+	}
+
+	return &Token{
+		id:         uuid.New(),
+		customerID: customerID,
+		hash:       secret.Hash(s),
+		expiresAt:  time.Now().Add(ttl),
+	}, s, nil
+}
+
+// State holds the persisted values of a Token so that a Repository can Restore it.
+type State struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Hash       string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+}
+
+// Restore creates a Token from existing state held in a Repository.
+func Restore(s State) *Token {
+	return &Token{
+		id:         s.ID,
+		customerID: s.CustomerID,
+		hash:       s.Hash,
+		expiresAt:  s.ExpiresAt,
+		usedAt:     s.UsedAt,
+	}
+}
+
+// ID uniquely identifies a Token within the application.
+func (t *Token) ID() uuid.UUID {
+	return t.id
+}
+
+// CustomerID is the id of the customer whose password the Token can reset.
+func (t *Token) CustomerID() uuid.UUID {
+	return t.customerID
+}
+
+// Hash is the hashed version of the tokens secret.
+func (t *Token) Hash() string {
+	return t.hash
+}
+
+// ExpiresAt is the time after which the Token can no longer be used.
+func (t *Token) ExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+// UsedAt is the time the Token was used to reset a password. It is nil if the Token has not been used.
+func (t *Token) UsedAt() *time.Time {
+	return t.usedAt
+}
+
+// IsUsable returns true if the Token has not been used or expired at the given time.
+func (t *Token) IsUsable(now time.Time) bool {
+	return t.usedAt == nil && now.Before(t.expiresAt)
+}
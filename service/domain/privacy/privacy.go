@@ -0,0 +1,203 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The types of Request that a customer can make about their personal data.
+const (
+	RequestExport  = "export"
+	RequestErasure = "erasure"
+)
+
+var ErrNotFound = errors.New("no personal data is held about the customer")
+
+// Source holds personal data about customers. Every repository that stores personal data registers itself
+// with the Service as a Source so that it is included in exports and erasures.
+type Source interface {
+	// ExportPersonalData returns everything held about the customer with the given id in a form that can be
+	// marshalled to JSON. If nothing is held then nil is returned.
+	ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error)
+
+	// ErasePersonalData irreversibly removes or anonymizes everything held about the customer with the given
+	// id. Data that must be kept, such as financial records, may be retained once it can no longer identify
+	// the customer. Erasing a customer that has already been erased does nothing.
+	ErasePersonalData(ctx context.Context, customerID uuid.UUID) error
+}
+
+// Repository records the requests that have been completed.
+type Repository interface {
+	// Add a completed Request to the repository.
+	Add(ctx context.Context, r *Request) error
+}
+
+// Request is the record of an export or erasure of the personal data held about a customer. It holds no
+// personal data so it is kept after the customer is erased to prove when the request was completed and
+// which sources it covered.
+type Request struct {
+	id          uuid.UUID
+	customerID  uuid.UUID
+	requestType string
+	requestedBy uuid.UUID
+	sources     []string
+	completedAt time.Time
+}
+
+// NewRequest creates a Request of the given type, completed now, for the customer with the given id.
+func NewRequest(customerID uuid.UUID, requestType string, requestedBy uuid.UUID, sources []string) *Request {
+	return &Request{
+		id:          uuid.New(),
+		customerID:  customerID,
+		requestType: requestType,
+		requestedBy: requestedBy,
+		sources:     append([]string(nil), sources...),
+		completedAt: time.Now(),
+	}
+}
+
+// RequestState is the persisted state of a Request, used to restore one from a datastore.
+type RequestState struct {
+	ID          uuid.UUID
+	CustomerID  uuid.UUID
+	Type        string
+	RequestedBy uuid.UUID
+	Sources     []string
+	CompletedAt time.Time
+}
+
+// RestoreRequest recreates a Request from its persisted state.
+func RestoreRequest(s RequestState) *Request {
+	return &Request{
+		id:          s.ID,
+		customerID:  s.CustomerID,
+		requestType: s.Type,
+		requestedBy: s.RequestedBy,
+		sources:     s.Sources,
+		completedAt: s.CompletedAt,
+	}
+}
+
+// ID of the Request.
+func (r *Request) ID() uuid.UUID {
+	return r.id
+}
+
+// CustomerID is the id of the customer whose personal data was exported or erased.
+func (r *Request) CustomerID() uuid.UUID {
+	return r.customerID
+}
+
+// Type is either RequestExport or RequestErasure.
+func (r *Request) Type() string {
+	return r.requestType
+}
+
+// RequestedBy is the id of the customer that made the Request, which may be an admin acting for the customer.
+func (r *Request) RequestedBy() uuid.UUID {
+	return r.requestedBy
+}
+
+// Sources are the names of the sources that were exported or erased.
+func (r *Request) Sources() []string {
+	return r.sources
+}
+
+// CompletedAt is the time that the Request was completed.
+func (r *Request) CompletedAt() time.Time {
+	return r.completedAt
+}
+
+// Archive is everything held about a customer, keyed by the name of the Source that holds it.
+type Archive struct {
+	CustomerID uuid.UUID              `json:"customer_id"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Service exports and erases the personal data held about customers by the registered sources.
+type Service struct {
+	repo    Repository
+	names   []string
+	sources map[string]Source
+}
+
+// NewService creates a Service without any sources that records requests in the Repository.
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, sources: make(map[string]Source)}
+}
+
+// Register adds the Source under the given name, which identifies its data in an Archive. Registering two
+// sources under the same name is a programming error so it panics.
+func (s *Service) Register(name string, src Source) {
+	if _, ok := s.sources[name]; ok {
+		panic(fmt.Sprintf("privacy: source %q is already registered", name))
+	}
+
+	s.names = append(s.names, name)
+	s.sources[name] = src
+}
+
+// Export collects the personal data held about the customer with the given id from every Source into an
+// Archive and records the Request. ErrNotFound is returned if no Source holds anything about the customer.
+func (s *Service) Export(ctx context.Context, customerID, requestedBy uuid.UUID) (*Archive, error) {
+	archive, err := s.collect(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Add(ctx, NewRequest(customerID, RequestExport, requestedBy, s.names)); err != nil {
+		return nil, fmt.Errorf("unable to record export: %w", err)
+	}
+
+	return archive, nil
+}
+
+// Erase irreversibly erases the personal data held about the customer with the given id from every Source and
+// records the Request as proof. Sources are erased in the reverse of the order they were registered so that
+// sources registered first, such as the customer itself, remain available to the others until last. Erasing
+// is safe to retry if a Source fails part way through. ErrNotFound is returned if no Source holds anything
+// about the customer.
+func (s *Service) Erase(ctx context.Context, customerID, requestedBy uuid.UUID) (*Request, error) {
+	if _, err := s.collect(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	for i := len(s.names) - 1; i >= 0; i-- {
+		if err := s.sources[s.names[i]].ErasePersonalData(ctx, customerID); err != nil {
+			return nil, fmt.Errorf("unable to erase %s: %w", s.names[i], err)
+		}
+	}
+
+	r := NewRequest(customerID, RequestErasure, requestedBy, s.names)
+	if err := s.repo.Add(ctx, r); err != nil {
+		return nil, fmt.Errorf("unable to record erasure: %w", err)
+	}
+
+	return r, nil
+}
+
+func (s *Service) collect(ctx context.Context, customerID uuid.UUID) (*Archive, error) {
+	archive := &Archive{CustomerID: customerID, ExportedAt: time.Now(), Data: make(map[string]interface{})}
+
+	for _, name := range s.names {
+		data, err := s.sources[name].ExportPersonalData(ctx, customerID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to export %s: %w", name, err)
+		}
+
+		if data != nil {
+			archive.Data[name] = data
+		}
+	}
+
+	if len(archive.Data) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,149 @@
+package privacy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySource holds a single value per customer and records the order sources are erased in.
+type memorySource struct {
+	name   string
+	data   map[uuid.UUID]string
+	erased *[]string
+	err    error
+}
+
+func (s *memorySource) ExportPersonalData(_ context.Context, customerID uuid.UUID) (interface{}, error) {
+	if v, ok := s.data[customerID]; ok {
+		return v, nil
+	}
+
+	return nil, nil
+}
+
+func (s *memorySource) ErasePersonalData(_ context.Context, customerID uuid.UUID) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	delete(s.data, customerID)
+	*s.erased = append(*s.erased, s.name)
+
+	return nil
+}
+
+type memoryRepository struct {
+	requests []*privacy.Request
+}
+
+func (r *memoryRepository) Add(_ context.Context, req *privacy.Request) error {
+	r.requests = append(r.requests, req)
+
+	return nil
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	customerID, adminID := uuid.New(), uuid.New()
+
+	newService := func(failing error) (*privacy.Service, *memoryRepository, *[]string) {
+		var erased []string
+
+		repo := &memoryRepository{}
+		s := privacy.NewService(repo)
+		s.Register("customer", &memorySource{
+			name:   "customer",
+			data:   map[uuid.UUID]string{customerID: "bob@example.org"},
+			erased: &erased,
+		})
+		s.Register("sessions", &memorySource{name: "sessions", data: map[uuid.UUID]string{}, erased: &erased})
+		s.Register("api_keys", &memorySource{
+			name:   "api_keys",
+			data:   map[uuid.UUID]string{customerID: "deploy key"},
+			erased: &erased,
+			err:    failing,
+		})
+
+		return s, repo, &erased
+	}
+
+	t.Run("export collects every source holding data", func(t *testing.T) {
+		t.Parallel()
+
+		s, repo, _ := newService(nil)
+
+		archive, err := s.Export(ctx, customerID, adminID)
+		if err != nil {
+			t.Fatalf("unable to export: %v", err)
+		}
+
+		assert.Equal(t, customerID, archive.CustomerID)
+		assert.Equal(t, map[string]interface{}{"customer": "bob@example.org", "api_keys": "deploy key"}, archive.Data)
+
+		if assert.Len(t, repo.requests, 1) {
+			assert.Equal(t, privacy.RequestExport, repo.requests[0].Type())
+			assert.Equal(t, adminID, repo.requests[0].RequestedBy())
+			assert.Equal(t, []string{"customer", "sessions", "api_keys"}, repo.requests[0].Sources())
+		}
+	})
+
+	t.Run("erase erases sources in reverse order and records the erasure", func(t *testing.T) {
+		t.Parallel()
+
+		s, repo, erased := newService(nil)
+
+		r, err := s.Erase(ctx, customerID, adminID)
+		if err != nil {
+			t.Fatalf("unable to erase: %v", err)
+		}
+
+		assert.Equal(t, []string{"api_keys", "sessions", "customer"}, *erased)
+		assert.Equal(t, privacy.RequestErasure, r.Type())
+		assert.Equal(t, customerID, r.CustomerID())
+		assert.Equal(t, []*privacy.Request{r}, repo.requests)
+
+		_, err = s.Export(ctx, customerID, adminID)
+		assert.True(t, errors.Is(err, privacy.ErrNotFound))
+	})
+
+	t.Run("a failing source stops the erasure before it is recorded", func(t *testing.T) {
+		t.Parallel()
+
+		s, repo, erased := newService(errors.New("unavailable"))
+
+		_, err := s.Erase(ctx, customerID, adminID)
+		assert.Error(t, err)
+		assert.Empty(t, *erased)
+		assert.Empty(t, repo.requests)
+	})
+
+	t.Run("unknown customers are not found", func(t *testing.T) {
+		t.Parallel()
+
+		s, repo, _ := newService(nil)
+
+		_, err := s.Export(ctx, uuid.New(), adminID)
+		assert.True(t, errors.Is(err, privacy.ErrNotFound))
+
+		_, err = s.Erase(ctx, uuid.New(), adminID)
+		assert.True(t, errors.Is(err, privacy.ErrNotFound))
+		assert.Empty(t, repo.requests)
+	})
+
+	t.Run("sources can only be registered once", func(t *testing.T) {
+		t.Parallel()
+
+		s, _, _ := newService(nil)
+
+		assert.Panics(t, func() {
+			s.Register("customer", &memorySource{})
+		})
+	})
+}
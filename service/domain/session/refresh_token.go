@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/secret"
+)
+
+var ErrTokenReused = errors.New("refresh token has already been used")
+
+// Repository represents our entity storage for a RefreshToken. Tokens are stored by the hash of
+// their secret, the secret itself is never persisted.
+type Repository interface {
+	// Add a new RefreshToken to the repository.
+	Add(ctx context.Context, t *RefreshToken) error
+
+	// Rotate marks the current RefreshToken as rotated and adds the next token in its family. Both
+	// happen atomically. ErrTokenReused is returned if the current token has already been rotated
+	// or revoked, which happens when the same token is used twice at the same time.
+	Rotate(ctx context.Context, current, next *RefreshToken) error
+
+	// RevokeFamily revokes every RefreshToken in the given family.
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+
+	// RevokeCustomer revokes every RefreshToken belonging to the given customer.
+	RevokeCustomer(ctx context.Context, customerID uuid.UUID) error
+
+	// FindByHash searches the repository for a RefreshToken with the given hashed secret.
+	// If none is found then nil is returned.
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+}
+
+// RefreshToken is a long lived token that can be exchanged for a new access token. Each token can only
+// be used once: using it rotates it for a new token in the same family. Families let us revoke every
+// token descended from a single login when a rotated token is reused, as that means it was stolen.
+type RefreshToken struct {
+	id         uuid.UUID
+	familyID   uuid.UUID
+	customerID uuid.UUID
+	hash       string
+	expiresAt  time.Time
+	rotatedAt  *time.Time
+	revokedAt  *time.Time
+}
+
+// New creates a RefreshToken for the customer that starts a new family. The returned string is the
+// secret that should be given to the customer, it can not be retrieved again.
+func New(customerID uuid.UUID, ttl time.Duration) (*RefreshToken, string, error) {
+	return newToken(uuid.New(), customerID, ttl)
+}
+
+// State holds the persisted values of a RefreshToken so that a Repository can Restore it.
+type State struct {
+	ID         uuid.UUID
+	FamilyID   uuid.UUID
+	CustomerID uuid.UUID
+	Hash       string
+	ExpiresAt  time.Time
+	RotatedAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+// Restore creates a RefreshToken from existing state held in a Repository.
+func Restore(s State) *RefreshToken {
+	return &RefreshToken{
+		id:         s.ID,
+		familyID:   s.FamilyID,
+		customerID: s.CustomerID,
+		hash:       s.Hash,
+		expiresAt:  s.ExpiresAt,
+		rotatedAt:  s.RotatedAt,
+		revokedAt:  s.RevokedAt,
+	}
+}
+
+// ID uniquely identifies a RefreshToken within the application.
+func (t *RefreshToken) ID() uuid.UUID {
+	return t.id
+}
+
+// FamilyID identifies the login that the RefreshToken descends from.
+func (t *RefreshToken) FamilyID() uuid.UUID {
+	return t.familyID
+}
+
+// CustomerID is the id of the customer that the RefreshToken was issued to.
+func (t *RefreshToken) CustomerID() uuid.UUID {
+	return t.customerID
+}
+
+// Hash is the hashed version of the tokens secret.
+func (t *RefreshToken) Hash() string {
+	return t.hash
+}
+
+// ExpiresAt is the time after which the RefreshToken can no longer be used.
+func (t *RefreshToken) ExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+// RotatedAt is the time the RefreshToken was exchanged for the next token in its family. It is nil
+// if the token has not been used.
+func (t *RefreshToken) RotatedAt() *time.Time {
+	return t.rotatedAt
+}
+
+// RevokedAt is the time the RefreshToken was revoked. It is nil if the token has not been revoked.
+func (t *RefreshToken) RevokedAt() *time.Time {
+	return t.revokedAt
+}
+
+// IsRotated returns true if the RefreshToken has already been exchanged. Presenting a rotated token
+// again means that it has been reused.
+func (t *RefreshToken) IsRotated() bool {
+	return t.rotatedAt != nil
+}
+
+// IsUsable returns true if the RefreshToken has not been rotated, revoked or expired at the given time.
+func (t *RefreshToken) IsUsable(now time.Time) bool {
+	return t.rotatedAt == nil && t.revokedAt == nil && now.Before(t.expiresAt)
+}
+
+// Next creates the RefreshToken that replaces this one within the same family. The returned string
+// is the secret that should be given to the customer.
+func (t *RefreshToken) Next(ttl time.Duration) (*RefreshToken, string, error) {
+	return newToken(t.familyID, t.customerID, ttl)
+}
+
+func newToken(familyID, customerID uuid.UUID, ttl time.Duration) (*RefreshToken, string, error) {
+	s, err := secret.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create refresh token: %w", err)
+	}
+
+	return &RefreshToken{
+		id:         uuid.New(),
+		familyID:   familyID,
+		customerID: customerID,
+		hash:       secret.Hash(s),
+		expiresAt:  time.Now().Add(ttl),
+	}, s, nil
+}
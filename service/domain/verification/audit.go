@@ -0,0 +1,33 @@
+package verification
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ChangeRecorder records the changes made to a customer without going through their repository, see
+// customer.AuditingRepository.
+type ChangeRecorder interface {
+	RecordUpdate(ctx context.Context, customerID uuid.UUID, save func(ctx context.Context) error) error
+}
+
+// AuditingRepository wraps a Repository so that verifying a customer is recorded in the audit log in the same
+// transaction that verifies them.
+type AuditingRepository struct {
+	Repository
+	recorder ChangeRecorder
+}
+
+// NewAuditingRepository creates an AuditingRepository that verifies with the Repository and records the change
+// with the ChangeRecorder.
+func NewAuditingRepository(repo Repository, r ChangeRecorder) *AuditingRepository {
+	return &AuditingRepository{Repository: repo, recorder: r}
+}
+
+// Verify marks the Token as used, its customer as verified and records the change to the customer.
+func (r *AuditingRepository) Verify(ctx context.Context, t *Token) error {
+	return r.recorder.RecordUpdate(ctx, t.CustomerID(), func(ctx context.Context) error {
+		return r.Repository.Verify(ctx, t)
+	})
+}
@@ -0,0 +1,84 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app/mail"
+	"github.com/nickbryan/go-template/service/domain/customer"
+)
+
+var ErrResendTooSoon = errors.New("a verification email has been sent recently")
+
+// Sender issues verification tokens and emails them to customers.
+type Sender struct {
+	repo           Repository
+	mailer         mail.Mailer
+	link           string
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// NewSender creates a Sender. The secret of each token is added to link as the token query parameter
+// so that customers can verify by following it. Tokens expire after ttl and a new one can only be
+// resent once resendInterval has passed since the last.
+func NewSender(repo Repository, mailer mail.Mailer, link string, ttl, resendInterval time.Duration) *Sender {
+	return &Sender{
+		repo:           repo,
+		mailer:         mailer,
+		link:           link,
+		ttl:            ttl,
+		resendInterval: resendInterval,
+	}
+}
+
+// Send issues a new Token for the Customer and emails it to their username.
+func (s *Sender) Send(ctx context.Context, c *customer.Customer) error {
+	link, err := url.Parse(s.link)
+	if err != nil {
+		return fmt.Errorf("unable to parse verification link: %w", err)
+	}
+
+	token, secret, err := New(c.ID(), c.Username(), s.ttl)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Add(ctx, token); err != nil {
+		return err
+	}
+
+	query := link.Query()
+	query.Set("token", secret)
+	link.RawQuery = query.Encode()
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      c.Username(),
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Please verify your email address by following the link below:\n\n%s\n\nThe link expires in %s.",
+			link,
+			s.ttl,
+		),
+	})
+}
+
+// Resend behaves like Send but refuses to send another Token within the resend interval of the last.
+// ErrResendTooSoon is returned along with how long the customer has to wait when they are throttled.
+func (s *Sender) Resend(ctx context.Context, c *customer.Customer) (time.Duration, error) {
+	latest, err := s.repo.FindLatest(ctx, c.ID())
+	if err != nil {
+		return 0, err
+	}
+
+	if latest != nil {
+		if wait := s.resendInterval - time.Since(latest.IssuedAt()); wait > 0 {
+			return wait, ErrResendTooSoon
+		}
+	}
+
+	return 0, s.Send(ctx, c)
+}
@@ -0,0 +1,131 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/customer"
+)
+
+var ErrTokenUsed = errors.New("verification token has already been used")
+
+// Repository represents our entity storage for a Token. Tokens are stored by the hash of their
+// secret, the secret itself is never persisted.
+type Repository interface {
+	// Add a new Token to the repository.
+	Add(ctx context.Context, t *Token) error
+
+	// Verify marks the Token as used and its customer as verified. Both happen atomically.
+	// ErrTokenUsed is returned if the Token has already been used or has expired and
+	// customer.ErrNotFound if its customer no longer exists or no longer has the username it was sent to.
+	Verify(ctx context.Context, t *Token) error
+
+	// FindByHash searches the repository for a Token with the given hashed secret.
+	// If none is found then nil is returned.
+	FindByHash(ctx context.Context, hash string) (*Token, error)
+
+	// FindLatest searches the repository for the Token most recently issued to the given customer.
+	// If none is found then nil is returned.
+	FindLatest(ctx context.Context, customerID uuid.UUID) (*Token, error)
+}
+
+// Token proves that a customer owns the email address they registered with. Tokens are emailed to
+// the customer when they are created and can only be used once. A Token only proves ownership of the
+// username it was sent to so it can not verify the customer once they have changed it.
+type Token struct {
+	id          uuid.UUID
+	customerID  uuid.UUID
+	usernameKey string
+	hash        string
+	issuedAt    time.Time
+	expiresAt   time.Time
+	usedAt      *time.Time
+}
+
+// New creates a Token for the customer that is sent to the given username. The returned string is the
+// secret that should be sent to the customer, it can not be retrieved again.
+func New(customerID uuid.UUID, username string, ttl time.Duration) (*Token, string, error) {
+	s, err := secret.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create verification token: %w", err)
+	}
+
+	now := time.Now()
+
+	return &Token{
+		id:          uuid.New(),
+		customerID:  customerID,
+		usernameKey: customer.UsernameKey(username),
+		hash:        secret.Hash(s),
+		issuedAt:    now,
+		expiresAt:   now.Add(ttl),
+	}, s, nil
+}
+
+// State holds the persisted values of a Token so that a Repository can Restore it.
+type State struct {
+	ID          uuid.UUID
+	CustomerID  uuid.UUID
+	UsernameKey string
+	Hash        string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+}
+
+// Restore creates a Token from existing state held in a Repository.
+func Restore(s State) *Token {
+	return &Token{
+		id:          s.ID,
+		customerID:  s.CustomerID,
+		usernameKey: s.UsernameKey,
+		hash:        s.Hash,
+		issuedAt:    s.IssuedAt,
+		expiresAt:   s.ExpiresAt,
+		usedAt:      s.UsedAt,
+	}
+}
+
+// ID uniquely identifies a Token within the application.
+func (t *Token) ID() uuid.UUID {
+	return t.id
+}
+
+// CustomerID is the id of the customer that the Token verifies.
+func (t *Token) CustomerID() uuid.UUID {
+	return t.customerID
+}
+
+// UsernameKey is the customer.UsernameKey of the username that the Token was sent to.
+func (t *Token) UsernameKey() string {
+	return t.usernameKey
+}
+
+// Hash is the hashed version of the tokens secret.
+func (t *Token) Hash() string {
+	return t.hash
+}
+
+// IssuedAt is the time the Token was created.
+func (t *Token) IssuedAt() time.Time {
+	return t.issuedAt
+}
+
+// ExpiresAt is the time after which the Token can no longer be used.
+func (t *Token) ExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+// UsedAt is the time the Token was used. It is nil if the Token has not been used.
+func (t *Token) UsedAt() *time.Time {
+	return t.usedAt
+}
+
+// IsUsable returns true if the Token has not been used or expired at the given time.
+func (t *Token) IsUsable(now time.Time) bool {
+	return t.usedAt == nil && now.Before(t.expiresAt)
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+)
+
+// APIKeyRepository gives us our datastore interaction for an apikey.Key.
+type APIKeyRepository struct {
+	db *app.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository with an encapsulated database connection.
+func NewAPIKeyRepository(db *app.DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+// Add a new apikey.Key to the repository.
+func (ar *APIKeyRepository) Add(ctx context.Context, k *apikey.Key) error {
+	// The scopes column can not be null.
+	scopes := k.Scopes()
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	query := ar.db.QB().
+		Insert("api_keys").
+		Columns("uuid", "customer_uuid", "name", "hint", "key_hash", "scopes", "expires_at", "created_at").
+		Values(k.ID(), k.CustomerID(), k.Name(), k.Hint(), k.Hash(), scopes, k.ExpiresAt(), k.CreatedAt())
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert api_keys create query to SQL: %w", err)
+	}
+
+	if _, err = ar.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create api_keys: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke revokes the apikey.Key with the given id if it belongs to the customer. apikey.ErrNotFound is
+// returned if the customer has no such key or it has already been revoked.
+func (ar *APIKeyRepository) Revoke(ctx context.Context, customerID, id uuid.UUID) error {
+	query := ar.db.QB().
+		Update("api_keys").
+		Set("revoked_at", time.Now()).
+		Where(qb.Eq{"uuid": id, "customer_uuid": customerID, "revoked_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert api_keys revoke query to SQL: %w", err)
+	}
+
+	tag, err := ar.db.Conn().Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("unable to revoke api_keys: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apikey.ErrNotFound
+	}
+
+	return nil
+}
+
+// Touch records that the apikey.Key with the given id was used at the given time.
+func (ar *APIKeyRepository) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := ar.db.QB().
+		Update("api_keys").
+		Set("last_used_at", at).
+		Where(qb.Eq{"uuid": id})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert api_keys touch query to SQL: %w", err)
+	}
+
+	if _, err = ar.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to touch api_keys: %w", err)
+	}
+
+	return nil
+}
+
+// FindByHash searches the repository for an apikey.Key with the given hashed key.
+// If none is found then nil is returned.
+func (ar *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*apikey.Key, error) {
+	var rec apiKeyRecord
+
+	if err := ar.db.Get(ctx, &rec, ar.selectKeys().Where(qb.Eq{"key_hash": hash})); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch api_keys: %w", err)
+	}
+
+	return rec.toKey(), nil
+}
+
+// ListByCustomer returns the apikey.Key list of the customer without revoked keys, newest first.
+func (ar *APIKeyRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*apikey.Key, error) {
+	var recs []apiKeyRecord
+
+	query := ar.selectKeys().
+		Where(qb.Eq{"customer_uuid": customerID, "revoked_at": nil}).
+		OrderBy("created_at DESC", "uuid DESC")
+
+	if err := ar.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to list api_keys: %w", err)
+	}
+
+	keys := make([]*apikey.Key, 0, len(recs))
+	for _, rec := range recs {
+		keys = append(keys, rec.toKey())
+	}
+
+	return keys, nil
+}
+
+func (ar *APIKeyRepository) selectKeys() qb.SelectBuilder {
+	return ar.db.QB().
+		Select(
+			"uuid as id",
+			"customer_uuid as customer_id",
+			"name",
+			"hint",
+			"key_hash",
+			"scopes",
+			"expires_at",
+			"last_used_at",
+			"revoked_at",
+			"created_at",
+		).
+		From("api_keys")
+}
+
+// apiKeyExport is the personal data held about an apikey.Key. The key hash is left out as it is a credential
+// rather than information about the customer.
+type apiKeyExport struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ExportPersonalData returns the api keys created by the customer with the given id, including revoked keys.
+// If none are found then nil is returned.
+func (ar *APIKeyRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var recs []apiKeyExport
+
+	columns := []string{"uuid AS id", "name", "hint", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}
+	if err := exportRows(ctx, ar.db, &recs, "api_keys", columns, customerID); err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	return recs, nil
+}
+
+// ErasePersonalData deletes the api keys created by the customer with the given id.
+func (ar *APIKeyRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	return eraseRows(ctx, ar.db, "api_keys", customerID)
+}
+
+// apiKeyRecord is the database representation of an apikey.Key.
+type apiKeyRecord struct {
+	ID         string
+	CustomerID string
+	Name       string
+	Hint       string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (rec apiKeyRecord) toKey() *apikey.Key {
+	return apikey.Restore(apikey.State{
+		ID:         uuid.MustParse(rec.ID),
+		CustomerID: uuid.MustParse(rec.CustomerID),
+		Name:       rec.Name,
+		Hint:       rec.Hint,
+		Hash:       rec.KeyHash,
+		Scopes:     rec.Scopes,
+		ExpiresAt:  rec.ExpiresAt,
+		LastUsedAt: rec.LastUsedAt,
+		RevokedAt:  rec.RevokedAt,
+		CreatedAt:  rec.CreatedAt,
+	})
+}
@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+)
+
+// auditCursorSort identifies cursors handed out by AuditRepository.List.
+const auditCursorSort = "audit"
+
+// AuditRepository gives us our datastore interaction for an audit.Entry.
+type AuditRepository struct {
+	db *app.DB
+}
+
+// NewAuditRepository creates a new AuditRepository with an encapsulated database connection.
+func NewAuditRepository(db *app.DB) *AuditRepository {
+	return &AuditRepository{db}
+}
+
+// Add a new audit.Entry to the repository. The database rejects any change to an entry once it is added.
+func (ar *AuditRepository) Add(ctx context.Context, e *audit.Entry) error {
+	changes, err := json.Marshal(e.Changes())
+	if err != nil {
+		return fmt.Errorf("unable to marshal audit_entries changes: %w", err)
+	}
+
+	query := ar.db.QB().
+		Insert("audit_entries").
+		Columns("uuid", "actor_uuid", "action", "resource_type", "resource_id", "changes", "request_id", "occurred_at").
+		Values(
+			e.ID(),
+			e.ActorID(),
+			e.Action(),
+			e.ResourceType(),
+			e.ResourceID(),
+			string(changes),
+			e.RequestID(),
+			e.OccurredAt(),
+		)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert audit_entries create query to SQL: %w", err)
+	}
+
+	if _, err = ar.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create audit_entries: %w", err)
+	}
+
+	return nil
+}
+
+// List returns an audit.Page of the entries matching the audit.Filter, newest first. Pages are fetched using
+// keyset pagination on the entry id.
+func (ar *AuditRepository) List(ctx context.Context, f audit.Filter) (*audit.Page, error) {
+	if f.Limit <= 0 {
+		f.Limit = audit.DefaultListLimit
+	}
+
+	if f.Limit > audit.MaxListLimit {
+		f.Limit = audit.MaxListLimit
+	}
+
+	query := ar.db.QB().
+		Select(
+			"id",
+			"uuid AS entry_id",
+			"actor_uuid AS actor_id",
+			"action",
+			"resource_type",
+			"resource_id",
+			"changes",
+			"request_id",
+			"occurred_at",
+		).
+		From("audit_entries")
+
+	if f.ActorID != nil {
+		query = query.Where(qb.Eq{"actor_uuid": *f.ActorID})
+	}
+
+	if f.ResourceType != "" {
+		query = query.Where(qb.Eq{"resource_type": f.ResourceType})
+	}
+
+	if f.ResourceID != "" {
+		query = query.Where(qb.Eq{"resource_id": f.ResourceID})
+	}
+
+	if f.From != nil {
+		query = query.Where(qb.GtOrEq{"occurred_at": *f.From})
+	}
+
+	if f.To != nil {
+		query = query.Where(qb.Lt{"occurred_at": *f.To})
+	}
+
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil || c.Sort != auditCursorSort {
+			return nil, audit.ErrInvalidCursor
+		}
+
+		id, err := strconv.ParseInt(c.ID, 10, 64)
+		if err != nil {
+			return nil, audit.ErrInvalidCursor
+		}
+
+		query = query.Where(qb.Lt{"id": id})
+	}
+
+	// We fetch one more record than we need so that we know if there is another page.
+	query = query.OrderBy("id DESC").Limit(uint64(f.Limit + 1))
+
+	var recs []auditEntryRecord
+	if err := ar.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to list audit_entries: %w", err)
+	}
+
+	page := &audit.Page{Entries: make([]*audit.Entry, 0, len(recs))}
+
+	if len(recs) > f.Limit {
+		recs = recs[:f.Limit]
+		page.NextCursor = cursor{Sort: auditCursorSort, ID: strconv.FormatInt(recs[len(recs)-1].ID, 10)}.encode()
+	}
+
+	for _, rec := range recs {
+		e, err := rec.toEntry()
+		if err != nil {
+			return nil, err
+		}
+
+		page.Entries = append(page.Entries, e)
+	}
+
+	return page, nil
+}
+
+// auditEntryExport is an audit.Entry about, or performed by, a customer.
+type auditEntryExport struct {
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Changes      json.RawMessage `json:"changes"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+}
+
+// ExportPersonalData returns the entries about the customer with the given id and the entries for actions they
+// performed, oldest first. If none are found then nil is returned.
+func (ar *AuditRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var recs []auditEntryExport
+
+	query := ar.db.QB().
+		Select("action", "resource_type", "resource_id", "changes", "occurred_at").
+		From("audit_entries").
+		Where(qb.Or{aboutCustomer(customerID), qb.Eq{"actor_uuid": customerID}}).
+		OrderBy("id")
+
+	if err := ar.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to export audit_entries: %w", err)
+	}
+
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	return recs, nil
+}
+
+// ErasePersonalData redacts the values changed by the entries about the customer with the given id. The entries
+// themselves are kept, along with the names of the fields that changed, as the record of what happened.
+func (ar *AuditRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	redacted := qb.Expr(
+		"COALESCE((SELECT jsonb_object_agg(key, jsonb_build_object('from', ?::text, 'to', ?::text)) "+
+			"FROM jsonb_object_keys(changes) AS key), '{}'::jsonb)",
+		audit.Redacted,
+		audit.Redacted,
+	)
+
+	sql, args, err := ar.db.QB().
+		Update("audit_entries").
+		Set("changes", redacted).
+		Set("redacted_at", time.Now()).
+		Where(aboutCustomer(customerID)).
+		Where(qb.Eq{"redacted_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert audit_entries erase query to SQL: %w", err)
+	}
+
+	if _, err = ar.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to erase audit_entries: %w", err)
+	}
+
+	return nil
+}
+
+func aboutCustomer(customerID uuid.UUID) qb.Eq {
+	return qb.Eq{"resource_type": audit.ResourceCustomer, "resource_id": customerID.String()}
+}
+
+// auditEntryRecord is the database representation of an audit.Entry.
+type auditEntryRecord struct {
+	ID           int64
+	EntryID      string
+	ActorID      *string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Changes      []byte
+	RequestID    string
+	OccurredAt   time.Time
+}
+
+func (rec auditEntryRecord) toEntry() (*audit.Entry, error) {
+	var changes map[string]audit.Change
+	if err := json.Unmarshal(rec.Changes, &changes); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal audit_entries changes: %w", err)
+	}
+
+	var actorID *uuid.UUID
+
+	if rec.ActorID != nil {
+		id := uuid.MustParse(*rec.ActorID)
+		actorID = &id
+	}
+
+	return audit.Restore(audit.State{
+		ID:           uuid.MustParse(rec.EntryID),
+		ActorID:      actorID,
+		Action:       rec.Action,
+		ResourceType: rec.ResourceType,
+		ResourceID:   rec.ResourceID,
+		Changes:      changes,
+		RequestID:    rec.RequestID,
+		OccurredAt:   rec.OccurredAt,
+	}), nil
+}
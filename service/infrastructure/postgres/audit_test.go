@@ -0,0 +1,155 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	db := testEnv.DB()
+	ctx := context.Background()
+	repo := postgres.NewAuditRepository(db)
+
+	adminID, customerID := uuid.New(), uuid.New()
+
+	entries := []*audit.Entry{
+		audit.NewEntry(nil, audit.ActionCreate, audit.ResourceCustomer, customerID.String(), map[string]audit.Change{
+			"username": {To: "bob@example.org"},
+		}, "req-1"),
+		audit.NewEntry(&customerID, audit.ActionLogin, audit.ResourceSession, uuid.New().String(), nil, "req-2"),
+		audit.NewEntry(&adminID, audit.ActionUpdate, audit.ResourceCustomer, customerID.String(), map[string]audit.Change{
+			"username": {From: "bob@example.org", To: "alice@example.org"},
+		}, "req-3"),
+	}
+
+	for _, e := range entries {
+		if err := repo.Add(ctx, e); err != nil {
+			t.Fatalf("unable to add audit entry: %v", err)
+		}
+	}
+
+	t.Run("lists the newest entries first a page at a time", func(t *testing.T) {
+		t.Parallel()
+
+		page, err := repo.List(ctx, audit.Filter{Limit: 2})
+		if err != nil {
+			t.Fatalf("unable to list audit entries: %v", err)
+		}
+
+		if assert.Len(t, page.Entries, 2) {
+			assert.Equal(t, entries[2].ID(), page.Entries[0].ID())
+			assert.Equal(t, &adminID, page.Entries[0].ActorID())
+			assert.Equal(t, "req-3", page.Entries[0].RequestID())
+			assert.Equal(t, entries[2].Changes(), page.Entries[0].Changes())
+			assert.Equal(t, entries[1].ID(), page.Entries[1].ID())
+		}
+
+		page, err = repo.List(ctx, audit.Filter{Limit: 2, Cursor: page.NextCursor})
+		if err != nil {
+			t.Fatalf("unable to list audit entries: %v", err)
+		}
+
+		if assert.Len(t, page.Entries, 1) {
+			assert.Equal(t, entries[0].ID(), page.Entries[0].ID())
+			assert.Nil(t, page.Entries[0].ActorID())
+		}
+
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("filters by actor, resource and time", func(t *testing.T) {
+		t.Parallel()
+
+		page, err := repo.List(ctx, audit.Filter{ActorID: &adminID})
+		if err != nil {
+			t.Fatalf("unable to list audit entries: %v", err)
+		}
+
+		assert.Len(t, page.Entries, 1)
+
+		page, err = repo.List(ctx, audit.Filter{ResourceType: audit.ResourceCustomer, ResourceID: customerID.String()})
+		if err != nil {
+			t.Fatalf("unable to list audit entries: %v", err)
+		}
+
+		assert.Len(t, page.Entries, 2)
+
+		future := time.Now().Add(time.Hour)
+
+		page, err = repo.List(ctx, audit.Filter{From: &future})
+		if err != nil {
+			t.Fatalf("unable to list audit entries: %v", err)
+		}
+
+		assert.Empty(t, page.Entries)
+	})
+
+	t.Run("rejects unknown cursors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := repo.List(ctx, audit.Filter{Cursor: "not-a-cursor"})
+		assert.Equal(t, audit.ErrInvalidCursor, err)
+	})
+}
+
+func TestAuditRepositoryIsAppendOnly(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	db := testEnv.DB()
+	ctx := context.Background()
+	repo := postgres.NewAuditRepository(db)
+	customerID := uuid.New()
+
+	e := audit.NewEntry(nil, audit.ActionCreate, audit.ResourceCustomer, customerID.String(), map[string]audit.Change{
+		"username": {To: "bob@example.org"},
+	}, "")
+	if err := repo.Add(ctx, e); err != nil {
+		t.Fatalf("unable to add audit entry: %v", err)
+	}
+
+	_, err := db.Conn().Exec(ctx, "UPDATE audit_entries SET action = 'delete'")
+	assert.Error(t, err, "entries can not be changed")
+
+	_, err = db.Conn().Exec(ctx, "DELETE FROM audit_entries")
+	assert.Error(t, err, "entries can not be removed")
+
+	if err := repo.ErasePersonalData(ctx, customerID); err != nil {
+		t.Fatalf("unable to erase personal data: %v", err)
+	}
+
+	export, err := repo.ExportPersonalData(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to export personal data: %v", err)
+	}
+
+	exported, err := json.Marshal(export)
+	if err != nil {
+		t.Fatalf("unable to marshal export: %v", err)
+	}
+
+	assert.NotContains(t, string(exported), "bob@example.org")
+
+	page, err := repo.List(ctx, audit.Filter{})
+	if err != nil {
+		t.Fatalf("unable to list audit entries: %v", err)
+	}
+
+	if assert.Len(t, page.Entries, 1) {
+		assert.Equal(t, audit.ActionCreate, page.Entries[0].Action(), "the entry is kept")
+		assert.Equal(t, map[string]audit.Change{
+			"username": {From: audit.Redacted, To: audit.Redacted},
+		}, page.Entries[0].Changes())
+	}
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// cursor is the decoded form of the opaque keyset cursors that we hand out to clients. It holds
+// the sort key and tie breaking id of the row at the edge of a page so that the next query can
+// continue on from it without the need for an OFFSET.
+type cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       string `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	// Marshalling a struct of strings and bools can not fail.
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("unable to decode cursor: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("unable to unmarshal cursor: %w", err)
+	}
+
+	return c, nil
+}
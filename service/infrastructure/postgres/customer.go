@@ -4,15 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	qb "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/event"
 	"github.com/nickbryan/go-template/service/domain/customer"
 )
 
+// usernameIndex is the unique index that stops active customers from sharing a username. It is built on the
+// username_key column, which is generated from the username so that usernames are compared case insensitively.
+const usernameIndex = "customers_username_key_idx"
+
+// customerAggregate identifies the messages written to the outbox for a domain.Customer.
+const customerAggregate = "customer"
+
 // CustomerRepository gives us our datastore interaction for a domain.Customer.
 type CustomerRepository struct {
 	db *app.DB
@@ -23,41 +34,613 @@ func NewCustomerRepository(db *app.DB) *CustomerRepository {
 	return &CustomerRepository{db}
 }
 
-// Add a new domain.Customer to the repository.
+// Add a new domain.Customer to the repository. The events raised by the customer are written to the outbox in
+// the same transaction. customer.ErrUsernameTaken is returned if an active customer already has the username.
 func (cr *CustomerRepository) Add(ctx context.Context, c *customer.Customer) error {
 	query := cr.db.QB().
 		Insert("customers").
 		Columns("uuid", "username", "password", "created_at", "updated_at").
-		Values(c.ID(), c.Username(), c.PasswordHash(), time.Now(), time.Now())
+		Values(c.ID(), c.Username(), c.PasswordHash(), c.CreatedAt(), c.CreatedAt())
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return fmt.Errorf("unable to convert customers create query to SQL: %w", err)
 	}
 
-	_, err = cr.db.Conn().Exec(ctx, sql, args...)
+	return cr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			if isUniqueViolation(err, usernameIndex) {
+				return customer.ErrUsernameTaken
+			}
+
+			return fmt.Errorf("unable to create customers: %w", err)
+		}
+
+		return writeOutbox(ctx, tx, cr.db, customerAggregate, c.ID(), c.Events())
+	})
+}
+
+// Update persists the changes made to an existing domain.Customer and returns it at its new version. The
+// events raised by the customer are written to the outbox in the same transaction.
+// The update only applies if the stored version matches the version the Customer was retrieved at,
+// otherwise customer.ErrVersionConflict is returned. customer.ErrUsernameTaken is returned if another active
+// customer already has the username.
+func (cr *CustomerRepository) Update(ctx context.Context, c *customer.Customer) (*customer.Customer, error) {
+	query := cr.db.QB().
+		Update("customers AS c").
+		Set("username", c.Username()).
+		Set("password", c.PasswordHash()).
+		Set("verified_at", c.VerifiedAt()).
+		Set("updated_at", time.Now()).
+		Set("version", qb.Expr("c.version + 1")).
+		Where(qb.Eq{"c.uuid": c.ID(), "c.version": c.Version(), "c.deleted_at": nil}).
+		Suffix("RETURNING " + strings.Join(customerColumns, ", "))
+
+	sql, args, err := query.ToSql()
 	if err != nil {
-		return fmt.Errorf("unable to create customers: %w", err)
+		return nil, fmt.Errorf("unable to convert customers update query to SQL: %w", err)
+	}
+
+	var rec customerRecord
+
+	err = cr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if err := pgxscan.Get(ctx, tx, &rec, sql, args...); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return customer.ErrVersionConflict
+			}
+
+			if isUniqueViolation(err, usernameIndex) {
+				return customer.ErrUsernameTaken
+			}
+
+			return fmt.Errorf("unable to update customers: %w", err)
+		}
+
+		return writeOutbox(ctx, tx, cr.db, customerAggregate, c.ID(), c.Events())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.toCustomer(), nil
+}
+
+// UpdatePasswordHash persists the rehashed password of a domain.Customer. The stored hash is compared against
+// previousHash rather than the version so that a rehash never conflicts with, or overwrites, other changes.
+func (cr *CustomerRepository) UpdatePasswordHash(ctx context.Context, c *customer.Customer, previousHash string) error {
+	query := cr.db.QB().
+		Update("customers").
+		Set("password", c.PasswordHash()).
+		Where(qb.Eq{"uuid": c.ID(), "password": previousHash, "deleted_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customers rehash query to SQL: %w", err)
+	}
+
+	if _, err = cr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to rehash customers: %w", err)
 	}
 
 	return nil
 }
 
-// FindByUsername searches the repository for a Customer with the given username.
+// Delete soft deletes the domain.Customer with the given id by setting its deleted_at column.
+// customer.ErrNotFound is returned if there is no customer with the given id.
+func (cr *CustomerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+
+	query := cr.db.QB().
+		Update("customers").
+		Set("deleted_at", now).
+		Set("updated_at", now).
+		Set("version", qb.Expr("version + 1")).
+		Where(qb.Eq{"uuid": id, "deleted_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customers delete query to SQL: %w", err)
+	}
+
+	tag, err := cr.db.Conn().Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("unable to delete customers: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customer.ErrNotFound
+	}
+
+	return nil
+}
+
+// Undelete restores a soft deleted domain.Customer and returns it. The customer is only restored if
+// no other customer has taken its username in the meantime, in which case customer.ErrUsernameTaken
+// is returned. customer.ErrNotFound is returned if there is no deleted customer with the given id.
+// Customers that have been erased can never be restored.
+func (cr *CustomerRepository) Undelete(ctx context.Context, id uuid.UUID) (*customer.Customer, error) {
+	usernameTaken := cr.db.QB().
+		Select("1").
+		From("customers AS o").
+		Where("o.username_key = c.username_key").
+		Where(qb.Eq{"o.deleted_at": nil}).
+		Prefix("NOT EXISTS (").
+		Suffix(")")
+
+	query := cr.db.QB().
+		Update("customers AS c").
+		Set("deleted_at", nil).
+		Set("updated_at", time.Now()).
+		Set("version", qb.Expr("c.version + 1")).
+		Where(qb.Eq{"c.uuid": id, "c.erased_at": nil}).
+		Where(qb.NotEq{"c.deleted_at": nil}).
+		Where(usernameTaken).
+		Suffix("RETURNING " + strings.Join(customerColumns, ", "))
+
+	var rec customerRecord
+
+	err := cr.db.Get(ctx, &rec, query)
+	if err == nil {
+		return rec.toCustomer(), nil
+	}
+
+	// The username can still be taken between the check and the update by a concurrent request.
+	if isUniqueViolation(err, usernameIndex) {
+		return nil, customer.ErrUsernameTaken
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("unable to undelete customers: %w", err)
+	}
+
+	// Nothing was restored so we check if that was because the customer does not exist.
+	deleted, err := cr.findOne(ctx, qb.Eq{"c.uuid": id, "c.erased_at": nil}, qb.NotEq{"c.deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted == nil {
+		return nil, customer.ErrNotFound
+	}
+
+	return nil, customer.ErrUsernameTaken
+}
+
+// Purge permanently removes all customers that were soft deleted before the given time. Erased customers
+// are never removed.
+func (cr *CustomerRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := cr.db.QB().
+		Delete("customers").
+		Where(qb.Lt{"deleted_at": deletedBefore}).
+		Where(qb.Eq{"erased_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("unable to convert customers purge query to SQL: %w", err)
+	}
+
+	tag, err := cr.db.Conn().Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to purge customers: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// FindByID searches the repository for a Customer with the given id.
+// If none is found then nil is returned.
+func (cr *CustomerRepository) FindByID(ctx context.Context, id uuid.UUID) (*customer.Customer, error) {
+	return cr.findOne(ctx, qb.Eq{"c.uuid": id, "c.deleted_at": nil})
+}
+
+// FindByUsername searches the repository for a Customer with the given username, ignoring case.
 // If none is found then nil is returned.
 func (cr *CustomerRepository) FindByUsername(ctx context.Context, username string) (*customer.Customer, error) {
+	return cr.findOne(
+		ctx,
+		qb.Expr("c.username_key = lower(?)", customer.NormalizeUsername(username)),
+		qb.Eq{"c.deleted_at": nil},
+	)
+}
+
+// List returns a Page of customers matching the given ListOptions. Pages are fetched using keyset
+// pagination on the sort column with the uuid as a tie breaker so that the cost of fetching a page
+// does not grow with its position in the table.
+func (cr *CustomerRepository) List(ctx context.Context, opts customer.ListOptions) (*customer.Page, error) {
+	if opts.Sort == "" {
+		opts.Sort = customer.SortCreatedAt
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = customer.DefaultListLimit
+	}
+
+	if opts.Limit > customer.MaxListLimit {
+		opts.Limit = customer.MaxListLimit
+	}
+
+	column, desc, ok := customerSortColumn(opts.Sort)
+	if !ok {
+		return nil, fmt.Errorf("unable to list customers sorted by %q", opts.Sort)
+	}
+
+	query := cr.selectCustomers()
+
+	if !opts.IncludeDeleted {
+		query = query.Where(qb.Eq{"c.deleted_at": nil})
+	}
+
+	if opts.CreatedAfter != nil {
+		query = query.Where(qb.GtOrEq{"c.created_at": opts.CreatedAfter.UTC()})
+	}
+
+	if opts.CreatedBefore != nil {
+		query = query.Where(qb.Lt{"c.created_at": opts.CreatedBefore.UTC()})
+	}
+
+	var from cursor
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil || c.Sort != opts.Sort {
+			return nil, customer.ErrInvalidCursor
+		}
+
+		value, id, err := customerCursorKey(opts.Sort, c)
+		if err != nil {
+			return nil, customer.ErrInvalidCursor
+		}
+
+		// Walking backwards through a descending sort is the same as walking forwards through an
+		// ascending one and vice versa.
+		op := ">"
+		if desc != c.Backward {
+			op = "<"
+		}
+
+		query = query.Where(fmt.Sprintf("(%s, c.uuid) %s (?, ?)", column, op), value, id)
+		from = c
+	}
+
+	dir := "ASC"
+	if desc != from.Backward {
+		dir = "DESC"
+	}
+
+	// We fetch one more record than we need so that we know if there is another page.
+	query = query.OrderBy(column+" "+dir, "c.uuid "+dir).Limit(uint64(opts.Limit + 1))
+
+	var recs []customerRecord
+	if err := cr.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to list customers: %w", err)
+	}
+
+	hasMore := len(recs) > opts.Limit
+	if hasMore {
+		recs = recs[:opts.Limit]
+	}
+
+	// Records for a backwards page are fetched in reverse so we flip them back to the requested order.
+	if from.Backward {
+		for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+			recs[i], recs[j] = recs[j], recs[i]
+		}
+	}
+
+	page := &customer.Page{Customers: make([]*customer.Customer, 0, len(recs))}
+
+	for _, rec := range recs {
+		page.Customers = append(page.Customers, rec.toCustomer())
+	}
+
+	if len(recs) == 0 {
+		return page, nil
+	}
+
+	first, last := recs[0], recs[len(recs)-1]
+
+	if from.Backward {
+		page.NextCursor = last.cursor(opts.Sort, false).encode()
+
+		if hasMore {
+			page.PrevCursor = first.cursor(opts.Sort, true).encode()
+		}
+
+		return page, nil
+	}
+
+	if hasMore {
+		page.NextCursor = last.cursor(opts.Sort, false).encode()
+	}
+
+	if opts.Cursor != "" {
+		page.PrevCursor = first.cursor(opts.Sort, true).encode()
+	}
+
+	return page, nil
+}
+
+// GrantRole grants the role to the domain.Customer with the given id. Existing grants are left as they are.
+func (cr *CustomerRepository) GrantRole(ctx context.Context, id uuid.UUID, role string) error {
+	roleID, err := cr.findRoleID(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	// Selecting from customers means that nothing is inserted for customers that do not exist.
+	customers := cr.db.QB().
+		Select("c.uuid").
+		Column("CAST(? AS INT)", roleID).
+		Column("CAST(? AS TIMESTAMP)", time.Now()).
+		From("customers AS c").
+		Where(qb.Eq{"c.uuid": id, "c.deleted_at": nil})
+
+	query := cr.db.QB().
+		Insert("customer_roles").
+		Columns("customer_uuid", "role_id", "created_at").
+		Select(customers).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customer_roles create query to SQL: %w", err)
+	}
+
+	tag, err := cr.db.Conn().Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("unable to create customer_roles: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return cr.ensureExists(ctx, id)
+	}
+
+	return nil
+}
+
+// RevokeRole revokes the role from the domain.Customer with the given id.
+func (cr *CustomerRepository) RevokeRole(ctx context.Context, id uuid.UUID, role string) error {
+	roleID, err := cr.findRoleID(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	if err := cr.ensureExists(ctx, id); err != nil {
+		return err
+	}
+
+	query := cr.db.QB().
+		Delete("customer_roles").
+		Where(qb.Eq{"customer_uuid": id, "role_id": roleID})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customer_roles delete query to SQL: %w", err)
+	}
+
+	if _, err = cr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to delete customer_roles: %w", err)
+	}
+
+	return nil
+}
+
+// customerExport is the personal data held in the customers table.
+type customerExport struct {
+	ID         uuid.UUID  `json:"id"`
+	Username   string     `json:"username"`
+	Roles      []string   `json:"roles"`
+	CreatedAt  time.Time  `json:"created_at"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+}
+
+// ExportPersonalData returns the domain.Customer with the given id, including deleted customers.
+// If none is found then nil is returned.
+func (cr *CustomerRepository) ExportPersonalData(ctx context.Context, id uuid.UUID) (interface{}, error) {
+	c, err := cr.findOne(ctx, qb.Eq{"c.uuid": id})
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	return customerExport{
+		ID:         c.ID(),
+		Username:   c.Username(),
+		Roles:      c.Roles(),
+		CreatedAt:  c.CreatedAt(),
+		VerifiedAt: c.VerifiedAt(),
+		DeletedAt:  c.DeletedAt(),
+	}, nil
+}
+
+// ErasePersonalData anonymizes the domain.Customer with the given id. The row is kept, rather than deleted, so
+// that the customer id held in retained records such as the ledger still refers to a customer. The username is
+// replaced with one derived from the id, the password is cleared so that it can never be used to log in, the
+// roles are revoked and the customer is deleted and marked as erased so that it can not be restored. A
+// customer.Erased event is written to the outbox in the same transaction.
+func (cr *CustomerRepository) ErasePersonalData(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+
+	anonymize, anonymizeArgs, err := cr.db.QB().
+		Update("customers").
+		Set("username", "erased:"+id.String()).
+		Set("password", "").
+		Set("verified_at", nil).
+		Set("deleted_at", qb.Expr("COALESCE(deleted_at, ?)", now)).
+		Set("erased_at", qb.Expr("COALESCE(erased_at, ?)", now)).
+		Set("updated_at", now).
+		Set("version", qb.Expr("version + 1")).
+		Where(qb.Eq{"uuid": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customers erase query to SQL: %w", err)
+	}
+
+	roles, rolesArgs, err := cr.db.QB().
+		Delete("customer_roles").
+		Where(qb.Eq{"customer_uuid": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customer_roles erase query to SQL: %w", err)
+	}
+
+	return cr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, anonymize, anonymizeArgs...); err != nil {
+			return fmt.Errorf("unable to erase customers: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, roles, rolesArgs...); err != nil {
+			return fmt.Errorf("unable to erase customer_roles: %w", err)
+		}
+
+		erased := customer.Erased{CustomerID: id, OccurredAt: now}
+
+		return writeOutbox(ctx, tx, cr.db, customerAggregate, id, []event.Event{erased})
+	})
+}
+
+// findRoleID returns the id of the role with the given name or customer.ErrUnknownRole if there is none.
+func (cr *CustomerRepository) findRoleID(ctx context.Context, role string) (int, error) {
+	var rec struct{ ID int }
+
 	query := cr.db.QB().
-		Select("c.uuid as id", "c.username", "c.password").
-		From("customers as c").
-		Where(qb.Eq{"username": username})
+		Select("id").
+		From("roles").
+		Where(qb.Eq{"name": role})
+
+	if err := cr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, customer.ErrUnknownRole
+		}
 
-	var cust struct {
-		ID       string
-		Username string
-		Password string
+		return 0, fmt.Errorf("unable to fetch roles: %w", err)
 	}
 
-	if err := cr.db.Get(ctx, &cust, query); err != nil {
+	return rec.ID, nil
+}
+
+// ensureExists returns customer.ErrNotFound if there is no domain.Customer with the given id.
+func (cr *CustomerRepository) ensureExists(ctx context.Context, id uuid.UUID) error {
+	c, err := cr.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		return customer.ErrNotFound
+	}
+
+	return nil
+}
+
+// customerSortColumn maps the allowed customer.ListOptions sorts to the column they order by.
+func customerSortColumn(sort string) (column string, desc bool, ok bool) {
+	switch sort {
+	case customer.SortCreatedAt:
+		return "c.created_at", false, true
+	case customer.SortCreatedAtDesc:
+		return "c.created_at", true, true
+	case customer.SortUsername:
+		return "c.username", false, true
+	case customer.SortUsernameDesc:
+		return "c.username", true, true
+	}
+
+	return "", false, false
+}
+
+// customerCursorKey converts the values held in the cursor back into the types of the sort column.
+func customerCursorKey(sort string, c cursor) (interface{}, uuid.UUID, error) {
+	id, err := uuid.Parse(c.ID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("unable to parse cursor id: %w", err)
+	}
+
+	if sort == customer.SortCreatedAt || sort == customer.SortCreatedAtDesc {
+		createdAt, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, uuid.Nil, fmt.Errorf("unable to parse cursor value: %w", err)
+		}
+
+		return createdAt, id, nil
+	}
+
+	return c.Value, id, nil
+}
+
+// customerRecord is the database representation of a customer.Customer.
+type customerRecord struct {
+	ID          string
+	Username    string
+	Password    string
+	Version     int
+	CreatedAt   time.Time
+	VerifiedAt  *time.Time
+	DeletedAt   *time.Time
+	Roles       []string
+	Permissions []string
+}
+
+// cursor creates a cursor pointing at the record for the given sort.
+func (rec customerRecord) cursor(sort string, backward bool) cursor {
+	value := rec.Username
+	if sort == customer.SortCreatedAt || sort == customer.SortCreatedAtDesc {
+		value = rec.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return cursor{Sort: sort, Value: value, ID: rec.ID, Backward: backward}
+}
+
+func (rec customerRecord) toCustomer() *customer.Customer {
+	return customer.Restore(customer.State{
+		ID:           uuid.MustParse(rec.ID),
+		Username:     rec.Username,
+		PasswordHash: rec.Password,
+		Version:      rec.Version,
+		CreatedAt:    rec.CreatedAt,
+		VerifiedAt:   rec.VerifiedAt,
+		DeletedAt:    rec.DeletedAt,
+		Roles:        rec.Roles,
+		Permissions:  rec.Permissions,
+	})
+}
+
+// customerColumns are selected for each customerRecord. The roles and permissions granted to the customer
+// are aggregated into arrays so that they are loaded with the customer.
+var customerColumns = []string{ //nolint:gochecknoglobals
+	"c.uuid as id",
+	"c.username",
+	"c.password",
+	"c.version",
+	"c.created_at",
+	"c.verified_at",
+	"c.deleted_at",
+	"ARRAY(" +
+		"SELECT r.name FROM customer_roles AS cr JOIN roles AS r ON r.id = cr.role_id " +
+		"WHERE cr.customer_uuid = c.uuid ORDER BY r.name" +
+		") AS roles",
+	"ARRAY(" +
+		"SELECT DISTINCT rp.permission FROM customer_roles AS cr " +
+		"JOIN role_permissions AS rp ON rp.role_id = cr.role_id " +
+		"WHERE cr.customer_uuid = c.uuid ORDER BY rp.permission" +
+		") AS permissions",
+}
+
+func (cr *CustomerRepository) selectCustomers() qb.SelectBuilder {
+	return cr.db.QB().
+		Select(customerColumns...).
+		From("customers as c")
+}
+
+func (cr *CustomerRepository) findOne(ctx context.Context, preds ...qb.Sqlizer) (*customer.Customer, error) {
+	var rec customerRecord
+
+	query := cr.selectCustomers()
+	for _, pred := range preds {
+		query = query.Where(pred)
+	}
+
+	if err := cr.db.Get(ctx, &rec, query); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
@@ -65,9 +648,15 @@ func (cr *CustomerRepository) FindByUsername(ctx context.Context, username strin
 		return nil, fmt.Errorf("unable to fetch customers: %w", err)
 	}
 
-	return customer.Restore(
-		uuid.MustParse(cust.ID),
-		cust.Username,
-		cust.Password,
-	), nil
+	return rec.toCustomer(), nil
+}
+
+// uniqueViolation is the postgres error code raised when a unique index or constraint is violated.
+const uniqueViolation = "23505"
+
+// isUniqueViolation returns true if err was caused by a row violating the named unique index or constraint.
+func isUniqueViolation(err error, name string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == name
 }
@@ -0,0 +1,291 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerRepositoryPurge(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	now := time.Now()
+
+	records := map[string]*time.Time{
+		"active@example.org":           nil,
+		"recently.deleted@example.org": timePtr(now.Add(-time.Hour)),
+		"long.deleted@example.org":     timePtr(now.Add(-48 * time.Hour)),
+	}
+
+	for username, deletedAt := range records {
+		postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+			"uuid":       uuid.New(),
+			"username":   username,
+			"password":   "abc123",
+			"created_at": now,
+			"updated_at": now,
+			"deleted_at": deletedAt,
+		})
+	}
+
+	erasedID := uuid.New()
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       erasedID,
+		"username":   "erased@example.org",
+		"password":   "abc123",
+		"created_at": now,
+		"updated_at": now,
+		"deleted_at": now.Add(-48 * time.Hour),
+		"erased_at":  now.Add(-48 * time.Hour),
+	})
+
+	purged, err := postgres.NewCustomerRepository(testEnv.DB()).Purge(context.Background(), now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("unable to purge customers: %v", err)
+	}
+
+	assert.Equal(t, int64(1), purged)
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{"username": "active@example.org"})
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{
+		"username": "recently.deleted@example.org",
+	})
+	// Erased customers are kept for the ledger and as proof of the erasure.
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{"uuid": erasedID.String()})
+}
+
+func TestCustomerRepositoryRoles(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	ctx := context.Background()
+	id := uuid.New()
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   "admin@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	assert.True(t, errors.Is(repo.GrantRole(ctx, id, "unknown"), customer.ErrUnknownRole))
+	assert.True(t, errors.Is(repo.GrantRole(ctx, uuid.New(), customer.RoleAdmin), customer.ErrNotFound))
+
+	// Granting twice must not fail.
+	for i := 0; i < 2; i++ {
+		if err := repo.GrantRole(ctx, id, customer.RoleAdmin); err != nil {
+			t.Fatalf("unable to grant role: %v", err)
+		}
+	}
+
+	cust, err := repo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.Equal(t, []string{customer.RoleAdmin}, cust.Roles())
+	assert.True(t, cust.HasRole(customer.RoleAdmin))
+	assert.True(t, cust.HasPermissions(customer.PermissionManageRoles, customer.PermissionUnlockLogins))
+	assert.False(t, cust.HasPermissions(customer.PermissionManageRoles, "unknown:permission"))
+
+	if err := repo.RevokeRole(ctx, id, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to revoke role: %v", err)
+	}
+
+	cust, err = repo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.Empty(t, cust.Roles())
+	assert.False(t, cust.HasPermissions(customer.PermissionManageRoles))
+}
+
+func TestCustomerRepositoryUniqueUsernames(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	hasher := testEnv.PasswordHasher()
+	ctx := context.Background()
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       uuid.New(),
+		"username":   "deleted@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+		"deleted_at": time.Now(),
+	})
+
+	add := func(username string) (*customer.Customer, error) {
+		t.Helper()
+
+		c, err := customer.New(username, "Sup3rS3cr3t", hasher)
+		if err != nil {
+			t.Fatalf("unable to create customer: %v", err)
+		}
+
+		return c, repo.Add(ctx, c)
+	}
+
+	if _, err := add("taken@example.org"); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	_, err := add("taken@example.org")
+	assert.True(t, errors.Is(err, customer.ErrUsernameTaken), err)
+
+	// Usernames that only differ by case belong to the same customer.
+	_, err = add(" Taken@EXAMPLE.org")
+	assert.True(t, errors.Is(err, customer.ErrUsernameTaken), err)
+
+	found, err := repo.FindByUsername(ctx, "TAKEN@example.org ")
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "taken@example.org", found.Username())
+	}
+
+	// Deleted customers do not hold on to their username.
+	other, err := add("deleted@example.org")
+	if err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	if err := other.ChangeUsername("taken@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	_, err = repo.Update(ctx, other)
+	assert.True(t, errors.Is(err, customer.ErrUsernameTaken), err)
+}
+
+// failingAuditor records the entry and then fails, as if the audit log became unavailable part way through.
+type failingAuditor struct {
+	recorder *audit.Recorder
+}
+
+func (a failingAuditor) Record(
+	ctx context.Context,
+	action, resourceType, resourceID string,
+	changes map[string]audit.Change,
+) error {
+	if err := a.recorder.Record(ctx, action, resourceType, resourceID, changes); err != nil {
+		return err
+	}
+
+	return errors.New("audit log unavailable")
+}
+
+func TestAuditingCustomerRepositoryRecordsInTheSameTransaction(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ctx := context.Background()
+	store := postgres.NewCustomerRepository(testEnv.DB())
+	recorder := audit.NewRecorder(
+		postgres.NewAuditRepository(testEnv.DB()),
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+
+	recorded, err := customer.New("recorded@example.org", "Sup3rS3cr3t", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := customer.NewAuditingRepository(store, recorder, testEnv.DB()).Add(ctx, recorded); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "audit_entries", map[string]string{
+		"action":      audit.ActionCreate,
+		"resource_id": recorded.ID().String(),
+	})
+
+	unrecorded, err := customer.New("unrecorded@example.org", "Sup3rS3cr3t", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	failing := customer.NewAuditingRepository(store, failingAuditor{recorder}, testEnv.DB())
+	assert.Error(t, failing.Add(ctx, unrecorded))
+
+	found, err := store.FindByID(ctx, unrecorded.ID())
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.Nil(t, found, "the customer is rolled back when it can not be recorded")
+
+	entries := postgres.NewAuditRepository(testEnv.DB())
+
+	page, err := entries.List(ctx, audit.Filter{ResourceID: unrecorded.ID().String()})
+	if err != nil {
+		t.Fatalf("unable to list audit entries: %v", err)
+	}
+
+	assert.Empty(t, page.Entries, "the audit entry is rolled back with the customer")
+}
+
+func TestAuditingPersonalDataStoreRecordsErasureInTheSameTransaction(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ctx := context.Background()
+	store := postgres.NewCustomerRepository(testEnv.DB())
+	recorder := audit.NewRecorder(
+		postgres.NewAuditRepository(testEnv.DB()),
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+
+	c, err := customer.New("erased@example.org", "Sup3rS3cr3t", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := store.Add(ctx, c); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	failing := customer.NewAuditingPersonalDataStore(store, failingAuditor{recorder}, testEnv.DB())
+	assert.Error(t, failing.ErasePersonalData(ctx, c.ID()))
+
+	found, err := store.FindByID(ctx, c.ID())
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	if assert.NotNil(t, found, "the erasure is rolled back when it can not be recorded") {
+		assert.Equal(t, "erased@example.org", found.Username())
+	}
+
+	recorded := customer.NewAuditingPersonalDataStore(store, recorder, testEnv.DB())
+	if err := recorded.ErasePersonalData(ctx, c.ID()); err != nil {
+		t.Fatalf("unable to erase customer: %v", err)
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "audit_entries", map[string]string{
+		"action":      audit.ActionErase,
+		"resource_id": c.ID().String(),
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
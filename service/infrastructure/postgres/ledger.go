@@ -0,0 +1,309 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+)
+
+// foreignKeyViolation is the postgres error code raised when a row references a row that does not exist.
+const foreignKeyViolation = "23503"
+
+// historyCursorSort identifies cursors handed out by LedgerRepository.History.
+const historyCursorSort = "ledger_history"
+
+// LedgerRepository gives us our datastore interaction for ledger accounts and entries.
+type LedgerRepository struct {
+	db *app.DB
+}
+
+// NewLedgerRepository creates a new LedgerRepository with an encapsulated database connection.
+func NewLedgerRepository(db *app.DB) *LedgerRepository {
+	return &LedgerRepository{db}
+}
+
+// EnsureAccount returns the stored ledger.Account with the same code as the given ledger.Account, adding
+// the given ledger.Account if there is none.
+func (lr *LedgerRepository) EnsureAccount(ctx context.Context, a *ledger.Account) (*ledger.Account, error) {
+	query := lr.db.QB().
+		Insert("ledger_accounts").
+		Columns("uuid", "code", "type", "customer_uuid", "created_at").
+		Values(a.ID(), a.Code(), a.Type(), a.CustomerID(), a.CreatedAt()).
+		Suffix("ON CONFLICT (code) DO NOTHING")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert ledger_accounts create query to SQL: %w", err)
+	}
+
+	if _, err = lr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("unable to create ledger_accounts: %w", err)
+	}
+
+	stored, err := lr.findAccount(ctx, qb.Eq{"code": a.Code()})
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		return nil, fmt.Errorf("unable to find ledger_accounts %q after creating it", a.Code())
+	}
+
+	return stored, nil
+}
+
+// FindAccountByCustomer searches the repository for the ledger.Account belonging to the customer.
+// If none is found then nil is returned.
+func (lr *LedgerRepository) FindAccountByCustomer(ctx context.Context, customerID uuid.UUID) (*ledger.Account, error) {
+	return lr.findAccount(ctx, qb.Eq{"customer_uuid": customerID})
+}
+
+func (lr *LedgerRepository) findAccount(ctx context.Context, pred qb.Sqlizer) (*ledger.Account, error) {
+	var rec ledgerAccountRecord
+
+	query := lr.db.QB().
+		Select("uuid as id", "code", "type", "customer_uuid as customer_id", "created_at").
+		From("ledger_accounts").
+		Where(pred)
+
+	if err := lr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch ledger_accounts: %w", err)
+	}
+
+	return rec.toAccount(), nil
+}
+
+// Record persists the ledger.Entry along with all of its postings in a single transaction. The database
+// rejects the transaction if the postings do not balance. ledger.ErrUnknownAccount is returned if a posting
+// is made to an account that does not exist.
+func (lr *LedgerRepository) Record(ctx context.Context, e *ledger.Entry) error {
+	entry, entryArgs, err := lr.db.QB().
+		Insert("ledger_entries").
+		Columns("uuid", "description", "created_at").
+		Values(e.ID(), e.Description(), e.CreatedAt()).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert ledger_entries create query to SQL: %w", err)
+	}
+
+	postingsQuery := lr.db.QB().
+		Insert("ledger_postings").
+		Columns("entry_uuid", "account_uuid", "amount")
+
+	for _, p := range e.Postings() {
+		postingsQuery = postingsQuery.Values(e.ID(), p.AccountID(), p.Amount().Pence())
+	}
+
+	postings, postingsArgs, err := postingsQuery.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert ledger_postings create query to SQL: %w", err)
+	}
+
+	return lr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, entry, entryArgs...); err != nil {
+			return fmt.Errorf("unable to create ledger_entries: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, postings, postingsArgs...); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+				return ledger.ErrUnknownAccount
+			}
+
+			return fmt.Errorf("unable to create ledger_postings: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Net returns the debits minus the credits posted to the ledger.Account with the given id.
+func (lr *LedgerRepository) Net(ctx context.Context, accountID uuid.UUID) (money.Amount, error) {
+	var net int64
+
+	query := lr.db.QB().
+		Select("CAST(COALESCE(SUM(amount), 0) AS BIGINT)").
+		From("ledger_postings").
+		Where(qb.Eq{"account_uuid": accountID})
+
+	if err := lr.db.Get(ctx, &net, query); err != nil {
+		return money.Zero(), fmt.Errorf("unable to sum ledger_postings: %w", err)
+	}
+
+	return money.FromPence(net), nil
+}
+
+// History returns a ledger.HistoryPage of the postings made to the ledger.Account with the given id, newest
+// first. Pages are fetched using keyset pagination on the posting id.
+func (lr *LedgerRepository) History(
+	ctx context.Context,
+	accountID uuid.UUID,
+	opts ledger.HistoryOptions,
+) (*ledger.HistoryPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = ledger.DefaultHistoryLimit
+	}
+
+	if opts.Limit > ledger.MaxHistoryLimit {
+		opts.Limit = ledger.MaxHistoryLimit
+	}
+
+	query := lr.db.QB().
+		Select("p.id", "e.uuid as entry_id", "e.description", "p.amount", "e.created_at").
+		From("ledger_postings AS p").
+		Join("ledger_entries AS e ON e.uuid = p.entry_uuid").
+		Where(qb.Eq{"p.account_uuid": accountID})
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil || c.Sort != historyCursorSort {
+			return nil, ledger.ErrInvalidCursor
+		}
+
+		id, err := strconv.ParseInt(c.ID, 10, 64)
+		if err != nil {
+			return nil, ledger.ErrInvalidCursor
+		}
+
+		query = query.Where(qb.Lt{"p.id": id})
+	}
+
+	// We fetch one more record than we need so that we know if there is another page.
+	query = query.OrderBy("p.id DESC").Limit(uint64(opts.Limit + 1))
+
+	var recs []ledgerLineRecord
+	if err := lr.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to list ledger_postings: %w", err)
+	}
+
+	page := &ledger.HistoryPage{Lines: make([]ledger.Line, 0, len(recs))}
+
+	if len(recs) > opts.Limit {
+		recs = recs[:opts.Limit]
+		page.NextCursor = cursor{Sort: historyCursorSort, ID: strconv.FormatInt(recs[len(recs)-1].ID, 10)}.encode()
+	}
+
+	for _, rec := range recs {
+		page.Lines = append(page.Lines, ledger.Line{
+			EntryID:     uuid.MustParse(rec.EntryID),
+			Description: rec.Description,
+			Amount:      money.FromPence(rec.Amount),
+			CreatedAt:   rec.CreatedAt,
+		})
+	}
+
+	return page, nil
+}
+
+// ledgerExport is the personal data held in the ledger account of a customer. Amounts are in pence from the
+// point of view of the customer, so credits to the customer are positive.
+type ledgerExport struct {
+	AccountID    string             `json:"account_id"`
+	Balance      money.Amount       `json:"balance"`
+	Transactions []ledgerLineExport `json:"transactions"`
+}
+
+type ledgerLineExport struct {
+	EntryID     string       `json:"entry_id"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// ExportPersonalData returns the balance and every posting made to the ledger account of the customer with the
+// given id, oldest first. If the customer has no account then nil is returned.
+func (lr *LedgerRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	account, err := lr.FindAccountByCustomer(ctx, customerID)
+	if err != nil || account == nil {
+		return nil, err
+	}
+
+	query := lr.db.QB().
+		Select("p.id", "e.uuid as entry_id", "e.description", "p.amount", "e.created_at").
+		From("ledger_postings AS p").
+		Join("ledger_entries AS e ON e.uuid = p.entry_uuid").
+		Where(qb.Eq{"p.account_uuid": account.ID()}).
+		OrderBy("p.id")
+
+	var recs []ledgerLineRecord
+	if err := lr.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to export ledger_postings: %w", err)
+	}
+
+	net, err := lr.Net(ctx, account.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	export := ledgerExport{
+		AccountID:    account.ID().String(),
+		Balance:      account.Balance(net),
+		Transactions: make([]ledgerLineExport, 0, len(recs)),
+	}
+
+	for _, rec := range recs {
+		export.Transactions = append(export.Transactions, ledgerLineExport{
+			EntryID:     rec.EntryID,
+			Description: rec.Description,
+			Amount:      account.Balance(money.FromPence(rec.Amount)),
+			CreatedAt:   rec.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// ErasePersonalData does nothing as financial records must be kept for as long as the law requires. The ledger
+// only refers to the customer by their id so it no longer identifies them once the customer has been erased.
+func (lr *LedgerRepository) ErasePersonalData(context.Context, uuid.UUID) error {
+	return nil
+}
+
+// ledgerAccountRecord is the database representation of a ledger.Account.
+type ledgerAccountRecord struct {
+	ID         string
+	Code       string
+	Type       string
+	CustomerID *string
+	CreatedAt  time.Time
+}
+
+func (rec ledgerAccountRecord) toAccount() *ledger.Account {
+	var customerID *uuid.UUID
+
+	if rec.CustomerID != nil {
+		id := uuid.MustParse(*rec.CustomerID)
+		customerID = &id
+	}
+
+	return ledger.RestoreAccount(ledger.AccountState{
+		ID:         uuid.MustParse(rec.ID),
+		Code:       rec.Code,
+		Type:       rec.Type,
+		CustomerID: customerID,
+		CreatedAt:  rec.CreatedAt,
+	})
+}
+
+// ledgerLineRecord is the database representation of a ledger.Line.
+type ledgerLineRecord struct {
+	ID          int64
+	EntryID     string
+	Description string
+	Amount      int64
+	CreatedAt   time.Time
+}
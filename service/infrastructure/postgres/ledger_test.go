@@ -0,0 +1,137 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerRepository(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewLedgerRepository(testEnv.DB())
+	l := ledger.NewLedger(repo)
+	ctx := context.Background()
+	customerID := uuid.New()
+
+	for i := 1; i <= 3; i++ {
+		if _, err := l.Credit(ctx, customerID, money.FromPence(int64(i)), "top up"); err != nil {
+			t.Fatalf("unable to credit customer: %v", err)
+		}
+	}
+
+	account, err := repo.FindAccountByCustomer(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to find account: %v", err)
+	}
+
+	net, err := repo.Net(ctx, account.ID())
+	if err != nil {
+		t.Fatalf("unable to sum postings: %v", err)
+	}
+
+	assert.Equal(t, money.FromPence(-6), net)
+
+	first, err := repo.History(ctx, account.ID(), ledger.HistoryOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("unable to fetch history: %v", err)
+	}
+
+	second, err := repo.History(ctx, account.ID(), ledger.HistoryOptions{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("unable to fetch history: %v", err)
+	}
+
+	if assert.Len(t, first.Lines, 2) && assert.Len(t, second.Lines, 1) {
+		assert.Equal(t, money.FromPence(-3), first.Lines[0].Amount)
+		assert.Equal(t, money.FromPence(-2), first.Lines[1].Amount)
+		assert.Equal(t, money.FromPence(-1), second.Lines[0].Amount)
+		assert.Empty(t, second.NextCursor)
+	}
+
+	_, err = repo.History(ctx, account.ID(), ledger.HistoryOptions{Cursor: "not-a-cursor"})
+	assert.True(t, errors.Is(err, ledger.ErrInvalidCursor))
+
+	e, err := ledger.NewEntry("unknown account", ledger.Debit(account.ID(), 1), ledger.Credit(uuid.New(), 1))
+	if err != nil {
+		t.Fatalf("unable to create entry: %v", err)
+	}
+
+	assert.True(t, errors.Is(repo.Record(ctx, e), ledger.ErrUnknownAccount))
+}
+
+func TestLedgerRepositoryEntriesAreImmutable(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ctx := context.Background()
+
+	l := ledger.NewLedger(postgres.NewLedgerRepository(testEnv.DB()))
+
+	if _, err := l.Credit(ctx, uuid.New(), 1, "top up"); err != nil {
+		t.Fatalf("unable to credit customer: %v", err)
+	}
+
+	for _, sql := range []string{
+		"UPDATE ledger_postings SET amount = amount * 2",
+		"DELETE FROM ledger_postings",
+		"UPDATE ledger_entries SET description = 'changed'",
+		"DELETE FROM ledger_entries",
+	} {
+		_, err := testEnv.DB().Conn().Exec(ctx, sql)
+		assert.Error(t, err, sql)
+	}
+}
+
+func TestLedgerRepositoryRejectsUnbalancedPostings(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewLedgerRepository(testEnv.DB())
+	ctx := context.Background()
+
+	account, err := repo.EnsureAccount(ctx, ledger.NewCustomerAccount(uuid.New()))
+	if err != nil {
+		t.Fatalf("unable to create account: %v", err)
+	}
+
+	// Postings written around the domain must still balance.
+	err = testEnv.DB().Transaction(ctx, func(tx pgx.Tx) error {
+		entryID := uuid.New()
+
+		if _, err := tx.Exec(
+			ctx,
+			"INSERT INTO ledger_entries (uuid, description, created_at) VALUES ($1, 'unbalanced', NOW())",
+			entryID,
+		); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO ledger_postings (entry_uuid, account_uuid, amount) VALUES ($1, $2, 100)",
+			entryID,
+			account.ID(),
+		)
+
+		return err
+	})
+
+	assert.Error(t, err)
+
+	net, err := repo.Net(ctx, account.ID())
+	if err != nil {
+		t.Fatalf("unable to sum postings: %v", err)
+	}
+
+	assert.Equal(t, money.Zero(), net)
+}
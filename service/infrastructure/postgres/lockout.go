@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+)
+
+// LockoutRepository gives us our datastore interaction for lockout.Attempts.
+type LockoutRepository struct {
+	db *app.DB
+}
+
+// NewLockoutRepository creates a new LockoutRepository with an encapsulated database connection.
+func NewLockoutRepository(db *app.DB) *LockoutRepository {
+	return &LockoutRepository{db}
+}
+
+// Find searches the repository for the lockout.Attempts recorded for the key within the scope.
+// If none is found then nil is returned.
+func (lr *LockoutRepository) Find(ctx context.Context, scope, key string) (*lockout.Attempts, error) {
+	var rec loginFailureRecord
+
+	query := lr.db.QB().
+		Select("failures", "last_failed_at").
+		From("login_failures").
+		Where(qb.Eq{"scope": scope, "key": key})
+
+	if err := lr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch login_failures: %w", err)
+	}
+
+	return rec.toAttempts(), nil
+}
+
+// RecordFailure adds a failed attempt for the key within the scope. The count is incremented in a single
+// upsert so that concurrent failures are not lost, starting again from one if the last failure was
+// before resetBefore.
+func (lr *LockoutRepository) RecordFailure(ctx context.Context, scope, key string, at, resetBefore time.Time) error {
+	query := lr.db.QB().
+		Insert("login_failures").
+		Columns("scope", "key", "failures", "last_failed_at").
+		Values(scope, key, 1, at).
+		Suffix(
+			"ON CONFLICT (scope, key) DO UPDATE SET "+
+				"failures = CASE WHEN login_failures.last_failed_at < ? THEN 1 ELSE login_failures.failures + 1 END, "+
+				"last_failed_at = EXCLUDED.last_failed_at",
+			resetBefore,
+		)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert login_failures record query to SQL: %w", err)
+	}
+
+	if _, err = lr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to record login_failures: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes the failed attempts recorded for the key within the scope.
+func (lr *LockoutRepository) Reset(ctx context.Context, scope, key string) error {
+	query := lr.db.QB().
+		Delete("login_failures").
+		Where(qb.Eq{"scope": scope, "key": key})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert login_failures reset query to SQL: %w", err)
+	}
+
+	if _, err = lr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to reset login_failures: %w", err)
+	}
+
+	return nil
+}
+
+// loginFailureExport is the personal data held about the failed logins of a customer.
+type loginFailureExport struct {
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+// ExportPersonalData returns the failed logins recorded against the username of the customer with the given
+// id. Failures recorded against an IP address can not be linked to a customer so they are not included.
+// If none are found then nil is returned.
+func (lr *LockoutRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var rec loginFailureExport
+
+	query := lr.db.QB().
+		Select("failures", "last_failed_at").
+		From("login_failures").
+		Where(qb.Eq{"scope": lockout.ScopeUsername}).
+		Where(qb.Expr("key = (?)", lr.usernameKey(customerID)))
+
+	if err := lr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to export login_failures: %w", err)
+	}
+
+	return rec, nil
+}
+
+// ErasePersonalData deletes the failed logins recorded against the username of the customer with the given id.
+// It must be called before the customer is erased as the username is used to find them.
+func (lr *LockoutRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	sql, args, err := lr.db.QB().
+		Delete("login_failures").
+		Where(qb.Eq{"scope": lockout.ScopeUsername}).
+		Where(qb.Expr("key = (?)", lr.usernameKey(customerID))).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert login_failures erase query to SQL: %w", err)
+	}
+
+	if _, err = lr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to erase login_failures: %w", err)
+	}
+
+	return nil
+}
+
+// usernameKey selects the key that failed logins for the customer with the given id are recorded against.
+func (lr *LockoutRepository) usernameKey(customerID uuid.UUID) qb.SelectBuilder {
+	return lr.db.QB().
+		Select("username_key").
+		From("customers").
+		Where(qb.Eq{"uuid": customerID})
+}
+
+// loginFailureRecord is the database representation of lockout.Attempts.
+type loginFailureRecord struct {
+	Failures     int
+	LastFailedAt time.Time
+}
+
+func (rec loginFailureRecord) toAttempts() *lockout.Attempts {
+	return lockout.Restore(lockout.State{
+		Failures:     rec.Failures,
+		LastFailedAt: rec.LastFailedAt,
+	})
+}
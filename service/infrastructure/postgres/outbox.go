@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/event"
+	"github.com/nickbryan/go-template/service/app/outbox"
+)
+
+// OutboxRepository gives us our datastore interaction for the outbox.Message written alongside our aggregates.
+type OutboxRepository struct {
+	db *app.DB
+}
+
+// NewOutboxRepository creates a new OutboxRepository with an encapsulated database connection.
+func NewOutboxRepository(db *app.DB) *OutboxRepository {
+	return &OutboxRepository{db}
+}
+
+// Claim hides up to limit pending messages from other relays until the given time and returns them. A message
+// is only claimed once every earlier message of its aggregate has been published or dead lettered so that the
+// messages of an aggregate are published in order. Rows locked by another relay are skipped rather than waited
+// on.
+func (ob *OutboxRepository) Claim(ctx context.Context, limit int, until time.Time) ([]outbox.Message, error) {
+	now := time.Now()
+
+	earlierPending := ob.db.QB().
+		Select("1").
+		From("outbox AS e").
+		Where("e.aggregate_type = o.aggregate_type").
+		Where("e.aggregate_uuid = o.aggregate_uuid").
+		Where("e.id < o.id").
+		Where(qb.Eq{"e.published_at": nil, "e.dead_at": nil})
+
+	due := ob.db.QB().
+		Select("o.id").
+		From("outbox AS o").
+		Where(qb.Eq{"o.published_at": nil, "o.dead_at": nil}).
+		Where(qb.LtOrEq{"o.next_attempt_at": now}).
+		Where(qb.Or{qb.Eq{"o.locked_until": nil}, qb.LtOrEq{"o.locked_until": now}}).
+		Where(qb.Expr("NOT EXISTS (?)", earlierPending)).
+		OrderBy("o.id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query := ob.db.QB().
+		Update("outbox").
+		Set("locked_until", until).
+		Where(qb.Expr("id IN (?)", due)).
+		Suffix("RETURNING uuid AS id, aggregate_type, aggregate_uuid AS aggregate_id, event, payload, occurred_at, attempts")
+
+	var recs []outboxRecord
+
+	if err := ob.db.Select(ctx, &recs, query); err != nil {
+		return nil, fmt.Errorf("unable to claim outbox: %w", err)
+	}
+
+	messages := make([]outbox.Message, 0, len(recs))
+	for _, rec := range recs {
+		messages = append(messages, rec.toMessage())
+	}
+
+	return messages, nil
+}
+
+// MarkPublished records that the outbox.Message with the given id has been published.
+func (ob *OutboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := ob.db.QB().
+		Update("outbox").
+		Set("published_at", time.Now()).
+		Set("locked_until", nil).
+		Where(qb.Eq{"uuid": id})
+
+	return ob.exec(ctx, "publish", query)
+}
+
+// MarkFailed records a failed attempt at publishing the outbox.Message with the given id and releases it to be
+// retried at the given time.
+func (ob *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, retryAt time.Time, reason string) error {
+	query := ob.db.QB().
+		Update("outbox").
+		Set("attempts", qb.Expr("attempts + 1")).
+		Set("next_attempt_at", retryAt).
+		Set("last_error", reason).
+		Set("locked_until", nil).
+		Where(qb.Eq{"uuid": id})
+
+	return ob.exec(ctx, "fail", query)
+}
+
+// MarkDead records the final failed attempt at publishing the outbox.Message with the given id. Dead messages
+// are kept in the outbox so that they can be inspected and replayed by hand.
+func (ob *OutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string) error {
+	query := ob.db.QB().
+		Update("outbox").
+		Set("attempts", qb.Expr("attempts + 1")).
+		Set("dead_at", time.Now()).
+		Set("last_error", reason).
+		Set("locked_until", nil).
+		Where(qb.Eq{"uuid": id})
+
+	return ob.exec(ctx, "dead letter", query)
+}
+
+// ExportPersonalData returns nil as the outbox only holds copies of the data exported by other sources.
+func (ob *OutboxRepository) ExportPersonalData(context.Context, uuid.UUID) (interface{}, error) {
+	return nil, nil
+}
+
+// ErasePersonalData redacts the payloads of the messages raised by the customer with the given id so that only
+// the customer id remains. Messages that are still pending are published with the redacted payload.
+func (ob *OutboxRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	query := ob.db.QB().
+		Update("outbox").
+		Set("payload", qb.Expr("jsonb_build_object('customer_id', aggregate_uuid)")).
+		Where(qb.Eq{"aggregate_type": customerAggregate, "aggregate_uuid": customerID})
+
+	return ob.exec(ctx, "erase", query)
+}
+
+func (ob *OutboxRepository) exec(ctx context.Context, action string, query qb.UpdateBuilder) error {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert outbox %s query to SQL: %w", action, err)
+	}
+
+	if _, err = ob.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to %s outbox: %w", action, err)
+	}
+
+	return nil
+}
+
+// writeOutbox writes the events raised by an aggregate to the outbox within the transaction that saves the
+// aggregate so that the events are only published if the change that raised them is committed.
+func writeOutbox(
+	ctx context.Context,
+	tx pgx.Tx,
+	db *app.DB,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	events []event.Event,
+) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := db.QB().
+		Insert("outbox").
+		Columns("uuid", "aggregate_type", "aggregate_uuid", "event", "payload", "occurred_at", "next_attempt_at")
+
+	for _, e := range events {
+		m, err := outbox.NewMessage(aggregateType, aggregateID, e)
+		if err != nil {
+			return err
+		}
+
+		query = query.Values(m.ID, m.AggregateType, m.AggregateID, m.Event, string(m.Payload), m.OccurredAt, m.OccurredAt)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert outbox create query to SQL: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create outbox: %w", err)
+	}
+
+	return nil
+}
+
+// outboxRecord is the database representation of an outbox.Message.
+type outboxRecord struct {
+	ID            string
+	AggregateType string
+	AggregateID   string
+	Event         string
+	Payload       []byte
+	OccurredAt    time.Time
+	Attempts      int
+}
+
+func (rec outboxRecord) toMessage() outbox.Message {
+	return outbox.Message{
+		ID:            uuid.MustParse(rec.ID),
+		AggregateType: rec.AggregateType,
+		AggregateID:   uuid.MustParse(rec.AggregateID),
+		Event:         rec.Event,
+		Payload:       rec.Payload,
+		OccurredAt:    rec.OccurredAt,
+		Attempts:      rec.Attempts,
+	}
+}
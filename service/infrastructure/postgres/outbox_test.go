@@ -0,0 +1,125 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/outbox"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	customers := postgres.NewCustomerRepository(testEnv.DB())
+	repo := postgres.NewOutboxRepository(testEnv.DB())
+	hasher := testEnv.PasswordHasher()
+	ctx := context.Background()
+
+	add := func(username string) (*customer.Customer, error) {
+		t.Helper()
+
+		c, err := customer.New(username, "Sup3rS3cr3t", hasher)
+		if err != nil {
+			t.Fatalf("unable to create customer: %v", err)
+		}
+
+		return c, customers.Add(ctx, c)
+	}
+
+	claim := func() map[string]outbox.Message {
+		t.Helper()
+
+		messages, err := repo.Claim(ctx, 10, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("unable to claim outbox: %v", err)
+		}
+
+		claimed := make(map[string]outbox.Message)
+		for _, m := range messages {
+			claimed[m.AggregateID.String()+" "+m.Event] = m
+		}
+
+		return claimed
+	}
+
+	first, err := add("first@example.org")
+	if err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	if err := first.ChangeUsername("renamed@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	if _, err := customers.Update(ctx, first); err != nil {
+		t.Fatalf("unable to update customer: %v", err)
+	}
+
+	second, err := add("second@example.org")
+	if err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	// Nothing is written to the outbox when saving the customer fails.
+	duplicate, err := add("second@example.org")
+	assert.True(t, errors.Is(err, customer.ErrUsernameTaken))
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "outbox", map[string]string{
+		"aggregate_type": "customer",
+		"aggregate_uuid": first.ID().String(),
+		"event":          customer.EventUsernameChanged,
+	})
+
+	// Only the oldest pending message of each customer is claimed.
+	claimed := claim()
+	firstRegistered := claimed[first.ID().String()+" "+customer.EventRegistered]
+	secondRegistered := claimed[second.ID().String()+" "+customer.EventRegistered]
+
+	assert.Len(t, claimed, 2)
+	assert.NotContains(t, claimed, duplicate.ID().String()+" "+customer.EventRegistered)
+
+	var registered customer.Registered
+	if err := json.Unmarshal(firstRegistered.Payload, &registered); err != nil {
+		t.Fatalf("unable to unmarshal payload: %v", err)
+	}
+
+	assert.Equal(t, first.ID(), registered.CustomerID)
+	assert.Equal(t, "first@example.org", registered.Username)
+
+	// Claimed messages are hidden from other relays.
+	assert.Empty(t, claim())
+
+	if err := repo.MarkPublished(ctx, firstRegistered.ID); err != nil {
+		t.Fatalf("unable to mark message as published: %v", err)
+	}
+
+	if err := repo.MarkFailed(ctx, secondRegistered.ID, time.Now().Add(-time.Second), "unavailable"); err != nil {
+		t.Fatalf("unable to mark message as failed: %v", err)
+	}
+
+	claimed = claim()
+	retried := claimed[second.ID().String()+" "+customer.EventRegistered]
+
+	assert.Len(t, claimed, 2)
+	assert.Contains(t, claimed, first.ID().String()+" "+customer.EventUsernameChanged)
+	assert.Equal(t, 1, retried.Attempts)
+
+	if err := repo.MarkDead(ctx, retried.ID, "unavailable"); err != nil {
+		t.Fatalf("unable to mark message as dead: %v", err)
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "outbox", map[string]string{
+		"uuid":       retried.ID.String(),
+		"attempts":   "2",
+		"last_error": "unavailable",
+	})
+}
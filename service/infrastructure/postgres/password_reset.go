@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/passwordreset"
+)
+
+// PasswordResetRepository gives us our datastore interaction for a passwordreset.Token.
+type PasswordResetRepository struct {
+	db *app.DB
+}
+
+// NewPasswordResetRepository creates a new PasswordResetRepository with an encapsulated database connection.
+func NewPasswordResetRepository(db *app.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db}
+}
+
+// Add a new passwordreset.Token to the repository.
+func (pr *PasswordResetRepository) Add(ctx context.Context, t *passwordreset.Token) error {
+	query := pr.db.QB().
+		Insert("password_resets").
+		Columns("uuid", "customer_uuid", "token_hash", "expires_at", "created_at").
+		Values(t.ID(), t.CustomerID(), t.Hash(), t.ExpiresAt(), time.Now())
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert password_resets create query to SQL: %w", err)
+	}
+
+	if _, err = pr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create password_resets: %w", err)
+	}
+
+	return nil
+}
+
+// Use marks the passwordreset.Token as used. The token is only marked if it is still usable so that
+// only one of two concurrent uses succeeds, the other receives passwordreset.ErrTokenUsed.
+func (pr *PasswordResetRepository) Use(ctx context.Context, t *passwordreset.Token) error {
+	now := time.Now()
+
+	query := pr.db.QB().
+		Update("password_resets").
+		Set("used_at", now).
+		Where(qb.Eq{"uuid": t.ID(), "used_at": nil}).
+		Where(qb.Gt{"expires_at": now})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert password_resets use query to SQL: %w", err)
+	}
+
+	tag, err := pr.db.Conn().Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("unable to use password_resets: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return passwordreset.ErrTokenUsed
+	}
+
+	return nil
+}
+
+// FindByHash searches the repository for a passwordreset.Token with the given hashed secret.
+// If none is found then nil is returned.
+func (pr *PasswordResetRepository) FindByHash(ctx context.Context, hash string) (*passwordreset.Token, error) {
+	var rec passwordResetRecord
+
+	query := pr.db.QB().
+		Select("uuid as id", "customer_uuid as customer_id", "token_hash", "expires_at", "used_at").
+		From("password_resets").
+		Where(qb.Eq{"token_hash": hash})
+
+	if err := pr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch password_resets: %w", err)
+	}
+
+	return rec.toToken(), nil
+}
+
+// ExportPersonalData returns the password reset tokens issued to the customer with the given id.
+// If none are found then nil is returned.
+func (pr *PasswordResetRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var recs []tokenExport
+
+	columns := []string{"created_at", "expires_at", "used_at"}
+	if err := exportRows(ctx, pr.db, &recs, "password_resets", columns, customerID); err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	return recs, nil
+}
+
+// ErasePersonalData deletes the password reset tokens issued to the customer with the given id.
+func (pr *PasswordResetRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	return eraseRows(ctx, pr.db, "password_resets", customerID)
+}
+
+// passwordResetRecord is the database representation of a passwordreset.Token.
+type passwordResetRecord struct {
+	ID         string
+	CustomerID string
+	TokenHash  string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+}
+
+func (rec passwordResetRecord) toToken() *passwordreset.Token {
+	return passwordreset.Restore(passwordreset.State{
+		ID:         uuid.MustParse(rec.ID),
+		CustomerID: uuid.MustParse(rec.CustomerID),
+		Hash:       rec.TokenHash,
+		ExpiresAt:  rec.ExpiresAt,
+		UsedAt:     rec.UsedAt,
+	})
+}
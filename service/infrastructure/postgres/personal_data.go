@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+)
+
+// PersonalDataRequestRepository gives us our datastore interaction for a privacy.Request.
+type PersonalDataRequestRepository struct {
+	db *app.DB
+}
+
+// NewPersonalDataRequestRepository creates a new PersonalDataRequestRepository with an encapsulated database
+// connection.
+func NewPersonalDataRequestRepository(db *app.DB) *PersonalDataRequestRepository {
+	return &PersonalDataRequestRepository{db}
+}
+
+// Add a completed privacy.Request to the repository. Requests can not be changed once they are added.
+func (pr *PersonalDataRequestRepository) Add(ctx context.Context, r *privacy.Request) error {
+	query := pr.db.QB().
+		Insert("personal_data_requests").
+		Columns("uuid", "customer_uuid", "type", "requested_by_uuid", "sources", "completed_at").
+		Values(r.ID(), r.CustomerID(), r.Type(), r.RequestedBy(), r.Sources(), r.CompletedAt())
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert personal_data_requests create query to SQL: %w", err)
+	}
+
+	if _, err = pr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create personal_data_requests: %w", err)
+	}
+
+	return nil
+}
+
+// tokenExport is the personal data held about a single use token. The token hash is left out as it is a
+// credential rather than information about the customer.
+type tokenExport struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// exportRows selects the columns of the rows in the table that belong to the customer with the given id, oldest
+// first, into dst which must be a pointer to a slice.
+func exportRows(
+	ctx context.Context,
+	db *app.DB,
+	dst interface{},
+	table string,
+	columns []string,
+	customerID uuid.UUID,
+) error {
+	query := db.QB().
+		Select(columns...).
+		From(table).
+		Where(qb.Eq{"customer_uuid": customerID}).
+		OrderBy("created_at", "id")
+
+	if err := db.Select(ctx, dst, query); err != nil {
+		return fmt.Errorf("unable to export %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// eraseRows deletes the rows in the table that belong to the customer with the given id.
+func eraseRows(ctx context.Context, db *app.DB, table string, customerID uuid.UUID) error {
+	sql, args, err := db.QB().
+		Delete(table).
+		Where(qb.Eq{"customer_uuid": customerID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert %s erase query to SQL: %w", table, err)
+	}
+
+	if _, err = db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to erase %s: %w", table, err)
+	}
+
+	return nil
+}
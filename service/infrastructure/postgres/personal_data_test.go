@@ -0,0 +1,145 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalData(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	db := testEnv.DB()
+	ctx := context.Background()
+	adminID := uuid.New()
+
+	customers := postgres.NewCustomerRepository(db)
+	ledgerRepo := postgres.NewLedgerRepository(db)
+
+	s := privacy.NewService(postgres.NewPersonalDataRequestRepository(db))
+	s.Register("customer", customers)
+	s.Register("sessions", postgres.NewRefreshTokenRepository(db))
+	s.Register("password_resets", postgres.NewPasswordResetRepository(db))
+	s.Register("verifications", postgres.NewVerificationRepository(db))
+	s.Register("failed_logins", postgres.NewLockoutRepository(db))
+	s.Register("api_keys", postgres.NewAPIKeyRepository(db))
+	s.Register("ledger", ledgerRepo)
+	s.Register("events", postgres.NewOutboxRepository(db))
+
+	c, err := customer.New("bob@example.org", "Sup3rS3cr3t", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := customers.Add(ctx, c); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	if err := customers.GrantRole(ctx, c.ID(), customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	if _, err := ledger.NewLedger(ledgerRepo).Credit(ctx, c.ID(), money.FromPence(500), "top up"); err != nil {
+		t.Fatalf("unable to credit customer: %v", err)
+	}
+
+	postgrestest.Insert(t, db, "refresh_tokens", map[string]interface{}{
+		"uuid":          uuid.New(),
+		"family_uuid":   uuid.New(),
+		"customer_uuid": c.ID(),
+		"token_hash":    "refresh-hash",
+		"expires_at":    time.Now().Add(time.Hour),
+		"created_at":    time.Now(),
+	})
+
+	postgrestest.Insert(t, db, "api_keys", map[string]interface{}{
+		"uuid":          uuid.New(),
+		"customer_uuid": c.ID(),
+		"name":          "deploy",
+		"hint":          "abcd",
+		"key_hash":      "key-hash",
+		"scopes":        []string{},
+		"created_at":    time.Now(),
+	})
+
+	postgrestest.Insert(t, db, "login_failures", map[string]interface{}{
+		"scope":          lockout.ScopeUsername,
+		"key":            "bob@example.org",
+		"failures":       2,
+		"last_failed_at": time.Now(),
+	})
+
+	archive, err := s.Export(ctx, c.ID(), adminID)
+	if err != nil {
+		t.Fatalf("unable to export personal data: %v", err)
+	}
+
+	assert.ElementsMatch(
+		t,
+		[]string{"customer", "sessions", "failed_logins", "api_keys", "ledger"},
+		mapKeys(archive.Data),
+	)
+
+	erasure, err := s.Erase(ctx, c.ID(), adminID)
+	if err != nil {
+		t.Fatalf("unable to erase personal data: %v", err)
+	}
+
+	postgrestest.AssertDatabaseHas(t, db, "customers", map[string]string{
+		"uuid":     c.ID().String(),
+		"username": "erased:" + c.ID().String(),
+		"password": "",
+	})
+	postgrestest.AssertDatabaseHas(t, db, "personal_data_requests", map[string]string{
+		"uuid":          erasure.ID().String(),
+		"customer_uuid": c.ID().String(),
+		"type":          privacy.RequestErasure,
+	})
+	postgrestest.AssertDatabaseHas(t, db, "outbox", map[string]string{
+		"aggregate_uuid": c.ID().String(),
+		"event":          customer.EventErased,
+	})
+
+	// Only the anonymized customer and the ledger, which is kept for legal reasons, remain.
+	archive, err = s.Export(ctx, c.ID(), adminID)
+	if err != nil {
+		t.Fatalf("unable to export personal data: %v", err)
+	}
+
+	assert.ElementsMatch(t, []string{"customer", "ledger"}, mapKeys(archive.Data))
+
+	_, err = customers.Undelete(ctx, c.ID())
+	assert.True(t, errors.Is(err, customer.ErrNotFound))
+
+	var payloads []string
+
+	if err := db.Select(ctx, &payloads, db.QB().Select("payload::text").From("outbox")); err != nil {
+		t.Fatalf("unable to select outbox payloads: %v", err)
+	}
+
+	for _, payload := range payloads {
+		assert.NotContains(t, payload, "bob@example.org")
+	}
+}
+
+func mapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
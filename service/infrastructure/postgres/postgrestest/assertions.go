@@ -38,4 +38,9 @@ func AssertDatabaseHas(t *testing.T, db *app.DB, table string, fields map[string
 
 		t.Fatalf("assert database, has scan failed: %v", err)
 	}
+
+	if count == 0 {
+		t.Errorf("record not found in %s with args: %v", table, fields)
+		t.FailNow()
+	}
 }
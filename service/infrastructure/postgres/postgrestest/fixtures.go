@@ -0,0 +1,36 @@
+package postgrestest
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/nickbryan/go-template/service/app"
+)
+
+// Insert allows seeding a table with a record made up of the given fields.
+func Insert(t *testing.T, db *app.DB, table string, fields map[string]interface{}) {
+	t.Helper()
+
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		columns = append(columns, column)
+	}
+
+	// Sort the columns so that the generated SQL is deterministic.
+	sort.Strings(columns)
+
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, fields[column])
+	}
+
+	sql, args, err := db.QB().Insert(table).Columns(columns...).Values(values...).ToSql()
+	if err != nil {
+		t.Fatalf("insert is unable to build query: %v", err)
+	}
+
+	if _, err := db.Conn().Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("insert into %s failed: %v", table, err)
+	}
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/session"
+)
+
+// RefreshTokenRepository gives us our datastore interaction for a session.RefreshToken.
+type RefreshTokenRepository struct {
+	db *app.DB
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository with an encapsulated database connection.
+func NewRefreshTokenRepository(db *app.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db}
+}
+
+// Add a new session.RefreshToken to the repository.
+func (rr *RefreshTokenRepository) Add(ctx context.Context, t *session.RefreshToken) error {
+	sql, args, err := rr.insert(t).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert refresh_tokens create query to SQL: %w", err)
+	}
+
+	if _, err = rr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create refresh_tokens: %w", err)
+	}
+
+	return nil
+}
+
+// Rotate marks the current session.RefreshToken as rotated and adds the next one in the same transaction.
+// The current token is only rotated if it is still usable so that only one of two concurrent rotations
+// succeeds, the other receives session.ErrTokenReused.
+func (rr *RefreshTokenRepository) Rotate(ctx context.Context, current, next *session.RefreshToken) error {
+	rotate, rotateArgs, err := rr.db.QB().
+		Update("refresh_tokens").
+		Set("rotated_at", time.Now()).
+		Where(qb.Eq{"uuid": current.ID(), "rotated_at": nil, "revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert refresh_tokens rotate query to SQL: %w", err)
+	}
+
+	insert, insertArgs, err := rr.insert(next).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert refresh_tokens create query to SQL: %w", err)
+	}
+
+	return rr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, rotate, rotateArgs...)
+		if err != nil {
+			return fmt.Errorf("unable to rotate refresh_tokens: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return session.ErrTokenReused
+		}
+
+		if _, err := tx.Exec(ctx, insert, insertArgs...); err != nil {
+			return fmt.Errorf("unable to create refresh_tokens: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RevokeFamily revokes every session.RefreshToken in the given family.
+func (rr *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return rr.revoke(ctx, qb.Eq{"family_uuid": familyID})
+}
+
+// RevokeCustomer revokes every session.RefreshToken belonging to the given customer.
+func (rr *RefreshTokenRepository) RevokeCustomer(ctx context.Context, customerID uuid.UUID) error {
+	return rr.revoke(ctx, qb.Eq{"customer_uuid": customerID})
+}
+
+// FindByHash searches the repository for a session.RefreshToken with the given hashed secret.
+// If none is found then nil is returned.
+func (rr *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*session.RefreshToken, error) {
+	var rec refreshTokenRecord
+
+	query := rr.db.QB().
+		Select(
+			"uuid as id",
+			"family_uuid as family_id",
+			"customer_uuid as customer_id",
+			"token_hash",
+			"expires_at",
+			"rotated_at",
+			"revoked_at",
+		).
+		From("refresh_tokens").
+		Where(qb.Eq{"token_hash": hash})
+
+	if err := rr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch refresh_tokens: %w", err)
+	}
+
+	return rec.toRefreshToken(), nil
+}
+
+func (rr *RefreshTokenRepository) insert(t *session.RefreshToken) qb.InsertBuilder {
+	return rr.db.QB().
+		Insert("refresh_tokens").
+		Columns("uuid", "family_uuid", "customer_uuid", "token_hash", "expires_at", "created_at").
+		Values(t.ID(), t.FamilyID(), t.CustomerID(), t.Hash(), t.ExpiresAt(), time.Now())
+}
+
+func (rr *RefreshTokenRepository) revoke(ctx context.Context, pred qb.Sqlizer) error {
+	query := rr.db.QB().
+		Update("refresh_tokens").
+		Set("revoked_at", time.Now()).
+		Where(pred).
+		Where(qb.Eq{"revoked_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert refresh_tokens revoke query to SQL: %w", err)
+	}
+
+	if _, err = rr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to revoke refresh_tokens: %w", err)
+	}
+
+	return nil
+}
+
+// refreshTokenExport is the personal data held about a refresh token. The token hash is left out as it is a
+// credential rather than information about the customer.
+type refreshTokenExport struct {
+	SessionID string     `json:"session_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// ExportPersonalData returns the refresh tokens issued to the customer with the given id.
+// If none are found then nil is returned.
+func (rr *RefreshTokenRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var recs []refreshTokenExport
+
+	columns := []string{"family_uuid AS session_id", "created_at", "expires_at", "rotated_at", "revoked_at"}
+	if err := exportRows(ctx, rr.db, &recs, "refresh_tokens", columns, customerID); err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	return recs, nil
+}
+
+// ErasePersonalData deletes the refresh tokens issued to the customer with the given id.
+func (rr *RefreshTokenRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	return eraseRows(ctx, rr.db, "refresh_tokens", customerID)
+}
+
+// refreshTokenRecord is the database representation of a session.RefreshToken.
+type refreshTokenRecord struct {
+	ID         string
+	FamilyID   string
+	CustomerID string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RotatedAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+func (rec refreshTokenRecord) toRefreshToken() *session.RefreshToken {
+	return session.Restore(session.State{
+		ID:         uuid.MustParse(rec.ID),
+		FamilyID:   uuid.MustParse(rec.FamilyID),
+		CustomerID: uuid.MustParse(rec.CustomerID),
+		Hash:       rec.TokenHash,
+		ExpiresAt:  rec.ExpiresAt,
+		RotatedAt:  rec.RotatedAt,
+		RevokedAt:  rec.RevokedAt,
+	})
+}
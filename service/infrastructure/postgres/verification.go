@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qb "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/verification"
+)
+
+// VerificationRepository gives us our datastore interaction for a verification.Token.
+type VerificationRepository struct {
+	db *app.DB
+}
+
+// NewVerificationRepository creates a new VerificationRepository with an encapsulated database connection.
+func NewVerificationRepository(db *app.DB) *VerificationRepository {
+	return &VerificationRepository{db}
+}
+
+// Add a new verification.Token to the repository.
+func (vr *VerificationRepository) Add(ctx context.Context, t *verification.Token) error {
+	query := vr.db.QB().
+		Insert("customer_verifications").
+		Columns("uuid", "customer_uuid", "username_key", "token_hash", "expires_at", "created_at").
+		Values(t.ID(), t.CustomerID(), t.UsernameKey(), t.Hash(), t.ExpiresAt(), t.IssuedAt())
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customer_verifications create query to SQL: %w", err)
+	}
+
+	if _, err = vr.db.Conn().Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("unable to create customer_verifications: %w", err)
+	}
+
+	return nil
+}
+
+// Verify marks the verification.Token as used and its customer as verified in the same transaction. The
+// token is only marked if it is still usable so that only one of two concurrent uses succeeds, the other
+// receives verification.ErrTokenUsed. Customers that are already verified keep their original verified_at.
+// The customer is only verified while they still have the username that the token was sent to.
+func (vr *VerificationRepository) Verify(ctx context.Context, t *verification.Token) error {
+	now := time.Now()
+
+	use, useArgs, err := vr.db.QB().
+		Update("customer_verifications").
+		Set("used_at", now).
+		Where(qb.Eq{"uuid": t.ID(), "used_at": nil}).
+		Where(qb.Gt{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customer_verifications use query to SQL: %w", err)
+	}
+
+	verify, verifyArgs, err := vr.db.QB().
+		Update("customers").
+		Set("verified_at", qb.Expr("COALESCE(verified_at, ?)", now)).
+		Set("updated_at", now).
+		Set("version", qb.Expr("version + 1")).
+		Where(qb.Eq{"uuid": t.CustomerID(), "username_key": t.UsernameKey(), "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to convert customers verify query to SQL: %w", err)
+	}
+
+	return vr.db.Transaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, use, useArgs...)
+		if err != nil {
+			return fmt.Errorf("unable to use customer_verifications: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return verification.ErrTokenUsed
+		}
+
+		if tag, err = tx.Exec(ctx, verify, verifyArgs...); err != nil {
+			return fmt.Errorf("unable to verify customers: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return customer.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// FindByHash searches the repository for a verification.Token with the given hashed secret.
+// If none is found then nil is returned.
+func (vr *VerificationRepository) FindByHash(ctx context.Context, hash string) (*verification.Token, error) {
+	return vr.findOne(ctx, vr.selectTokens().Where(qb.Eq{"token_hash": hash}))
+}
+
+// FindLatest searches the repository for the verification.Token most recently issued to the given customer.
+// If none is found then nil is returned.
+func (vr *VerificationRepository) FindLatest(ctx context.Context, customerID uuid.UUID) (*verification.Token, error) {
+	return vr.findOne(ctx, vr.selectTokens().
+		Where(qb.Eq{"customer_uuid": customerID}).
+		OrderBy("created_at DESC").
+		Limit(1),
+	)
+}
+
+func (vr *VerificationRepository) selectTokens() qb.SelectBuilder {
+	return vr.db.QB().
+		Select(
+			"uuid as id",
+			"customer_uuid as customer_id",
+			"username_key",
+			"token_hash",
+			"created_at",
+			"expires_at",
+			"used_at",
+		).
+		From("customer_verifications")
+}
+
+func (vr *VerificationRepository) findOne(ctx context.Context, query qb.SelectBuilder) (*verification.Token, error) {
+	var rec verificationRecord
+
+	if err := vr.db.Get(ctx, &rec, query); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to fetch customer_verifications: %w", err)
+	}
+
+	return rec.toToken(), nil
+}
+
+// ExportPersonalData returns the verification tokens issued to the customer with the given id.
+// If none are found then nil is returned.
+func (vr *VerificationRepository) ExportPersonalData(ctx context.Context, customerID uuid.UUID) (interface{}, error) {
+	var recs []tokenExport
+
+	columns := []string{"created_at", "expires_at", "used_at"}
+	if err := exportRows(ctx, vr.db, &recs, "customer_verifications", columns, customerID); err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	return recs, nil
+}
+
+// ErasePersonalData deletes the verification tokens issued to the customer with the given id.
+func (vr *VerificationRepository) ErasePersonalData(ctx context.Context, customerID uuid.UUID) error {
+	return eraseRows(ctx, vr.db, "customer_verifications", customerID)
+}
+
+// verificationRecord is the database representation of a verification.Token.
+type verificationRecord struct {
+	ID          string
+	CustomerID  string
+	UsernameKey string
+	TokenHash   string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+}
+
+func (rec verificationRecord) toToken() *verification.Token {
+	return verification.Restore(verification.State{
+		ID:          uuid.MustParse(rec.ID),
+		CustomerID:  uuid.MustParse(rec.CustomerID),
+		UsernameKey: rec.UsernameKey,
+		Hash:        rec.TokenHash,
+		IssuedAt:    rec.CreatedAt,
+		ExpiresAt:   rec.ExpiresAt,
+		UsedAt:      rec.UsedAt,
+	})
+}
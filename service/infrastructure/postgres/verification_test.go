@@ -0,0 +1,68 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/verification"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditingVerificationRepositoryRecordsInTheSameTransaction(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ctx := context.Background()
+	store := postgres.NewCustomerRepository(testEnv.DB())
+	tokens := postgres.NewVerificationRepository(testEnv.DB())
+	recorder := audit.NewRecorder(
+		postgres.NewAuditRepository(testEnv.DB()),
+		func(context.Context) (uuid.UUID, bool) { return uuid.UUID{}, false },
+		func(context.Context) string { return "" },
+	)
+
+	c, err := customer.New("verified@example.org", "Sup3rS3cr3t", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := store.Add(ctx, c); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	token, _, err := verification.New(c.ID(), c.Username(), time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create verification token: %v", err)
+	}
+
+	if err := tokens.Add(ctx, token); err != nil {
+		t.Fatalf("unable to add verification token: %v", err)
+	}
+
+	failing := customer.NewAuditingRepository(store, failingAuditor{recorder}, testEnv.DB())
+	assert.Error(t, verification.NewAuditingRepository(tokens, failing).Verify(ctx, token))
+
+	found, err := store.FindByID(ctx, c.ID())
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.False(t, found.IsVerified(), "the verification is rolled back when it can not be recorded")
+
+	recorded := customer.NewAuditingRepository(store, recorder, testEnv.DB())
+	if err := verification.NewAuditingRepository(tokens, recorded).Verify(ctx, token); err != nil {
+		t.Fatalf("unable to verify customer: %v", err)
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "audit_entries", map[string]string{
+		"action":      audit.ActionUpdate,
+		"resource_id": c.ID().String(),
+	})
+}
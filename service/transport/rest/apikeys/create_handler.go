@@ -0,0 +1,103 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var (
+	errScopesNotGranted = errors.New("must only contain permissions that you have been granted")
+	errExpiresInPast    = errors.New("must be in the future")
+)
+
+// NewCreateHandler creates a new handler that creates an api key for the authenticated customer. The key is
+// only included in this response, only its hash is stored. Creating keys requires an access token so that
+// a leaked key can not be used to create more keys.
+func NewCreateHandler(keys apikey.Repository, repo customer.Repository, j *app.JWT) rest.Handler {
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	type response struct {
+		keyResponse
+		Key string `json:"key"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/api-keys").Methods(http.MethodPost)
+		},
+		Middleware: rest.RequireJWTAuthentication(j),
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			customerID, _ := r.CustomerID()
+
+			cust, err := repo.FindByID(r.Context(), customerID)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Name, validation.Required, validation.Length(1, 255)),
+				validation.Field(&req.Scopes, validation.By(func(interface{}) error {
+					if !cust.HasPermissions(req.Scopes...) {
+						return errScopesNotGranted
+					}
+
+					return nil
+				})),
+				validation.Field(&req.ExpiresAt, validation.By(func(interface{}) error {
+					if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+						return errExpiresInPast
+					}
+
+					return nil
+				})),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			k, key, err := apikey.New(cust.ID(), req.Name, req.Scopes, req.ExpiresAt)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if err := keys.Add(r.Context(), k); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Respond(http.StatusCreated, response{keyResponse: newKeyResponse(k), Key: key})
+		},
+	}
+}
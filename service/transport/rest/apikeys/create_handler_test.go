@@ -0,0 +1,153 @@
+package apikeys_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/apikeys"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedCustomer inserts a customer and returns its id.
+func seedCustomer(t *testing.T, db *app.DB) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+
+	postgrestest.Insert(t, db, "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   id.String() + "@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	return id
+}
+
+func TestAPIKeyCreateHandler(t *testing.T) {
+	t.Parallel()
+
+	type payload map[string]interface{}
+
+	tests := []struct {
+		name   string
+		input  payload
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB, customerID uuid.UUID)
+	}{
+		{
+			name:  "create with empty payload returns error",
+			input: payload{},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB, _ uuid.UUID) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.name").Data().(string))
+			},
+		},
+		{
+			name:  "create with scopes the customer has not been granted returns error",
+			input: payload{"name": "partner", "scopes": []string{customer.PermissionManageRoles}},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB, _ uuid.UUID) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must only contain permissions that you have been granted",
+					data.Path("error.validation_errors.scopes").Data().(string),
+				)
+			},
+		},
+		{
+			name:  "create with an expiry in the past returns error",
+			input: payload{"name": "partner", "expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB, _ uuid.UUID) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "must be in the future", data.Path("error.validation_errors.expires_at").Data().(string))
+			},
+		},
+		{
+			name:  "create returns the key once and stores its hash",
+			input: payload{"name": "partner", "expires_at": time.Now().Add(time.Hour).Format(time.RFC3339)},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB, customerID uuid.UUID) {
+				assert.Equal(t, http.StatusCreated, resp.Code, data)
+
+				key := data.Path("key").Data().(string)
+				assert.True(t, strings.HasPrefix(key, apikey.Prefix), key)
+				assert.True(t, strings.HasPrefix(key, data.Path("hint").Data().(string)))
+				assert.Equal(t, "partner", data.Path("name").Data().(string))
+				assert.NotNil(t, data.Path("expires_at").Data())
+
+				postgrestest.AssertDatabaseHas(t, db, "api_keys", map[string]string{
+					"uuid":          data.Path("id").Data().(string),
+					"customer_uuid": customerID.String(),
+					"key_hash":      secret.Hash(key),
+				})
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+			customerID := seedCustomer(t, testEnv.DB())
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodPost,
+				"/api-keys",
+				apikeys.NewCreateHandler(
+					postgres.NewAPIKeyRepository(testEnv.DB()),
+					postgres.NewCustomerRepository(testEnv.DB()),
+					testEnv.JWT(),
+				),
+				tc.input,
+				resttest.AuthHeader(t, testEnv, customerID),
+				testEnv,
+			)
+
+			tc.assert(data, resp, testEnv.DB(), customerID)
+		})
+	}
+}
+
+func TestAPIKeyCreateHandlerWithScopes(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	customerID := seedCustomer(t, testEnv.DB())
+
+	if err := postgres.NewCustomerRepository(testEnv.DB()).GrantRole(context.Background(), customerID, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/api-keys",
+		apikeys.NewCreateHandler(
+			postgres.NewAPIKeyRepository(testEnv.DB()),
+			postgres.NewCustomerRepository(testEnv.DB()),
+			testEnv.JWT(),
+		),
+		map[string]interface{}{"name": "unlocker", "scopes": []string{customer.PermissionUnlockLogins}},
+		resttest.AuthHeader(t, testEnv, customerID),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusCreated, resp.Code, data)
+	assert.Equal(t, []interface{}{customer.PermissionUnlockLogins}, data.Path("scopes").Data())
+}
@@ -0,0 +1,74 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errCustomerNotFound = errors.New("customer not found")
+
+// keyResponse is the representation of an apikey.Key shared by the handlers. It never includes the key.
+type keyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newKeyResponse(k *apikey.Key) keyResponse {
+	scopes := k.Scopes()
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return keyResponse{
+		ID:         k.ID().String(),
+		Name:       k.Name(),
+		Hint:       k.Hint(),
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt(),
+		LastUsedAt: k.LastUsedAt(),
+		CreatedAt:  k.CreatedAt(),
+	}
+}
+
+// NewIndexHandler creates a new handler for listing the api keys of the authenticated customer that
+// have not been revoked.
+func NewIndexHandler(keys apikey.Repository, j *app.JWT) rest.Handler {
+	type response struct {
+		Data []keyResponse `json:"data"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/api-keys").Methods(http.MethodGet)
+		},
+		Middleware: rest.RequireJWTAuthentication(j),
+		Func: func(w rest.Responder, r rest.Request) {
+			customerID, _ := r.CustomerID()
+
+			list, err := keys.ListByCustomer(r.Context(), customerID)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp := response{Data: make([]keyResponse, 0, len(list))}
+			for _, k := range list {
+				resp.Data = append(resp.Data, newKeyResponse(k))
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package apikeys_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/apikeys"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyIndexHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewAPIKeyRepository(testEnv.DB())
+	customerID := seedCustomer(t, testEnv.DB())
+	otherID := seedCustomer(t, testEnv.DB())
+
+	keys := make(map[string]*apikey.Key)
+
+	for name, owner := range map[string]uuid.UUID{"mine": customerID, "revoked": customerID, "theirs": otherID} {
+		k, _, err := apikey.New(owner, name, nil, nil)
+		if err != nil {
+			t.Fatalf("unable to create api key: %v", err)
+		}
+
+		if err := repo.Add(context.Background(), k); err != nil {
+			t.Fatalf("unable to add api key: %v", err)
+		}
+
+		keys[name] = k
+	}
+
+	if err := repo.Revoke(context.Background(), customerID, keys["revoked"].ID()); err != nil {
+		t.Fatalf("unable to revoke api key: %v", err)
+	}
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/api-keys",
+		apikeys.NewIndexHandler(repo, testEnv.JWT()),
+		nil,
+		resttest.AuthHeader(t, testEnv, customerID),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+
+	listed, err := data.Path("data").Children()
+	if err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+
+	assert.Len(t, listed, 1)
+	assert.Equal(t, keys["mine"].ID().String(), listed[0].Path("id").Data().(string))
+	assert.Equal(t, keys["mine"].Hint(), listed[0].Path("hint").Data().(string))
+	assert.Nil(t, listed[0].Path("key").Data(), "keys must only be shown when they are created")
+}
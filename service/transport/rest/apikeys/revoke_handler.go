@@ -0,0 +1,49 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errInvalidID = errors.New("api key id must be a valid uuid")
+
+// NewRevokeHandler creates a new handler for revoking one of the api keys of the authenticated customer.
+// Revoked keys can no longer be used to authenticate.
+func NewRevokeHandler(keys apikey.Repository, j *app.JWT) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/api-keys/{id}").Methods(http.MethodDelete)
+		},
+		Middleware: rest.RequireJWTAuthentication(j),
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := uuid.Parse(mux.Vars(r.Request)["id"])
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, errInvalidID)
+
+				return
+			}
+
+			customerID, _ := r.CustomerID()
+
+			if err := keys.Revoke(r.Context(), customerID, id); err != nil {
+				if errors.Is(err, apikey.ErrNotFound) {
+					w.RespondError(http.StatusNotFound, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
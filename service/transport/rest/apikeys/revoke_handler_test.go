@@ -0,0 +1,62 @@
+package apikeys_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/apikeys"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRevokeHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewAPIKeyRepository(testEnv.DB())
+	customerID := seedCustomer(t, testEnv.DB())
+	otherID := seedCustomer(t, testEnv.DB())
+
+	k, key, err := apikey.New(customerID, "partner", nil, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), k); err != nil {
+		t.Fatalf("unable to add api key: %v", err)
+	}
+
+	revoke := func(url string, as uuid.UUID) int {
+		t.Helper()
+
+		_, resp := resttest.RequestWithHeaders(
+			t,
+			http.MethodDelete,
+			url,
+			apikeys.NewRevokeHandler(repo, testEnv.JWT()),
+			nil,
+			resttest.AuthHeader(t, testEnv, as),
+			testEnv,
+		)
+
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, revoke("/api-keys/not-a-uuid", customerID))
+	assert.Equal(t, http.StatusNotFound, revoke("/api-keys/"+k.ID().String(), otherID), "keys of others can not be revoked")
+	assert.Equal(t, http.StatusNoContent, revoke("/api-keys/"+k.ID().String(), customerID))
+	assert.Equal(t, http.StatusNotFound, revoke("/api-keys/"+k.ID().String(), customerID))
+
+	found, err := apikey.NewAuthenticator(repo).Authenticate(context.Background(), key, time.Now())
+	if err != nil {
+		t.Fatalf("unable to authenticate: %v", err)
+	}
+
+	assert.Nil(t, found, "revoked keys can not be used")
+}
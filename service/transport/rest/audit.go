@@ -0,0 +1,11 @@
+package rest
+
+import (
+	"github.com/nickbryan/go-template/service/domain/audit"
+)
+
+// NewAuditRecorder creates an audit.Recorder that records the authenticated customer as the actor and the
+// id of the request that performed each action.
+func NewAuditRecorder(repo audit.Repository) *audit.Recorder {
+	return audit.NewRecorder(repo, CustomerIDFromContext, RequestIDFromContext)
+}
@@ -0,0 +1,158 @@
+package audits
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errLimitOutOfRange = fmt.Errorf("must be between 1 and %d", audit.MaxListLimit)
+
+// NewIndexHandler creates a new handler for paginating through the audit log, newest first. Entries can be
+// filtered by the customer that performed them, the resource they were performed on and when they occurred.
+func NewIndexHandler(repo audit.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type request struct {
+		Limit        string `json:"limit"`
+		Cursor       string `json:"cursor"`
+		ActorID      string `json:"actor_id"`
+		ResourceType string `json:"resource_type"`
+		ResourceID   string `json:"resource_id"`
+		From         string `json:"from"`
+		To           string `json:"to"`
+	}
+
+	type entryResponse struct {
+		ID           string                  `json:"id"`
+		ActorID      *string                 `json:"actor_id"`
+		Action       string                  `json:"action"`
+		ResourceType string                  `json:"resource_type"`
+		ResourceID   string                  `json:"resource_id"`
+		Changes      map[string]audit.Change `json:"changes"`
+		RequestID    string                  `json:"request_id"`
+		OccurredAt   time.Time               `json:"occurred_at"`
+	}
+
+	type response struct {
+		Data       []entryResponse `json:"data"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	limitInRange := validation.By(func(value interface{}) error {
+		limit, err := strconv.Atoi(value.(string))
+		if err != nil || limit < 1 || limit > audit.MaxListLimit {
+			return errLimitOutOfRange
+		}
+
+		return nil
+	})
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/audit-entries").Methods(http.MethodGet)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionReadAuditLog},
+		Func: func(w rest.Responder, r rest.Request) {
+			q := r.URL.Query()
+
+			req := request{
+				Limit:        q.Get("limit"),
+				Cursor:       q.Get("cursor"),
+				ActorID:      q.Get("actor_id"),
+				ResourceType: q.Get("resource_type"),
+				ResourceID:   q.Get("resource_id"),
+				From:         q.Get("from"),
+				To:           q.Get("to"),
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Limit, is.Int, limitInRange),
+				validation.Field(&req.ActorID, is.UUID),
+				validation.Field(&req.ResourceType, validation.In(audit.ResourceCustomer, audit.ResourceSession)),
+				validation.Field(&req.From, validation.Date(time.RFC3339)),
+				validation.Field(&req.To, validation.Date(time.RFC3339)),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			f := audit.Filter{
+				Cursor:       req.Cursor,
+				ResourceType: req.ResourceType,
+				ResourceID:   req.ResourceID,
+			}
+
+			// The values have been validated above so we can safely ignore the conversion errors.
+			if req.Limit != "" {
+				f.Limit, _ = strconv.Atoi(req.Limit)
+			}
+
+			if req.ActorID != "" {
+				actorID := uuid.MustParse(req.ActorID)
+				f.ActorID = &actorID
+			}
+
+			if req.From != "" {
+				from, _ := time.Parse(time.RFC3339, req.From)
+				f.From = &from
+			}
+
+			if req.To != "" {
+				to, _ := time.Parse(time.RFC3339, req.To)
+				f.To = &to
+			}
+
+			page, err := repo.List(r.Context(), f)
+			if err != nil {
+				if errors.Is(err, audit.ErrInvalidCursor) {
+					w.RespondError(http.StatusBadRequest, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp := response{
+				Data:       make([]entryResponse, 0, len(page.Entries)),
+				NextCursor: page.NextCursor,
+			}
+
+			for _, e := range page.Entries {
+				entry := entryResponse{
+					ID:           e.ID().String(),
+					Action:       e.Action(),
+					ResourceType: e.ResourceType(),
+					ResourceID:   e.ResourceID(),
+					Changes:      e.Changes(),
+					RequestID:    e.RequestID(),
+					OccurredAt:   e.OccurredAt(),
+				}
+
+				if e.ActorID() != nil {
+					actorID := e.ActorID().String()
+					entry.ActorID = &actorID
+				}
+
+				resp.Data = append(resp.Data, entry)
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package audits_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/audits"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ctx := context.Background()
+	repo := postgres.NewAuditRepository(testEnv.DB())
+	keys := apikey.NewAuthenticator(postgres.NewAPIKeyRepository(testEnv.DB()))
+	adminID, customerID := uuid.New(), uuid.New()
+
+	for id, username := range map[uuid.UUID]string{adminID: "admin@example.org", customerID: "customer@example.org"} {
+		postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+			"uuid":       id,
+			"username":   username,
+			"password":   "abc123",
+			"created_at": time.Now(),
+			"updated_at": time.Now(),
+		})
+	}
+
+	if err := postgres.NewCustomerRepository(testEnv.DB()).GrantRole(ctx, adminID, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	for _, e := range []*audit.Entry{
+		audit.NewEntry(nil, audit.ActionCreate, audit.ResourceCustomer, customerID.String(), map[string]audit.Change{
+			"username": {To: "customer@example.org"},
+		}, "req-1"),
+		audit.NewEntry(&customerID, audit.ActionLogin, audit.ResourceSession, uuid.New().String(), nil, "req-2"),
+		audit.NewEntry(&adminID, audit.ActionDelete, audit.ResourceCustomer, customerID.String(), nil, "req-3"),
+	} {
+		if err := repo.Add(ctx, e); err != nil {
+			t.Fatalf("unable to add audit entry: %v", err)
+		}
+	}
+
+	index := func(as uuid.UUID, query string) (*gabs.Container, int) {
+		t.Helper()
+
+		data, resp := resttest.RequestWithHeaders(
+			t,
+			http.MethodGet,
+			"/audit-entries"+query,
+			audits.NewIndexHandler(repo, testEnv.JWT(), keys),
+			nil,
+			resttest.AuthHeader(t, testEnv, as),
+			testEnv,
+		)
+
+		return data, resp.Code
+	}
+
+	_, code := index(customerID, "")
+	assert.Equal(t, http.StatusForbidden, code, "only customers granted the permission can read the audit log")
+
+	data, code := index(adminID, "?limit=0&actor_id=nope&from=yesterday")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "must be between 1 and 100", data.Path("error.validation_errors.limit").Data().(string))
+	assert.True(t, data.Exists("error", "validation_errors", "actor_id"))
+	assert.True(t, data.Exists("error", "validation_errors", "from"))
+
+	_, code = index(adminID, "?cursor=not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	first, code := index(adminID, "?limit=2")
+	assert.Equal(t, http.StatusOK, code, first)
+	assert.Equal(t, audit.ActionDelete, first.Path("data").Index(0).Path("action").Data().(string))
+	assert.Equal(t, adminID.String(), first.Path("data").Index(0).Path("actor_id").Data().(string))
+	assert.Equal(t, "req-3", first.Path("data").Index(0).Path("request_id").Data().(string))
+	assert.Equal(t, audit.ActionLogin, first.Path("data").Index(1).Path("action").Data().(string))
+
+	second, code := index(adminID, "?limit=2&cursor="+first.Path("next_cursor").Data().(string))
+	assert.Equal(t, http.StatusOK, code, second)
+	assert.Equal(t, audit.ActionCreate, second.Path("data").Index(0).Path("action").Data().(string))
+	assert.Nil(t, second.Path("data").Index(0).Path("actor_id").Data())
+	assert.Equal(
+		t,
+		"customer@example.org",
+		second.Path("data").Index(0).Path("changes.username.to").Data().(string),
+	)
+	assert.False(t, second.Exists("next_cursor"))
+
+	count := func(query string) int {
+		t.Helper()
+
+		data, code := index(adminID, query)
+		assert.Equal(t, http.StatusOK, code, data)
+
+		children, _ := data.Path("data").Children()
+
+		return len(children)
+	}
+
+	assert.Equal(t, 2, count("?resource_type=customer&resource_id="+customerID.String()))
+	assert.Equal(t, 1, count("?actor_id="+customerID.String()))
+	assert.Equal(t, 0, count("?from="+url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))))
+}
@@ -1 +1,258 @@
 package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+)
+
+// The authentication schemes accepted in the Authorization header.
+const (
+	schemeBearer = "Bearer"
+	schemeAPIKey = "ApiKey"
+)
+
+var (
+	errMissingToken       = errors.New("authorization header must contain a bearer token")
+	errMissingAPIKey      = errors.New("authorization header must contain an api key")
+	errMissingCredentials = errors.New("authorization header must contain a bearer token or an api key")
+	errNotAuthed          = errors.New("authentication is required to access this resource")
+	errInvalidToken       = errors.New("access token is invalid or has expired")
+	errInvalidAPIKey      = errors.New("api key is invalid, expired or revoked")
+	errNotPermitted       = errors.New("you do not have permission to access this resource")
+)
+
+type contextKey int
+
+const (
+	customerIDKey contextKey = iota
+	scopesKey
+	requestIDKey
+	clientIPKey
+)
+
+// WithCustomerID returns a copy of the context holding the id of the authenticated customer.
+func WithCustomerID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, customerIDKey, id)
+}
+
+// CustomerIDFromContext returns the id of the authenticated customer. The bool will be false
+// if the request was not authenticated.
+func CustomerIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(customerIDKey).(uuid.UUID)
+
+	return id, ok
+}
+
+// RequireJWTAuthentication only allows requests carrying a valid access token in their Authorization
+// header through to the next ServiceFunc. The id of the authenticated customer is added to the request
+// context and can be retrieved with Request.CustomerID.
+func RequireJWTAuthentication(j *app.JWT) func(next ServiceFunc) ServiceFunc {
+	return func(next ServiceFunc) ServiceFunc {
+		return func(w Responder, r Request) {
+			token, ok := credentials(r, schemeBearer)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", schemeBearer)
+				w.RespondError(http.StatusUnauthorized, errMissingToken)
+
+				return
+			}
+
+			serveJWT(j, token, next, w, r)
+		}
+	}
+}
+
+// RequireAPIKeyAuthentication only allows requests carrying a usable api key in their Authorization header,
+// in the form "ApiKey <key>", through to the next ServiceFunc. The id of the customer that owns the key is
+// added to the request context and can be retrieved with Request.CustomerID.
+func RequireAPIKeyAuthentication(keys *apikey.Authenticator) func(next ServiceFunc) ServiceFunc {
+	return func(next ServiceFunc) ServiceFunc {
+		return func(w Responder, r Request) {
+			key, ok := credentials(r, schemeAPIKey)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", schemeAPIKey)
+				w.RespondError(http.StatusUnauthorized, errMissingAPIKey)
+
+				return
+			}
+
+			serveAPIKey(keys, key, next, w, r)
+		}
+	}
+}
+
+// RequireAuthentication allows requests authenticated by either RequireJWTAuthentication or
+// RequireAPIKeyAuthentication through to the next ServiceFunc. It is used by handlers that machine clients
+// should be able to call as well as customers that have logged in.
+func RequireAuthentication(j *app.JWT, keys *apikey.Authenticator) func(next ServiceFunc) ServiceFunc {
+	return func(next ServiceFunc) ServiceFunc {
+		return func(w Responder, r Request) {
+			if token, ok := credentials(r, schemeBearer); ok {
+				serveJWT(j, token, next, w, r)
+
+				return
+			}
+
+			if key, ok := credentials(r, schemeAPIKey); ok {
+				serveAPIKey(keys, key, next, w, r)
+
+				return
+			}
+
+			w.Header().Add("WWW-Authenticate", schemeBearer)
+			w.Header().Add("WWW-Authenticate", schemeAPIKey)
+			w.RespondError(http.StatusUnauthorized, errMissingCredentials)
+		}
+	}
+}
+
+func serveJWT(j *app.JWT, token string, next ServiceFunc, w Responder, r Request) {
+	claims, err := j.Verify(token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.RespondError(http.StatusUnauthorized, errInvalidToken)
+
+		return
+	}
+
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.RespondError(http.StatusUnauthorized, errInvalidToken)
+
+		return
+	}
+
+	next(w, r.withContext(WithCustomerID(r.Context(), id)))
+}
+
+func serveAPIKey(keys *apikey.Authenticator, key string, next ServiceFunc, w Responder, r Request) {
+	k, err := keys.Authenticate(r.Context(), key, time.Now())
+	if err != nil {
+		w.RespondInternalError(err)
+
+		return
+	}
+
+	if k == nil {
+		w.Header().Set("WWW-Authenticate", `ApiKey error="invalid_key"`)
+		w.RespondError(http.StatusUnauthorized, errInvalidAPIKey)
+
+		return
+	}
+
+	ctx := WithCustomerID(r.Context(), k.CustomerID())
+	if len(k.Scopes()) > 0 {
+		ctx = context.WithValue(ctx, scopesKey, k.Scopes())
+	}
+
+	next(w, r.withContext(ctx))
+}
+
+// Authorizer decides whether an authenticated customer has been granted permissions.
+type Authorizer interface {
+	// Authorize returns true if the customer has every one of the permissions.
+	Authorize(ctx context.Context, customerID uuid.UUID, permissions []string) (bool, error)
+}
+
+// requirePermissions only allows requests from customers with every one of the Permissions of the Handler, or
+// from the Owner of the requested resource, through to the next ServiceFunc. The request must have been
+// authenticated by an earlier middleware. Requests are denied if there is no Authorizer so that a misconfigured
+// server fails closed.
+func requirePermissions(a Authorizer, h Handler) func(next ServiceFunc) ServiceFunc {
+	permissions := h.Permissions
+
+	return func(next ServiceFunc) ServiceFunc {
+		return func(w Responder, r Request) {
+			id, ok := r.CustomerID()
+			if !ok {
+				w.RespondError(http.StatusUnauthorized, errNotAuthed)
+
+				return
+			}
+
+			scopes, scoped := r.Context().Value(scopesKey).([]string)
+
+			if h.Owner != nil {
+				ownerID, err := h.Owner(r)
+				if err != nil {
+					w.RespondError(http.StatusBadRequest, err)
+
+					return
+				}
+
+				// API keys restricted to scopes only grant those permissions so they are never treated as the owner.
+				if ownerID == id && !scoped {
+					next(w, r)
+
+					return
+				}
+			}
+
+			if a == nil {
+				w.RespondError(http.StatusForbidden, errNotPermitted)
+
+				return
+			}
+
+			permitted, err := a.Authorize(r.Context(), id, permissions)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			// API keys can be restricted to some of the permissions of their customer.
+			if scoped {
+				permitted = permitted && containsAll(scopes, permissions)
+			}
+
+			if !permitted {
+				w.RespondError(http.StatusForbidden, errNotPermitted)
+
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// credentials returns the credentials following the scheme in the Authorization header of the request.
+func credentials(r Request, scheme string) (string, bool) {
+	prefix := scheme + " "
+
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+func containsAll(values, required []string) bool {
+	for _, req := range required {
+		found := false
+
+		for _, v := range values {
+			if v == req {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"go.uber.org/zap"
+)
+
+var (
+	// errInvalidCredentials is deliberately vague so that we do not reveal which usernames exist.
+	errInvalidCredentials = errors.New("invalid username or password")
+	// errTooManyAttempts is the same for every username for the same reason.
+	errTooManyAttempts = errors.New("too many failed login attempts, try again later")
+)
+
+// NewLoginHandler creates a new handler that exchanges a customers credentials for an access token and
+// a refresh token that starts a new session. Passwords that were hashed with an outdated algorithm or
+// parameters are rehashed while we have the plain text password. Failing to rehash does not fail the login.
+// Failed attempts are throttled by the guard before the username is looked up so that throttled and
+// locked responses are the same whether or not the account exists. Successful and failed logins are recorded
+// in the audit log without the username that was tried.
+func NewLoginHandler(
+	repo customer.Repository,
+	hasher customer.PasswordHasher,
+	guard *lockout.Guard,
+	sessions session.Repository,
+	j *app.JWT,
+	refreshTTL time.Duration,
+	auditor *audit.Recorder,
+) rest.Handler {
+	type request struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/login").Methods(http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Username, validation.Required),
+				validation.Field(&req.Password, validation.Required),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			wait, err := guard.Check(r.Context(), req.Username, r.ClientIP(), time.Now())
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				w.RespondError(http.StatusTooManyRequests, errTooManyAttempts)
+
+				return
+			}
+
+			cust, err := repo.FindByUsername(r.Context(), req.Username)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil || !cust.HasPassword(req.Password, hasher) {
+				if err := guard.Fail(r.Context(), req.Username, r.ClientIP(), time.Now()); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+
+				if err := recordLoginFailed(r, auditor, cust); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+
+				w.RespondError(http.StatusUnauthorized, errInvalidCredentials)
+
+				return
+			}
+
+			if err := guard.Succeed(r.Context(), req.Username); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust.PasswordNeedsRehash(hasher) {
+				if err := rehashPassword(r, repo, hasher, cust, req.Password); err != nil {
+					r.Logger().Warn("unable to rehash password", zap.Error(err), zap.String("customer", cust.ID().String()))
+				}
+			}
+
+			refresh, refreshSecret, err := session.New(cust.ID(), refreshTTL)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if err := sessions.Add(r.Context(), refresh); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			// The request is not authenticated yet so the customer logging in is recorded as the actor.
+			if err := auditor.Record(
+				rest.WithCustomerID(r.Context(), cust.ID()),
+				audit.ActionLogin,
+				audit.ResourceSession,
+				refresh.FamilyID().String(),
+				nil,
+			); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp, err := newTokenResponse(j, refresh, refreshSecret)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
+
+// recordLoginFailed records a failed login against the customer, who is nil if the username does not exist.
+func recordLoginFailed(r rest.Request, auditor *audit.Recorder, cust *customer.Customer) error {
+	var customerID string
+	if cust != nil {
+		customerID = cust.ID().String()
+	}
+
+	return auditor.Record(r.Context(), audit.ActionLoginFailed, audit.ResourceCustomer, customerID, nil)
+}
+
+// rehashPassword replaces the stored password hash of the customer with one made by the preferred algorithm.
+func rehashPassword(
+	r rest.Request,
+	repo customer.Repository,
+	hasher customer.PasswordHasher,
+	cust *customer.Customer,
+	password string,
+) error {
+	previous := cust.PasswordHash()
+
+	if err := cust.RehashPassword(password, hasher); err != nil {
+		return err
+	}
+
+	return repo.UpdatePasswordHash(r.Context(), cust, previous)
+}
@@ -0,0 +1,261 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/password"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLockoutPolicy throttles after a few failures with delays long enough not to pass during a test.
+var testLockoutPolicy = lockout.Policy{ //nolint:gochecknoglobals
+	DelayAfter:   3,
+	BaseDelay:    time.Minute,
+	MaxDelay:     time.Hour,
+	LockAfter:    5,
+	LockDuration: time.Hour,
+	ResetAfter:   time.Hour,
+}
+
+func newLoginHandler(e *app.Environment, repo customer.Repository, hasher customer.PasswordHasher) rest.Handler {
+	return auth.NewLoginHandler(
+		repo,
+		hasher,
+		lockout.NewGuard(postgres.NewLockoutRepository(e.DB()), testLockoutPolicy, testLockoutPolicy),
+		postgres.NewRefreshTokenRepository(e.DB()),
+		e.JWT(),
+		time.Hour,
+		newAuditor(e),
+	)
+}
+
+func newAuditor(e *app.Environment) *audit.Recorder {
+	return rest.NewAuditRecorder(postgres.NewAuditRepository(e.DB()))
+}
+
+func TestLoginHandler(t *testing.T) {
+	t.Parallel()
+
+	type payload map[string]interface{}
+
+	tests := []struct {
+		name   string
+		input  payload
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, e *app.Environment, cust *customer.Customer)
+	}{
+		{
+			name:  "login with empty payload returns error",
+			input: payload{},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.Environment, _ *customer.Customer) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.username").Data().(string))
+				assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.password").Data().(string))
+			},
+		},
+		{
+			name:  "login with unknown username returns unauthorized",
+			input: payload{"username": "unknown@example.org", "password": "S3cr3tP4ss"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, e *app.Environment, _ *customer.Customer) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+				assert.Equal(t, "invalid username or password", data.Path("error.message").Data().(string))
+				postgrestest.AssertDatabaseHas(t, e.DB(), "audit_entries", map[string]string{
+					"action":        audit.ActionLoginFailed,
+					"resource_type": audit.ResourceCustomer,
+					"resource_id":   "",
+				})
+			},
+		},
+		{
+			name:  "login with wrong password returns unauthorized",
+			input: payload{"username": "existing@example.org", "password": "wrong-password"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, e *app.Environment, cust *customer.Customer) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+				assert.Equal(t, "invalid username or password", data.Path("error.message").Data().(string))
+				postgrestest.AssertDatabaseHas(t, e.DB(), "audit_entries", map[string]string{
+					"action":        audit.ActionLoginFailed,
+					"resource_type": audit.ResourceCustomer,
+					"resource_id":   cust.ID().String(),
+				})
+			},
+		},
+		{
+			name:  "login with valid credentials returns an access token",
+			input: payload{"username": "existing@example.org", "password": "S3cr3tP4ss"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, e *app.Environment, cust *customer.Customer) {
+				assert.Equal(t, http.StatusOK, resp.Code, data)
+				assert.Equal(t, "Bearer", data.Path("token_type").Data().(string))
+				assert.Equal(t, e.JWT().TTL().Seconds(), data.Path("expires_in").Data().(float64))
+
+				claims, err := e.JWT().Verify(data.Path("access_token").Data().(string))
+				if err != nil {
+					t.Fatalf("unable to verify access token: %v", err)
+				}
+
+				assert.Equal(t, cust.ID().String(), claims.Subject)
+
+				refresh := data.Path("refresh_token").Data().(string)
+				postgrestest.AssertDatabaseHas(t, e.DB(), "refresh_tokens", map[string]string{
+					"customer_uuid": cust.ID().String(),
+					"token_hash":    secret.Hash(refresh),
+				})
+				postgrestest.AssertDatabaseHas(t, e.DB(), "audit_entries", map[string]string{
+					"actor_uuid": cust.ID().String(),
+					"action":     audit.ActionLogin,
+				})
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+			repo := postgres.NewCustomerRepository(testEnv.DB())
+
+			cust, err := customer.New("existing@example.org", "S3cr3tP4ss", testEnv.PasswordHasher())
+			if err != nil {
+				t.Fatalf("unable to create customer: %v", err)
+			}
+
+			if err := repo.Add(context.Background(), cust); err != nil {
+				t.Fatalf("unable to add customer: %v", err)
+			}
+
+			data, resp := resttest.RequestWithData(
+				t,
+				http.MethodPost,
+				"/auth/login",
+				newLoginHandler(testEnv, repo, testEnv.PasswordHasher()),
+				tc.input,
+				testEnv,
+			)
+
+			tc.assert(data, resp, testEnv, cust)
+		})
+	}
+}
+
+func TestLoginHandlerRehashesOutdatedPasswords(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+
+	// The customer registered when passwords were hashed with bcrypt.
+	legacy := password.NewHasher(password.Bcrypt{Cost: 4})
+
+	cust, err := customer.New("legacy@example.org", "S3cr3tP4ss", legacy)
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), cust); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	hasher := testEnv.PasswordHasher()
+	assert.True(t, cust.PasswordNeedsRehash(hasher))
+
+	data, resp := resttest.RequestWithData(
+		t,
+		http.MethodPost,
+		"/auth/login",
+		newLoginHandler(testEnv, repo, hasher),
+		map[string]string{"username": "legacy@example.org", "password": "S3cr3tP4ss"},
+		testEnv,
+	)
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+
+	rehashed, err := repo.FindByID(context.Background(), cust.ID())
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.NotEqual(t, cust.PasswordHash(), rehashed.PasswordHash())
+	assert.False(t, rehashed.PasswordNeedsRehash(hasher))
+	assert.True(t, rehashed.HasPassword("S3cr3tP4ss", hasher))
+	assert.Equal(t, cust.Version(), rehashed.Version(), "rehashing must not conflict with concurrent updates")
+}
+
+func TestLoginHandlerThrottlesFailedAttempts(t *testing.T) {
+	t.Parallel()
+
+	login := func(t *testing.T, e *app.Environment, h rest.Handler, username, pw string) (*gabs.Container, *httptest.ResponseRecorder) {
+		t.Helper()
+
+		return resttest.RequestWithData(
+			t,
+			http.MethodPost,
+			"/auth/login",
+			h,
+			map[string]string{"username": username, "password": pw},
+			e,
+		)
+	}
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+
+	cust, err := customer.New("existing@example.org", "S3cr3tP4ss", testEnv.PasswordHasher())
+	if err != nil {
+		t.Fatalf("unable to create customer: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), cust); err != nil {
+		t.Fatalf("unable to add customer: %v", err)
+	}
+
+	h := newLoginHandler(testEnv, repo, testEnv.PasswordHasher())
+
+	for _, username := range []string{"existing@example.org", "unknown@example.org"} {
+		for i := 0; i < testLockoutPolicy.DelayAfter; i++ {
+			_, resp := login(t, testEnv, h, username, "wrong-password")
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		}
+
+		// Even the correct password is refused so that guessing can not continue.
+		data, resp := login(t, testEnv, h, username, "S3cr3tP4ss")
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code, data)
+		assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+		assert.Equal(
+			t,
+			"too many failed login attempts, try again later",
+			data.Path("error.message").Data().(string),
+			"locked responses must not reveal whether the account exists",
+		)
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "login_failures", map[string]string{
+		"scope":    lockout.ScopeUsername,
+		"key":      "existing@example.org",
+		"failures": "3",
+	})
+
+	if err := lockout.NewGuard(
+		postgres.NewLockoutRepository(testEnv.DB()),
+		testLockoutPolicy,
+		testLockoutPolicy,
+	).Unlock(context.Background(), "existing@example.org", ""); err != nil {
+		t.Fatalf("unable to unlock username: %v", err)
+	}
+
+	data, resp := login(t, testEnv, h, "existing@example.org", "S3cr3tP4ss")
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewLogoutHandler creates a new handler that ends the session the given refresh token belongs to by
+// revoking its whole family. Holding the refresh token is enough to end its session so that customers
+// can logout after their access token has expired. Access tokens that have already been issued remain
+// valid until they expire. The logout is recorded in the audit log against the customer the session belongs to.
+func NewLogoutHandler(sessions session.Repository, auditor *audit.Recorder) rest.Handler {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/logout").Methods(http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.RefreshToken, validation.Required),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			t, err := sessions.FindByHash(r.Context(), secret.Hash(req.RefreshToken))
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			// Logging out of a session that does not exist has the same outcome as logging out of one that does.
+			if t != nil {
+				if err := sessions.RevokeFamily(r.Context(), t.FamilyID()); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+
+				if err := auditor.Record(
+					rest.WithCustomerID(r.Context(), t.CustomerID()),
+					audit.ActionLogout,
+					audit.ResourceSession,
+					t.FamilyID().String(),
+					nil,
+				); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
+
+// NewLogoutEverywhereHandler creates a new handler that ends every session of the authenticated customer and
+// records it in the audit log.
+func NewLogoutEverywhereHandler(sessions session.Repository, j *app.JWT, auditor *audit.Recorder) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/logout-everywhere").Methods(http.MethodPost)
+		},
+		Middleware: rest.RequireJWTAuthentication(j),
+		Func: func(w rest.Responder, r rest.Request) {
+			customerID, _ := r.CustomerID()
+
+			if err := sessions.RevokeCustomer(r.Context(), customerID); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			err := auditor.Record(r.Context(), audit.ActionLogoutEverywhere, audit.ResourceCustomer, customerID.String(), nil)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/audit"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogoutHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewRefreshTokenRepository(testEnv.DB())
+	customerID := seedCustomer(t, testEnv.DB(), nil)
+
+	current := startSession(t, repo, customerID, time.Hour)
+	other := startSession(t, repo, customerID, time.Hour)
+
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/auth/logout", auth.NewLogoutHandler(repo, newAuditor(testEnv)), map[string]string{
+		"refresh_token": current,
+	}, testEnv)
+
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+	assert.NotNil(t, findToken(t, repo, current).RevokedAt())
+	assert.Nil(t, findToken(t, repo, other).RevokedAt())
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "audit_entries", map[string]string{
+		"actor_uuid":  customerID.String(),
+		"action":      audit.ActionLogout,
+		"resource_id": findToken(t, repo, current).FamilyID().String(),
+	})
+
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/logout", auth.NewLogoutHandler(repo, newAuditor(testEnv)), map[string]string{
+		"refresh_token": "unknown",
+	}, testEnv)
+
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+}
+
+func TestLogoutEverywhereHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewRefreshTokenRepository(testEnv.DB())
+	customerID := seedCustomer(t, testEnv.DB(), nil)
+	otherCustomerID := seedCustomer(t, testEnv.DB(), nil)
+
+	first := startSession(t, repo, customerID, time.Hour)
+	second := startSession(t, repo, customerID, time.Hour)
+	others := startSession(t, repo, otherCustomerID, time.Hour)
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/auth/logout-everywhere",
+		auth.NewLogoutEverywhereHandler(repo, testEnv.JWT(), newAuditor(testEnv)),
+		nil,
+		resttest.AuthHeader(t, testEnv, customerID),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+	assert.NotNil(t, findToken(t, repo, first).RevokedAt())
+	assert.NotNil(t, findToken(t, repo, second).RevokedAt())
+	assert.Nil(t, findToken(t, repo, others).RevokedAt())
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "audit_entries", map[string]string{
+		"actor_uuid":  customerID.String(),
+		"action":      audit.ActionLogoutEverywhere,
+		"resource_id": customerID.String(),
+	})
+}
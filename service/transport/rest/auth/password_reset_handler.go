@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/mail"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/passwordreset"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"go.uber.org/zap"
+)
+
+// errInvalidResetToken covers unknown, expired and used password reset tokens alike.
+var errInvalidResetToken = errors.New("password reset token is invalid or has expired")
+
+// NewPasswordResetHandler creates a new handler that emails a password reset token to the customer with
+// the given username. The response is the same whether or not the username exists so that it can not
+// be used to discover which customers have an account. For the same reason failures after the lookup are
+// logged rather than returned.
+func NewPasswordResetHandler(
+	repo customer.Repository,
+	resets passwordreset.Repository,
+	mailer mail.Mailer,
+	ttl time.Duration,
+) rest.Handler {
+	type request struct {
+		Username string `json:"username"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/password-reset").Methods(http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Username, validation.Required, is.Email),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			cust, err := repo.FindByUsername(r.Context(), req.Username)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust != nil {
+				if err := sendPasswordReset(r, cust, resets, mailer, ttl); err != nil {
+					r.Logger().Error("unable to send password reset", zap.Error(err))
+				}
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+		},
+	}
+}
+
+func sendPasswordReset(
+	r rest.Request,
+	cust *customer.Customer,
+	resets passwordreset.Repository,
+	mailer mail.Mailer,
+	ttl time.Duration,
+) error {
+	token, s, err := passwordreset.New(cust.ID(), ttl)
+	if err != nil {
+		return err
+	}
+
+	if err := resets.Add(r.Context(), token); err != nil {
+		return err
+	}
+
+	return mailer.Send(r.Context(), mail.Message{
+		To:      cust.Username(),
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"We received a request to reset your password. Use the following token to choose a new one:\n\n%s\n\n"+
+				"The token expires in %s. If you did not request a password reset you can ignore this email.",
+			s,
+			ttl,
+		),
+	})
+}
+
+// NewPasswordResetConfirmHandler creates a new handler that exchanges a password reset token for a new
+// password. The token can only be used once. Every session of the customer is ended as whoever started
+// them may not know the new password. The token is used up in the same transaction that saves the new
+// password so that a failure part way through leaves the token usable.
+func NewPasswordResetConfirmHandler(
+	repo customer.Repository,
+	hasher customer.PasswordHasher,
+	resets passwordreset.Repository,
+	sessions session.Repository,
+	transactor customer.Transactor,
+) rest.Handler {
+	type request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/password-reset/confirm").Methods(http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Token, validation.Required),
+				validation.Field(
+					&req.Password,
+					validation.Required,
+					validation.RuneLength(customer.MinPasswordLength, customer.MaxPasswordLength),
+				),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			token, err := resets.FindByHash(r.Context(), secret.Hash(req.Token))
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if token == nil || !token.IsUsable(time.Now()) {
+				w.RespondError(http.StatusBadRequest, errInvalidResetToken)
+
+				return
+			}
+
+			// The customer may have been deleted since the token was sent.
+			cust, err := repo.FindByID(r.Context(), token.CustomerID())
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				w.RespondError(http.StatusBadRequest, errInvalidResetToken)
+
+				return
+			}
+
+			if err := cust.ChangePassword(req.Password, hasher); err != nil {
+				if errors.Is(err, customer.ErrPasswordLength) {
+					w.RespondError(http.StatusUnprocessableEntity, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			err = transactor.InTransaction(r.Context(), func(ctx context.Context) error {
+				if err := resets.Use(ctx, token); err != nil {
+					return err
+				}
+
+				if _, err := repo.Update(ctx, cust); err != nil {
+					return err
+				}
+
+				return sessions.RevokeCustomer(ctx, cust.ID())
+			})
+			if err != nil {
+				if errors.Is(err, passwordreset.ErrTokenUsed) {
+					w.RespondError(http.StatusBadRequest, errInvalidResetToken)
+
+					return
+				}
+
+				if errors.Is(err, customer.ErrVersionConflict) {
+					w.RespondError(http.StatusConflict, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
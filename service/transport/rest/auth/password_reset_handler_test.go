@@ -0,0 +1,183 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/mail/mailtest"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/passwordreset"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// requestPasswordReset adds a password reset token for the customer and returns its secret.
+func requestPasswordReset(t *testing.T, repo passwordreset.Repository, customerID uuid.UUID, ttl time.Duration) string {
+	t.Helper()
+
+	token, s, err := passwordreset.New(customerID, ttl)
+	if err != nil {
+		t.Fatalf("unable to create password reset token: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), token); err != nil {
+		t.Fatalf("unable to add password reset token: %v", err)
+	}
+
+	return s
+}
+
+func TestPasswordResetHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	resets := postgres.NewPasswordResetRepository(testEnv.DB())
+	mailer := &mailtest.Mailer{}
+	customerID := seedCustomer(t, testEnv.DB(), nil)
+	username := customerID.String() + "@example.org"
+
+	handler := auth.NewPasswordResetHandler(
+		postgres.NewCustomerRepository(testEnv.DB()),
+		resets,
+		mailer,
+		time.Hour,
+	)
+
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset", handler, map[string]string{
+		"username": "not-an-email",
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+
+	unknownData, unknownResp := resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset", handler, map[string]string{
+		"username": "unknown@example.org",
+	}, testEnv)
+	assert.Empty(t, mailer.Messages())
+
+	knownData, knownResp := resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset", handler, map[string]string{
+		"username": username,
+	}, testEnv)
+
+	// The response must not reveal whether the username exists.
+	assert.Equal(t, http.StatusAccepted, knownResp.Code, knownData)
+	assert.Equal(t, unknownResp.Code, knownResp.Code)
+	assert.Equal(t, unknownData, knownData)
+	assert.Equal(t, unknownResp.Body.String(), knownResp.Body.String())
+
+	messages := mailer.Messages()
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+
+	assert.Equal(t, username, messages[0].To)
+
+	var token *passwordreset.Token
+
+	for _, line := range strings.Split(messages[0].Body, "\n") {
+		if token, _ = resets.FindByHash(context.Background(), secret.Hash(line)); token != nil {
+			break
+		}
+	}
+
+	if assert.NotNil(t, token, "the email does not contain a stored token") {
+		assert.Equal(t, customerID, token.CustomerID())
+		assert.True(t, token.IsUsable(time.Now()))
+	}
+}
+
+func TestPasswordResetConfirmHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	customers := postgres.NewCustomerRepository(testEnv.DB())
+	resets := postgres.NewPasswordResetRepository(testEnv.DB())
+	sessions := postgres.NewRefreshTokenRepository(testEnv.DB())
+	handler := auth.NewPasswordResetConfirmHandler(customers, testEnv.PasswordHasher(), resets, sessions, testEnv.DB())
+
+	customerID := seedCustomer(t, testEnv.DB(), nil)
+	refresh := startSession(t, sessions, customerID, time.Hour)
+
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset/confirm", handler, map[string]string{
+		"token":    requestPasswordReset(t, resets, customerID, -time.Minute),
+		"password": "N3wP4ssword",
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+	assert.Equal(t, "password reset token is invalid or has expired", data.Path("error.message").Data().(string))
+
+	s := requestPasswordReset(t, resets, customerID, time.Hour)
+
+	// Passwords are measured in characters rather than bytes and a rejected password leaves the token usable.
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset/confirm", handler, map[string]string{
+		"token":    s,
+		"password": "\u00e9\u00e9\u00e9",
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+	assert.Equal(
+		t,
+		"the length must be between 6 and 256",
+		data.Path("error.validation_errors.password").Data().(string),
+	)
+
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset/confirm", handler, map[string]string{
+		"token":    s,
+		"password": "N3wP4ssword",
+	}, testEnv)
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+
+	cust, err := customers.FindByID(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.True(t, cust.HasPassword("N3wP4ssword", testEnv.PasswordHasher()))
+	assert.NotNil(t, findToken(t, sessions, refresh).RevokedAt())
+
+	// Tokens are single use.
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset/confirm", handler, map[string]string{
+		"token":    s,
+		"password": "An0therP4ssword",
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+}
+
+// conflictingCustomerRepository fails every update as if the customer was changed concurrently.
+type conflictingCustomerRepository struct {
+	customer.Repository
+}
+
+func (conflictingCustomerRepository) Update(context.Context, *customer.Customer) (*customer.Customer, error) {
+	return nil, customer.ErrVersionConflict
+}
+
+func TestPasswordResetConfirmHandlerKeepsTokenWhenPasswordIsNotChanged(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	customers := conflictingCustomerRepository{postgres.NewCustomerRepository(testEnv.DB())}
+	resets := postgres.NewPasswordResetRepository(testEnv.DB())
+	sessions := postgres.NewRefreshTokenRepository(testEnv.DB())
+	handler := auth.NewPasswordResetConfirmHandler(customers, testEnv.PasswordHasher(), resets, sessions, testEnv.DB())
+
+	customerID := seedCustomer(t, testEnv.DB(), nil)
+	s := requestPasswordReset(t, resets, customerID, time.Hour)
+
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/auth/password-reset/confirm", handler, map[string]string{
+		"token":    s,
+		"password": "N3wP4ssword",
+	}, testEnv)
+	assert.Equal(t, http.StatusConflict, resp.Code, data)
+
+	token, err := resets.FindByHash(context.Background(), secret.Hash(s))
+	if err != nil {
+		t.Fatalf("unable to find password reset token: %v", err)
+	}
+
+	assert.True(t, token.IsUsable(time.Now()))
+}
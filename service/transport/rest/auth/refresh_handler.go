@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewRefreshHandler creates a new handler that rotates a refresh token for a new access token and refresh
+// token. A refresh token can only be used once. If a token that has already been rotated is presented then
+// it has been stolen, so every token in its family is revoked and the customer has to login again.
+func NewRefreshHandler(
+	repo customer.Repository,
+	sessions session.Repository,
+	j *app.JWT,
+	refreshTTL time.Duration,
+) rest.Handler {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/refresh").Methods(http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.RefreshToken, validation.Required),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			current, err := sessions.FindByHash(r.Context(), secret.Hash(req.RefreshToken))
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if current == nil {
+				w.RespondError(http.StatusUnauthorized, errInvalidRefreshToken)
+
+				return
+			}
+
+			revokeFamily := func() {
+				if err := sessions.RevokeFamily(r.Context(), current.FamilyID()); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+
+				w.RespondError(http.StatusUnauthorized, errInvalidRefreshToken)
+			}
+
+			if current.IsRotated() {
+				revokeFamily()
+
+				return
+			}
+
+			if !current.IsUsable(time.Now()) {
+				w.RespondError(http.StatusUnauthorized, errInvalidRefreshToken)
+
+				return
+			}
+
+			// The customer may have been deleted since the session was started.
+			cust, err := repo.FindByID(r.Context(), current.CustomerID())
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				revokeFamily()
+
+				return
+			}
+
+			next, nextSecret, err := current.Next(refreshTTL)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if err := sessions.Rotate(r.Context(), current, next); err != nil {
+				if errors.Is(err, session.ErrTokenReused) {
+					revokeFamily()
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp, err := newTokenResponse(j, next, nextSecret)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedCustomer inserts a customer that refresh tokens can be issued to.
+func seedCustomer(t *testing.T, db *app.DB, deletedAt interface{}) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+
+	postgrestest.Insert(t, db, "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   id.String() + "@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+		"deleted_at": deletedAt,
+	})
+
+	return id
+}
+
+// startSession adds a refresh token for the customer and returns its secret.
+func startSession(t *testing.T, repo session.Repository, customerID uuid.UUID, ttl time.Duration) string {
+	t.Helper()
+
+	token, s, err := session.New(customerID, ttl)
+	if err != nil {
+		t.Fatalf("unable to create refresh token: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), token); err != nil {
+		t.Fatalf("unable to add refresh token: %v", err)
+	}
+
+	return s
+}
+
+func findToken(t *testing.T, repo session.Repository, s string) *session.RefreshToken {
+	t.Helper()
+
+	token, err := repo.FindByHash(context.Background(), secret.Hash(s))
+	if err != nil {
+		t.Fatalf("unable to find refresh token: %v", err)
+	}
+
+	return token
+}
+
+func TestRefreshHandler(t *testing.T) {
+	t.Parallel()
+
+	type payload map[string]interface{}
+
+	tests := []struct {
+		name   string
+		setup  func(db *app.DB, repo session.Repository) payload
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, repo session.Repository, sent payload)
+	}{
+		{
+			name: "refresh with empty payload returns error",
+			setup: func(_ *app.DB, _ session.Repository) payload {
+				return payload{}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ session.Repository, _ payload) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.refresh_token").Data().(string))
+			},
+		},
+		{
+			name: "refresh with unknown token returns unauthorized",
+			setup: func(_ *app.DB, _ session.Repository) payload {
+				return payload{"refresh_token": "unknown"}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ session.Repository, _ payload) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+				assert.Equal(t, "refresh token is invalid or has expired", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name: "refresh with expired token returns unauthorized",
+			setup: func(db *app.DB, repo session.Repository) payload {
+				return payload{"refresh_token": startSession(t, repo, seedCustomer(t, db, nil), -time.Minute)}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ session.Repository, _ payload) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+			},
+		},
+		{
+			name: "refresh for deleted customer returns unauthorized",
+			setup: func(db *app.DB, repo session.Repository) payload {
+				return payload{"refresh_token": startSession(t, repo, seedCustomer(t, db, time.Now()), time.Hour)}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, repo session.Repository, sent payload) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+				assert.NotNil(t, findToken(t, repo, sent["refresh_token"].(string)).RevokedAt())
+			},
+		},
+		{
+			name: "refresh with revoked token returns unauthorized",
+			setup: func(db *app.DB, repo session.Repository) payload {
+				s := startSession(t, repo, seedCustomer(t, db, nil), time.Hour)
+
+				if err := repo.RevokeFamily(context.Background(), findToken(t, repo, s).FamilyID()); err != nil {
+					t.Fatalf("unable to revoke refresh token: %v", err)
+				}
+
+				return payload{"refresh_token": s}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ session.Repository, _ payload) {
+				assert.Equal(t, http.StatusUnauthorized, resp.Code)
+			},
+		},
+		{
+			name: "refresh rotates the token",
+			setup: func(db *app.DB, repo session.Repository) payload {
+				return payload{"refresh_token": startSession(t, repo, seedCustomer(t, db, nil), time.Hour)}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, repo session.Repository, sent payload) {
+				assert.Equal(t, http.StatusOK, resp.Code, data)
+				assert.NotEmpty(t, data.Path("access_token").Data().(string))
+
+				previous := findToken(t, repo, sent["refresh_token"].(string))
+				assert.True(t, previous.IsRotated())
+
+				next := findToken(t, repo, data.Path("refresh_token").Data().(string))
+				assert.Equal(t, previous.FamilyID(), next.FamilyID())
+				assert.True(t, next.IsUsable(time.Now()))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+			repo := postgres.NewRefreshTokenRepository(testEnv.DB())
+			input := tc.setup(testEnv.DB(), repo)
+
+			data, resp := resttest.RequestWithData(
+				t,
+				http.MethodPost,
+				"/auth/refresh",
+				auth.NewRefreshHandler(postgres.NewCustomerRepository(testEnv.DB()), repo, testEnv.JWT(), time.Hour),
+				input,
+				testEnv,
+			)
+
+			tc.assert(data, resp, repo, input)
+		})
+	}
+}
+
+func TestRefreshHandlerRevokesFamilyOnReuse(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewRefreshTokenRepository(testEnv.DB())
+	handler := auth.NewRefreshHandler(postgres.NewCustomerRepository(testEnv.DB()), repo, testEnv.JWT(), time.Hour)
+
+	first := startSession(t, repo, seedCustomer(t, testEnv.DB(), nil), time.Hour)
+
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/auth/refresh", handler, map[string]string{
+		"refresh_token": first,
+	}, testEnv)
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+
+	second := data.Path("refresh_token").Data().(string)
+
+	// Replaying the first token means it has been stolen so the whole family must be revoked.
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/refresh", handler, map[string]string{
+		"refresh_token": first,
+	}, testEnv)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, data)
+	assert.NotNil(t, findToken(t, repo, second).RevokedAt())
+
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/auth/refresh", handler, map[string]string{
+		"refresh_token": second,
+	}, testEnv)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, data)
+}
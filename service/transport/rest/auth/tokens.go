@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/session"
+)
+
+// errInvalidRefreshToken covers unknown, expired, revoked and reused refresh tokens alike.
+var errInvalidRefreshToken = errors.New("refresh token is invalid or has expired")
+
+// tokenResponse is returned whenever a customer is issued a new pair of tokens.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// newTokenResponse issues an access token for the owner of the refresh token and pairs it with the
+// refresh tokens secret.
+func newTokenResponse(j *app.JWT, t *session.RefreshToken, refreshSecret string) (tokenResponse, error) {
+	token, err := j.Issue(t.CustomerID().String())
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	return tokenResponse{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(j.TTL().Seconds()),
+		RefreshToken: refreshSecret,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewUnlockHandler creates a new handler that lets an admin clear the failed login attempts for a
+// username, a client IP or both so that they can login again straight away.
+func NewUnlockHandler(guard *lockout.Guard, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type request struct {
+		Username string `json:"username"`
+		IP       string `json:"ip"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/auth/unlock").Methods(http.MethodPost)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionUnlockLogins},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Username, validation.Required.When(req.IP == "")),
+				validation.Field(&req.IP, validation.Required.When(req.Username == ""), is.IP),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			if err := guard.Unlock(r.Context(), req.Username, req.IP); err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/lockout"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/auth"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnlockHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	guard := lockout.NewGuard(postgres.NewLockoutRepository(testEnv.DB()), testLockoutPolicy, testLockoutPolicy)
+	handler := auth.NewUnlockHandler(guard, testEnv.JWT(), apikey.NewAuthenticator(postgres.NewAPIKeyRepository(testEnv.DB())))
+	ctx := context.Background()
+	now := time.Now()
+
+	adminID := seedCustomer(t, testEnv.DB(), nil)
+	if err := postgres.NewCustomerRepository(testEnv.DB()).GrantRole(ctx, adminID, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	for i := 0; i < testLockoutPolicy.LockAfter; i++ {
+		if err := guard.Fail(ctx, "locked@example.org", "192.0.2.1", now); err != nil {
+			t.Fatalf("unable to record failure: %v", err)
+		}
+	}
+
+	_, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/auth/unlock",
+		handler,
+		map[string]string{"username": "locked@example.org"},
+		resttest.AuthHeader(t, testEnv, seedCustomer(t, testEnv.DB(), nil)),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/auth/unlock",
+		handler,
+		map[string]string{},
+		resttest.AuthHeader(t, testEnv, adminID),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.username").Data().(string))
+	assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.ip").Data().(string))
+
+	data, resp = resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/auth/unlock",
+		handler,
+		map[string]string{"username": "locked@example.org"},
+		resttest.AuthHeader(t, testEnv, adminID),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+
+	wait, err := guard.Check(ctx, "locked@example.org", "", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.Zero(t, wait)
+
+	wait, err = guard.Check(ctx, "", "192.0.2.1", now)
+	if err != nil {
+		t.Fatalf("unable to check attempts: %v", err)
+	}
+
+	assert.NotZero(t, wait, "only the given username is unlocked")
+}
@@ -0,0 +1,470 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireJWTAuthentication(t *testing.T) {
+	t.Parallel()
+
+	customerID := uuid.New()
+
+	issueWith := func(t *testing.T, e *app.Environment, modify func(c *app.Config)) string {
+		t.Helper()
+
+		conf := *e.Config()
+		modify(&conf)
+
+		j, err := app.NewJWT(&conf)
+		if err != nil {
+			t.Fatalf("unable to create jwt: %v", err)
+		}
+
+		token, err := j.Issue(customerID.String())
+		if err != nil {
+			t.Fatalf("unable to issue token: %v", err)
+		}
+
+		return token
+	}
+
+	tests := []struct {
+		name           string
+		authorization  func(t *testing.T, e *app.Environment) string
+		expectedStatus int
+	}{
+		{
+			name:           "request without authorization header is rejected",
+			authorization:  func(t *testing.T, e *app.Environment) string { return "" },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "request with non bearer authorization header is rejected",
+			authorization:  func(t *testing.T, e *app.Environment) string { return "Basic dXNlcjpwYXNz" },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "request with malformed token is rejected",
+			authorization:  func(t *testing.T, e *app.Environment) string { return "Bearer not.a.token" },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "request with expired token is rejected",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return "Bearer " + issueWith(t, e, func(c *app.Config) { c.Auth.AccessTokenTTL = -60 })
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "request with token signed by another secret is rejected",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return "Bearer " + issueWith(t, e, func(c *app.Config) { c.JWTSecret = "another-secret-that-is-at-least-32-chars" })
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "request with token from another issuer is rejected",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return "Bearer " + issueWith(t, e, func(c *app.Config) { c.Auth.Issuer = "someone-else" })
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "request with valid token is authenticated",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return "Bearer " + issueWith(t, e, func(c *app.Config) {})
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, false)
+
+			header := http.Header{}
+			if authorization := tc.authorization(t, testEnv); authorization != "" {
+				header.Set("Authorization", authorization)
+			}
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodGet,
+				"/test-authentication",
+				rest.Handler{
+					Route: func(r *mux.Route) {
+						r.Path("/test-authentication").Methods(http.MethodGet)
+					},
+					Middleware: rest.RequireJWTAuthentication(testEnv.JWT()),
+					Func: func(w rest.Responder, r rest.Request) {
+						id, ok := r.CustomerID()
+						assert.True(t, ok)
+
+						w.Respond(http.StatusOK, map[string]string{"id": id.String()})
+					},
+				},
+				nil,
+				header,
+				testEnv,
+			)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code, data)
+
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, customerID.String(), data.Path("id").Data().(string))
+			} else {
+				assert.NotEmpty(t, resp.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// authorizerFunc lets a function be used as a rest.Authorizer.
+type authorizerFunc func(customerID uuid.UUID, permissions []string) (bool, error)
+
+func (f authorizerFunc) Authorize(_ context.Context, customerID uuid.UUID, permissions []string) (bool, error) {
+	return f(customerID, permissions)
+}
+
+func TestHandlerPermissions(t *testing.T) {
+	t.Parallel()
+
+	adminID := uuid.New()
+
+	authorizer := authorizerFunc(func(customerID uuid.UUID, permissions []string) (bool, error) {
+		if customerID == uuid.Nil {
+			return false, errors.New("lookup failed")
+		}
+
+		return customerID == adminID && len(permissions) == 1 && permissions[0] == "things:manage", nil
+	})
+
+	tests := []struct {
+		name           string
+		authorizer     rest.Authorizer
+		middleware     bool
+		customerID     uuid.UUID
+		expectedStatus int
+	}{
+		{
+			name:           "customer with the permissions is allowed",
+			authorizer:     authorizer,
+			middleware:     true,
+			customerID:     adminID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "customer without the permissions is forbidden",
+			authorizer:     authorizer,
+			middleware:     true,
+			customerID:     uuid.New(),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "failed authorization is an internal error",
+			authorizer:     authorizer,
+			middleware:     true,
+			customerID:     uuid.Nil,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "unauthenticated request is rejected",
+			authorizer:     authorizer,
+			middleware:     false,
+			customerID:     adminID,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "server without an authorizer forbids the request",
+			authorizer:     nil,
+			middleware:     true,
+			customerID:     adminID,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, false)
+
+			h := rest.Handler{
+				Route: func(r *mux.Route) {
+					r.Path("/test-permissions").Methods(http.MethodGet)
+				},
+				Permissions: []string{"things:manage"},
+				Func: func(w rest.Responder, r rest.Request) {
+					w.WriteHeader(http.StatusOK)
+				},
+			}
+
+			if tc.middleware {
+				h.Middleware = func(next rest.ServiceFunc) rest.ServiceFunc {
+					return func(w rest.Responder, r rest.Request) {
+						next(w, rest.Request{Request: r.WithContext(rest.WithCustomerID(r.Context(), tc.customerID))})
+					}
+				}
+			}
+
+			s := rest.NewServer(testEnv, tc.authorizer)
+			s.RegisterHandlers(h)
+
+			req := httptest.NewRequest(http.MethodGet, "/test-permissions", nil)
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+		})
+	}
+}
+
+func TestHandlerOwner(t *testing.T) {
+	t.Parallel()
+
+	ownerID, adminID := uuid.New(), uuid.New()
+
+	scoped, scopedKey, err := apikey.New(ownerID, "scoped", []string{"things:read"}, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	authorizer := authorizerFunc(func(customerID uuid.UUID, _ []string) (bool, error) {
+		return customerID == adminID, nil
+	})
+
+	tests := []struct {
+		name           string
+		id             string
+		authorization  func(t *testing.T, e *app.Environment) string
+		expectedStatus int
+	}{
+		{
+			name: "owner is allowed without the permissions",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return resttest.AuthHeader(t, e, ownerID).Get("Authorization")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "customer with the permissions is allowed",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return resttest.AuthHeader(t, e, adminID).Get("Authorization")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "other customer without the permissions is forbidden",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return resttest.AuthHeader(t, e, uuid.New()).Get("Authorization")
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "scoped api key of the owner is not treated as the owner",
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey " + scopedKey },
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "malformed owner is rejected before the permissions are checked",
+			id:   "not-a-uuid",
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return resttest.AuthHeader(t, e, uuid.New()).Get("Authorization")
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, false)
+
+			s := rest.NewServer(testEnv, authorizer)
+			s.RegisterHandlers(rest.Handler{
+				Route: func(r *mux.Route) {
+					r.Path("/test-owner/{id}").Methods(http.MethodGet)
+				},
+				Middleware:  rest.RequireAuthentication(testEnv.JWT(), apikey.NewAuthenticator(apiKeyRepository{scoped})),
+				Permissions: []string{"things:manage"},
+				Owner: func(r rest.Request) (uuid.UUID, error) {
+					return uuid.Parse(mux.Vars(r.Request)["id"])
+				},
+				Func: func(w rest.Responder, r rest.Request) {
+					w.WriteHeader(http.StatusOK)
+				},
+			})
+
+			id := ownerID.String()
+			if tc.id != "" {
+				id = tc.id
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/test-owner/"+id, nil)
+			req.Header.Set("Authorization", tc.authorization(t, testEnv))
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+		})
+	}
+}
+
+// apiKeyRepository is an in memory apikey.Repository holding a single key.
+type apiKeyRepository struct {
+	key *apikey.Key
+}
+
+func (m apiKeyRepository) Add(context.Context, *apikey.Key) error             { return nil }
+func (m apiKeyRepository) Revoke(context.Context, uuid.UUID, uuid.UUID) error { return nil }
+func (m apiKeyRepository) Touch(context.Context, uuid.UUID, time.Time) error  { return nil }
+func (m apiKeyRepository) ListByCustomer(context.Context, uuid.UUID) ([]*apikey.Key, error) {
+	return []*apikey.Key{m.key}, nil
+}
+
+func (m apiKeyRepository) FindByHash(_ context.Context, hash string) (*apikey.Key, error) {
+	if m.key.Hash() == hash {
+		return m.key, nil
+	}
+
+	return nil, nil
+}
+
+func TestRequireAuthentication(t *testing.T) {
+	t.Parallel()
+
+	customerID := uuid.New()
+
+	unscoped, unscopedKey, err := apikey.New(customerID, "unscoped", nil, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	scoped, scopedKey, err := apikey.New(customerID, "scoped", []string{"things:read"}, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	// The customer has both permissions but the scoped key is restricted to one of them.
+	authorizer := authorizerFunc(func(uuid.UUID, []string) (bool, error) { return true, nil })
+
+	tests := []struct {
+		name           string
+		key            *apikey.Key
+		authorization  func(t *testing.T, e *app.Environment) string
+		permission     string
+		expectedStatus int
+	}{
+		{
+			name:           "request without authorization header is rejected",
+			key:            unscoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "" },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "request with an access token is authenticated",
+			key:  unscoped,
+			authorization: func(t *testing.T, e *app.Environment) string {
+				return resttest.AuthHeader(t, e, customerID).Get("Authorization")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "request with an api key is authenticated",
+			key:            unscoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey " + unscopedKey },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "request with an unknown api key is rejected",
+			key:            unscoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey gtk_unknown" },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unscoped api key can use every permission of the customer",
+			key:            unscoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey " + unscopedKey },
+			permission:     "things:write",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "scoped api key can use the permissions in its scopes",
+			key:            scoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey " + scopedKey },
+			permission:     "things:read",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "scoped api key can not use permissions outside of its scopes",
+			key:            scoped,
+			authorization:  func(t *testing.T, e *app.Environment) string { return "ApiKey " + scopedKey },
+			permission:     "things:write",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, false)
+
+			h := rest.Handler{
+				Route: func(r *mux.Route) {
+					r.Path("/test-authentication").Methods(http.MethodGet)
+				},
+				Middleware: rest.RequireAuthentication(testEnv.JWT(), apikey.NewAuthenticator(apiKeyRepository{tc.key})),
+				Func: func(w rest.Responder, r rest.Request) {
+					id, ok := r.CustomerID()
+					assert.True(t, ok)
+					assert.Equal(t, customerID, id)
+
+					w.WriteHeader(http.StatusOK)
+				},
+			}
+
+			if tc.permission != "" {
+				h.Permissions = []string{tc.permission}
+			}
+
+			s := rest.NewServer(testEnv, authorizer)
+			s.RegisterHandlers(h)
+
+			req := httptest.NewRequest(http.MethodGet, "/test-authentication", nil)
+			if authorization := tc.authorization(t, testEnv); authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.expectedStatus, resp.Code, resp.Body.String())
+
+			if tc.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, resp.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package customers
 
 import (
-	"context"
 	"errors"
 	"net/http"
 
@@ -10,41 +9,25 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/nickbryan/go-template/service/app"
 	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/verification"
 	"github.com/nickbryan/go-template/service/transport/rest"
+	"go.uber.org/zap"
 )
 
-var errUserExists = errors.New("customers already exists with the given username")
-
-type usernameUniqueRule struct {
-	storage customer.Repository
-	ctx     context.Context
-}
-
-func (r usernameUniqueRule) Validate(value interface{}) error {
-	c, err := r.storage.FindByUsername(r.ctx, value.(string))
-	if err != nil {
-		return validation.NewInternalError(err)
-	}
-
-	if c != nil {
-		return errUserExists
-	}
-
-	return nil
-}
-
-// NewCreateHandler creates a new handler for creating customers.
-func NewCreateHandler(repo customer.Repository) rest.Handler {
+// NewCreateHandler creates a new handler for creating customers. The new customer is returned along with
+// a Location header pointing at it. A verification email is sent to the new customer so that they can
+// prove they own their username. Failing to send it does not fail the request as the customer can ask for
+// it to be resent.
+func NewCreateHandler(
+	repo customer.Repository,
+	hasher customer.PasswordHasher,
+	sender *verification.Sender,
+) rest.Handler {
 	type request struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 
-	const (
-		minPassLen = 6
-		maxPassLen = 256 // Stops ddos through long password hashing
-	)
-
 	return rest.Handler{
 		Route: func(r *mux.Route) {
 			r.Path("/customers").Methods(http.MethodPost)
@@ -59,24 +42,44 @@ func NewCreateHandler(repo customer.Repository) rest.Handler {
 			}
 
 			if errs := app.Validate(&req,
-				validation.Field(&req.Username, validation.Required, is.Email, usernameUniqueRule{repo, r.Context()}),
-				validation.Field(&req.Password, validation.Required, validation.Length(minPassLen, maxPassLen)),
+				validation.Field(&req.Username, validation.Required, is.Email, usernameUniqueRule{storage: repo, ctx: r.Context()}),
+				validation.Field(
+					&req.Password,
+					validation.Required,
+					validation.RuneLength(customer.MinPasswordLength, customer.MaxPasswordLength),
+				),
 			); errs != nil {
 				w.RespondValidationFailed(errs)
 
 				return
 			}
 
-			cust, err := customer.New(req.Username, req.Password)
-			if err != nil && errors.Is(err, customer.ErrGeneratePassword) {
-				// TODO: handle error
+			cust, err := customer.New(req.Username, req.Password, hasher)
+			if err != nil {
+				respondChangeFailed(w, err)
+
+				return
 			}
 
+			// The username was checked during validation but a concurrent request may have taken it since.
 			if err := repo.Add(r.Context(), cust); err != nil {
-				// TODO: handle error
+				if errors.Is(err, customer.ErrUsernameTaken) {
+					w.RespondError(http.StatusConflict, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if err := sender.Send(r.Context(), cust); err != nil {
+				r.Logger().Error("unable to send verification email", zap.Error(err), zap.String("customer", cust.ID().String()))
 			}
 
-			w.WriteHeader(http.StatusCreated)
+			w.Header().Set("Location", customerLocation(cust))
+			w.Respond(http.StatusCreated, newCustomerResponse(cust))
 		},
 	}
 }
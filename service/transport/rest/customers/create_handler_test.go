@@ -12,8 +12,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/mail/mailtest"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/verification"
 	"github.com/nickbryan/go-template/service/infrastructure/postgres"
 	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest"
 	"github.com/nickbryan/go-template/service/transport/rest/customers"
 	"github.com/nickbryan/go-template/service/transport/rest/resttest"
 )
@@ -31,6 +35,18 @@ func genLongPassword(t *testing.T, ln uint) string {
 	return string(b)
 }
 
+func newCreateHandler(e *app.Environment, mailer *mailtest.Mailer) rest.Handler {
+	sender := verification.NewSender(
+		postgres.NewVerificationRepository(e.DB()),
+		mailer,
+		"http://localhost/customers/verify",
+		time.Hour,
+		time.Minute,
+	)
+
+	return customers.NewCreateHandler(postgres.NewCustomerRepository(e.DB()), e.PasswordHasher(), sender)
+}
+
 func TestCustomerCreateHandler(t *testing.T) {
 	t.Parallel()
 
@@ -135,6 +151,29 @@ func TestCustomerCreateHandler(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:   "create with password too short in characters but not bytes returns error",
+			url:    "/customers",
+			method: http.MethodPost,
+			input:  payload{"username": "test@example.org", "password": "\u00e9\u00e9\u00e9"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"the length must be between 6 and 256",
+					data.Path("error.validation_errors.password").Data().(string),
+				)
+			},
+		},
+		{
+			name:   "customers can be created with a multibyte password",
+			url:    "/customers",
+			method: http.MethodPost,
+			input:  payload{"username": "test@example.org", "password": "\u00e9\u00e9\u00e9\u00e9\u00e9\u00e9"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusCreated, resp.Code)
+			},
+		},
 		{
 			name:   "customers can be created",
 			url:    "/customers",
@@ -142,7 +181,12 @@ func TestCustomerCreateHandler(t *testing.T) {
 			input:  payload{"username": "test@example.org", "password": "Sup3rS3cr3t"},
 			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
 				assert.Equal(t, http.StatusCreated, resp.Code)
+				assert.Equal(t, "/customers/"+data.Path("id").Data().(string), resp.Header().Get("Location"))
+				assert.Equal(t, "test@example.org", data.Path("username").Data().(string))
+				assert.True(t, data.Exists("created_at"))
+				assert.False(t, data.Exists("password"))
 				postgrestest.AssertDatabaseHas(t, db, "customers", map[string]string{
+					"uuid":     data.Path("id").Data().(string),
 					"username": "test@example.org",
 				})
 			},
@@ -177,6 +221,25 @@ func TestCustomerCreateHandler(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:   "customers can be created with the username of a deleted customer",
+			url:    "/customers",
+			method: http.MethodPost,
+			input:  payload{"username": "deleted@example.org", "password": "Sup3rS3cr3t"},
+			setup: func(db *app.DB) {
+				postgrestest.Insert(t, db, "customers", map[string]interface{}{
+					"uuid":       uuid.New(),
+					"username":   "deleted@example.org",
+					"password":   "abc123",
+					"created_at": time.Now(),
+					"updated_at": time.Now(),
+					"deleted_at": time.Now(),
+				})
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusCreated, resp.Code)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -194,7 +257,7 @@ func TestCustomerCreateHandler(t *testing.T) {
 				t,
 				tc.method,
 				tc.url,
-				customers.NewCreateHandler(postgres.NewCustomerRepository(testEnv.DB())),
+				newCreateHandler(testEnv, &mailtest.Mailer{}),
 				tc.input,
 				testEnv,
 			)
@@ -203,3 +266,85 @@ func TestCustomerCreateHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomerCreateHandlerSendsVerificationEmail(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	mailer := &mailtest.Mailer{}
+
+	data, resp := resttest.RequestWithData(
+		t,
+		http.MethodPost,
+		"/customers",
+		newCreateHandler(testEnv, mailer),
+		map[string]string{"username": "new@example.org", "password": "Sup3rS3cr3t"},
+		testEnv,
+	)
+	assert.Equal(t, http.StatusCreated, resp.Code, data)
+
+	messages := mailer.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "new@example.org", messages[0].To)
+		assert.Contains(t, messages[0].Body, "http://localhost/customers/verify?token=")
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{
+		"username": "new@example.org",
+	})
+
+	cust, err := postgres.NewCustomerRepository(testEnv.DB()).FindByUsername(context.Background(), "new@example.org")
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.False(t, cust.IsVerified())
+}
+
+// racingRepository hides existing customers from the username validation so that the handler behaves as if
+// another request created the customer between validating and adding.
+type racingRepository struct {
+	*postgres.CustomerRepository
+}
+
+func (racingRepository) FindByUsername(context.Context, string) (*customer.Customer, error) {
+	return nil, nil
+}
+
+func TestCustomerCreateHandlerConflictsOnConcurrentSignup(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       uuid.New(),
+		"username":   "taken@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	sender := verification.NewSender(
+		postgres.NewVerificationRepository(testEnv.DB()),
+		&mailtest.Mailer{},
+		"http://localhost/customers/verify",
+		time.Hour,
+		time.Minute,
+	)
+
+	data, resp := resttest.RequestWithData(
+		t,
+		http.MethodPost,
+		"/customers",
+		customers.NewCreateHandler(
+			racingRepository{postgres.NewCustomerRepository(testEnv.DB())},
+			testEnv.PasswordHasher(),
+			sender,
+		),
+		map[string]string{"username": "taken@example.org", "password": "Sup3rS3cr3t"},
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusConflict, resp.Code, data)
+	assert.Equal(t, customer.ErrUsernameTaken.Error(), data.Path("error.message").Data().(string))
+}
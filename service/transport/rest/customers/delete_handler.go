@@ -0,0 +1,47 @@
+package customers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewDeleteHandler creates a new handler for soft deleting customers. Deleted customers
+// can be brought back with the restore handler until they are purged. Customers can delete
+// themselves while deleting anybody else requires customer.PermissionManageCustomers.
+func NewDeleteHandler(repo customer.Repository, j *app.JWT) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}").Methods(http.MethodDelete)
+		},
+		Middleware:  rest.RequireJWTAuthentication(j),
+		Permissions: []string{customer.PermissionManageCustomers},
+		Owner:       parseID,
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if err := repo.Delete(r.Context(), id); err != nil {
+				if errors.Is(err, customer.ErrNotFound) {
+					w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
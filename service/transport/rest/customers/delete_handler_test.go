@@ -0,0 +1,139 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerDeleteHandler(t *testing.T) {
+	t.Parallel()
+
+	activeID := uuid.New()
+	deletedID := uuid.New()
+
+	tests := []struct {
+		name   string
+		url    string
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB)
+	}{
+		{
+			name: "delete with invalid id returns error",
+			url:  "/customers/not-a-uuid",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+			},
+		},
+		{
+			name: "delete with unknown id returns not found",
+			url:  "/customers/" + uuid.New().String(),
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name: "delete of an already deleted customer returns not found",
+			url:  "/customers/" + deletedID.String(),
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name: "customers can be deleted",
+			url:  "/customers/" + activeID.String(),
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+				assert.Nil(t, data)
+
+				// The record is kept so that it can be restored.
+				postgrestest.AssertDatabaseHas(t, db, "customers", map[string]string{
+					"uuid": activeID.String(),
+				})
+
+				cust, err := postgres.NewCustomerRepository(db).FindByUsername(context.Background(), "active@example.org")
+				if err != nil {
+					t.Fatalf("unable to find customer: %v", err)
+				}
+
+				assert.Nil(t, cust, "deleted customers should not be found")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+
+			postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+				"uuid":       activeID,
+				"username":   "active@example.org",
+				"password":   "abc123",
+				"created_at": time.Now(),
+				"updated_at": time.Now(),
+			})
+			postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+				"uuid":       deletedID,
+				"username":   "deleted@example.org",
+				"password":   "abc123",
+				"created_at": time.Now(),
+				"updated_at": time.Now(),
+				"deleted_at": time.Now(),
+			})
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodDelete,
+				tc.url,
+				customers.NewDeleteHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT()),
+				nil,
+				newAdminHeader(t, testEnv),
+				testEnv,
+			)
+
+			tc.assert(data, resp, testEnv.DB())
+		})
+	}
+}
+
+func TestCustomerDeleteHandlerAuthorization(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(t, testEnv.DB(), time.Now(), "owner@example.org", "other@example.org")
+	handler := customers.NewDeleteHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT())
+	url := "/customers/" + ids[0].String()
+
+	remove := func(as uuid.UUID) int {
+		t.Helper()
+
+		header := resttest.AuthHeader(t, testEnv, as)
+		_, resp := resttest.RequestWithHeaders(t, http.MethodDelete, url, handler, nil, header, testEnv)
+
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, remove(ids[1]), "customers can not delete other customers")
+
+	cust, err := postgres.NewCustomerRepository(testEnv.DB()).FindByID(context.Background(), ids[0])
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.NotNil(t, cust, "the customer should not have been deleted")
+
+	assert.Equal(t, http.StatusNoContent, remove(ids[0]), "customers can delete themselves")
+}
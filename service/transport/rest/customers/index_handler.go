@@ -0,0 +1,130 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errLimitOutOfRange = fmt.Errorf("must be between 1 and %d", customer.MaxListLimit)
+
+// NewIndexHandler creates a new handler for listing customers a page at a time. Listing customers requires
+// customer.PermissionManageCustomers.
+func NewIndexHandler(repo customer.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type request struct {
+		Limit          string `json:"limit"`
+		Cursor         string `json:"cursor"`
+		Sort           string `json:"sort"`
+		CreatedAfter   string `json:"created_after"`
+		CreatedBefore  string `json:"created_before"`
+		IncludeDeleted string `json:"include_deleted"`
+	}
+
+	type response struct {
+		Data       []customerResponse `json:"data"`
+		NextCursor string             `json:"next_cursor,omitempty"`
+		PrevCursor string             `json:"prev_cursor,omitempty"`
+	}
+
+	limitInRange := validation.By(func(value interface{}) error {
+		limit, err := strconv.Atoi(value.(string))
+		if err != nil || limit < 1 || limit > customer.MaxListLimit {
+			return errLimitOutOfRange
+		}
+
+		return nil
+	})
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers").Methods(http.MethodGet)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManageCustomers},
+		Func: func(w rest.Responder, r rest.Request) {
+			q := r.URL.Query()
+
+			req := request{
+				Limit:          q.Get("limit"),
+				Cursor:         q.Get("cursor"),
+				Sort:           q.Get("sort"),
+				CreatedAfter:   q.Get("created_after"),
+				CreatedBefore:  q.Get("created_before"),
+				IncludeDeleted: q.Get("include_deleted"),
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Limit, is.Int, limitInRange),
+				validation.Field(&req.Sort, validation.In(
+					customer.SortCreatedAt,
+					customer.SortCreatedAtDesc,
+					customer.SortUsername,
+					customer.SortUsernameDesc,
+				)),
+				validation.Field(&req.CreatedAfter, validation.Date(time.RFC3339)),
+				validation.Field(&req.CreatedBefore, validation.Date(time.RFC3339)),
+				validation.Field(&req.IncludeDeleted, validation.In("true", "false")),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			opts := customer.ListOptions{
+				Cursor:         req.Cursor,
+				Sort:           req.Sort,
+				IncludeDeleted: req.IncludeDeleted == "true",
+			}
+
+			// The values have been validated above so we can safely ignore the conversion errors.
+			if req.Limit != "" {
+				opts.Limit, _ = strconv.Atoi(req.Limit)
+			}
+
+			if req.CreatedAfter != "" {
+				after, _ := time.Parse(time.RFC3339, req.CreatedAfter)
+				opts.CreatedAfter = &after
+			}
+
+			if req.CreatedBefore != "" {
+				before, _ := time.Parse(time.RFC3339, req.CreatedBefore)
+				opts.CreatedBefore = &before
+			}
+
+			page, err := repo.List(r.Context(), opts)
+			if err != nil {
+				if errors.Is(err, customer.ErrInvalidCursor) {
+					w.RespondError(http.StatusBadRequest, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp := response{
+				Data:       make([]customerResponse, 0, len(page.Customers)),
+				NextCursor: page.NextCursor,
+				PrevCursor: page.PrevCursor,
+			}
+
+			for _, c := range page.Customers {
+				resp.Data = append(resp.Data, newCustomerResponse(c))
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
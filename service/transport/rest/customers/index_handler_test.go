@@ -0,0 +1,275 @@
+package customers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedCustomers inserts a customer for each username, each created a minute after the last, and returns their ids.
+func seedCustomers(t *testing.T, db *app.DB, start time.Time, usernames ...string) []uuid.UUID {
+	t.Helper()
+
+	ids := make([]uuid.UUID, 0, len(usernames))
+
+	for i, username := range usernames {
+		createdAt := start.Add(time.Duration(i) * time.Minute)
+		id := uuid.New()
+		ids = append(ids, id)
+
+		postgrestest.Insert(t, db, "customers", map[string]interface{}{
+			"uuid":       id,
+			"username":   username,
+			"password":   "abc123",
+			"created_at": createdAt,
+			"updated_at": createdAt,
+		})
+	}
+
+	return ids
+}
+
+func usernames(data *gabs.Container) []string {
+	children, _ := data.Path("data").Children()
+
+	names := make([]string, 0, len(children))
+	for _, child := range children {
+		names = append(names, child.Path("username").Data().(string))
+	}
+
+	return names
+}
+
+func TestCustomerIndexHandler(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		url    string
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name: "index lists customers in order of creation by default",
+			url:  "/customers",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, []string{"c@example.org", "a@example.org", "b@example.org"}, usernames(data))
+				assert.False(t, data.Exists("next_cursor"))
+				assert.False(t, data.Exists("prev_cursor"))
+			},
+		},
+		{
+			name: "index can be sorted by username descending",
+			url:  "/customers?sort=-username",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, []string{"c@example.org", "b@example.org", "a@example.org"}, usernames(data))
+			},
+		},
+		{
+			name: "index can be filtered by created at range",
+			url: "/customers?created_after=" + url.QueryEscape(start.Add(time.Minute).Format(time.RFC3339)) +
+				"&created_before=" + url.QueryEscape(start.Add(2*time.Minute).Format(time.RFC3339)),
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, []string{"a@example.org"}, usernames(data))
+			},
+		},
+		{
+			name: "index with limit returns a next cursor",
+			url:  "/customers?limit=2",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, []string{"c@example.org", "a@example.org"}, usernames(data))
+				assert.True(t, data.Exists("next_cursor"))
+				assert.False(t, data.Exists("prev_cursor"))
+			},
+		},
+		{
+			name: "index with invalid limit returns error",
+			url:  "/customers?limit=1000",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must be between 1 and 100",
+					data.Path("error.validation_errors.limit").Data().(string),
+				)
+			},
+		},
+		{
+			name: "index with unknown sort returns error",
+			url:  "/customers?sort=password",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must be a valid value",
+					data.Path("error.validation_errors.sort").Data().(string),
+				)
+			},
+		},
+		{
+			name: "index with invalid date returns error",
+			url:  "/customers?created_after=yesterday",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must be a valid date",
+					data.Path("error.validation_errors.created_after").Data().(string),
+				)
+			},
+		},
+		{
+			name: "index can include deleted customers",
+			url:  "/customers?include_deleted=true",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(
+					t,
+					[]string{"c@example.org", "a@example.org", "b@example.org", "d@example.org"},
+					usernames(data),
+				)
+				assert.False(t, data.Path("data").Index(0).Exists("deleted_at"))
+				assert.True(t, data.Path("data").Index(3).Exists("deleted_at"))
+			},
+		},
+		{
+			name: "index with invalid include deleted returns error",
+			url:  "/customers?include_deleted=yes",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must be a valid value",
+					data.Path("error.validation_errors.include_deleted").Data().(string),
+				)
+			},
+		},
+		{
+			name: "index with invalid cursor returns error",
+			url:  "/customers?cursor=not-a-cursor",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "invalid cursor", data.Path("error.message").Data().(string))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+			ids := seedCustomers(t, testEnv.DB(), start, "c@example.org", "a@example.org", "b@example.org")
+			postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+				"uuid":       uuid.New(),
+				"username":   "d@example.org",
+				"password":   "abc123",
+				"created_at": start.Add(time.Hour),
+				"updated_at": start.Add(time.Hour),
+				"deleted_at": start.Add(time.Hour),
+			})
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodGet,
+				tc.url,
+				customers.NewIndexHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+				nil,
+				resttest.AdminHeader(t, testEnv, ids[0]),
+				testEnv,
+			)
+
+			tc.assert(data, resp)
+		})
+	}
+}
+
+func TestCustomerIndexHandlerCursors(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(
+		t,
+		testEnv.DB(),
+		time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC),
+		"a@example.org", "b@example.org", "c@example.org", "d@example.org", "e@example.org",
+	)
+
+	handler := customers.NewIndexHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv))
+	header := resttest.AdminHeader(t, testEnv, ids[0])
+
+	page := func(query string) *gabs.Container {
+		t.Helper()
+
+		data, resp := resttest.RequestWithHeaders(t, http.MethodGet, "/customers?"+query, handler, nil, header, testEnv)
+		assert.Equal(t, http.StatusOK, resp.Code, data)
+
+		return data
+	}
+
+	first := page("sort=-created_at&limit=2")
+	assert.Equal(t, []string{"e@example.org", "d@example.org"}, usernames(first))
+	assert.False(t, first.Exists("prev_cursor"))
+
+	second := page("sort=-created_at&limit=2&cursor=" + first.Path("next_cursor").Data().(string))
+	assert.Equal(t, []string{"c@example.org", "b@example.org"}, usernames(second))
+
+	last := page("sort=-created_at&limit=2&cursor=" + second.Path("next_cursor").Data().(string))
+	assert.Equal(t, []string{"a@example.org"}, usernames(last))
+	assert.False(t, last.Exists("next_cursor"))
+
+	back := page("sort=-created_at&limit=2&cursor=" + last.Path("prev_cursor").Data().(string))
+	assert.Equal(t, []string{"c@example.org", "b@example.org"}, usernames(back))
+
+	start := page("sort=-created_at&limit=2&cursor=" + back.Path("prev_cursor").Data().(string))
+	assert.Equal(t, []string{"e@example.org", "d@example.org"}, usernames(start))
+	assert.False(t, start.Exists("prev_cursor"))
+
+	// A cursor can not be used with a different sort to the one that created it.
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/customers?sort=username&cursor="+first.Path("next_cursor").Data().(string),
+		handler,
+		nil,
+		header,
+		testEnv,
+	)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+}
+
+func TestCustomerIndexHandlerRequiresPermission(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(t, testEnv.DB(), time.Now(), "a@example.org")
+
+	_, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/customers",
+		customers.NewIndexHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+		nil,
+		resttest.AuthHeader(t, testEnv, ids[0]),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
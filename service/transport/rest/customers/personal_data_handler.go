@@ -0,0 +1,107 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewExportPersonalDataHandler creates a new handler that lets an admin download everything we hold about a
+// customer as a JSON archive. Deleted customers are included until they are purged.
+func NewExportPersonalDataHandler(s *privacy.Service, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}/personal-data").Methods(http.MethodGet)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManagePersonalData},
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			requestedBy, _ := r.CustomerID()
+
+			archive, err := s.Export(r.Context(), id, requestedBy)
+			if err != nil {
+				if errors.Is(err, privacy.ErrNotFound) {
+					w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="customer-%s.json"`, id))
+			w.Respond(http.StatusOK, archive)
+		},
+	}
+}
+
+// NewErasePersonalDataHandler creates a new handler that lets an admin irreversibly erase everything we hold
+// about a customer. The response is the record of the erasure, which is kept as proof that it happened.
+func NewErasePersonalDataHandler(s *privacy.Service, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type response struct {
+		ID          uuid.UUID `json:"id"`
+		CustomerID  uuid.UUID `json:"customer_id"`
+		Type        string    `json:"type"`
+		RequestedBy uuid.UUID `json:"requested_by"`
+		Sources     []string  `json:"sources"`
+		CompletedAt time.Time `json:"completed_at"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}/personal-data").Methods(http.MethodDelete)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManagePersonalData},
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			requestedBy, _ := r.CustomerID()
+
+			erasure, err := s.Erase(r.Context(), id, requestedBy)
+			if err != nil {
+				if errors.Is(err, privacy.ErrNotFound) {
+					w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Respond(http.StatusOK, response{
+				ID:          erasure.ID(),
+				CustomerID:  erasure.CustomerID(),
+				Type:        erasure.Type(),
+				RequestedBy: erasure.RequestedBy(),
+				Sources:     erasure.Sources(),
+				CompletedAt: erasure.CompletedAt(),
+			})
+		},
+	}
+}
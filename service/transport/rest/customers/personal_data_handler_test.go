@@ -0,0 +1,85 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/privacy"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalDataHandlers(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	adminID, customerID := uuid.New(), uuid.New()
+
+	for id, username := range map[uuid.UUID]string{adminID: "admin@example.org", customerID: "customer@example.org"} {
+		postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+			"uuid":       id,
+			"username":   username,
+			"password":   "abc123",
+			"created_at": time.Now(),
+			"updated_at": time.Now(),
+		})
+	}
+
+	if err := repo.GrantRole(context.Background(), adminID, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	s := privacy.NewService(postgres.NewPersonalDataRequestRepository(testEnv.DB()))
+	s.Register("customer", repo)
+
+	export := customers.NewExportPersonalDataHandler(s, testEnv.JWT(), newKeyAuthenticator(testEnv))
+	erase := customers.NewErasePersonalDataHandler(s, testEnv.JWT(), newKeyAuthenticator(testEnv))
+	customerURL := "/customers/" + customerID.String() + "/personal-data"
+	unknownURL := "/customers/" + uuid.New().String() + "/personal-data"
+
+	_, resp := resttest.RequestWithHeaders(
+		t, http.MethodGet, customerURL, export, nil, resttest.AuthHeader(t, testEnv, customerID), testEnv,
+	)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	_, resp = resttest.RequestWithHeaders(
+		t, http.MethodGet, unknownURL, export, nil, resttest.AuthHeader(t, testEnv, adminID), testEnv,
+	)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	data, resp := resttest.RequestWithHeaders(
+		t, http.MethodGet, customerURL, export, nil, resttest.AuthHeader(t, testEnv, adminID), testEnv,
+	)
+	if assert.Equal(t, http.StatusOK, resp.Code) {
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "customer-"+customerID.String()+".json")
+		assert.Equal(t, customerID.String(), data.Path("customer_id").Data().(string))
+		assert.Equal(t, "customer@example.org", data.Path("data.customer.username").Data().(string))
+	}
+
+	_, resp = resttest.RequestWithHeaders(
+		t, http.MethodDelete, customerURL, erase, nil, resttest.AuthHeader(t, testEnv, customerID), testEnv,
+	)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	data, resp = resttest.RequestWithHeaders(
+		t, http.MethodDelete, customerURL, erase, nil, resttest.AuthHeader(t, testEnv, adminID), testEnv,
+	)
+	if assert.Equal(t, http.StatusOK, resp.Code) {
+		assert.Equal(t, privacy.RequestErasure, data.Path("type").Data().(string))
+		assert.Equal(t, adminID.String(), data.Path("requested_by").Data().(string))
+	}
+
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{
+		"uuid":     customerID.String(),
+		"username": "erased:" + customerID.String(),
+	})
+}
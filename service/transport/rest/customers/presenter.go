@@ -0,0 +1,32 @@
+package customers
+
+import (
+	"time"
+
+	"github.com/nickbryan/go-template/service/domain/customer"
+)
+
+// customerResponse is how a customer.Customer is represented by every customer endpoint. The password
+// hash is never included.
+type customerResponse struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func newCustomerResponse(c *customer.Customer) customerResponse {
+	return customerResponse{
+		ID:        c.ID().String(),
+		Username:  c.Username(),
+		Version:   c.Version(),
+		CreatedAt: c.CreatedAt(),
+		DeletedAt: c.DeletedAt(),
+	}
+}
+
+// customerLocation is the url that the customer can be shown at.
+func customerLocation(c *customer.Customer) string {
+	return "/customers/" + c.ID().String()
+}
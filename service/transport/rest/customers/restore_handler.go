@@ -0,0 +1,48 @@
+package customers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewRestoreHandler creates a new handler for restoring customers that have been soft deleted. Restoring
+// customers requires customer.PermissionManageCustomers.
+func NewRestoreHandler(repo customer.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}/restore").Methods(http.MethodPost)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManageCustomers},
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			cust, err := repo.Undelete(r.Context(), id)
+			if err != nil {
+				switch {
+				case errors.Is(err, customer.ErrNotFound):
+					w.RespondError(http.StatusNotFound, errCustomerNotFound)
+				case errors.Is(err, customer.ErrUsernameTaken):
+					w.RespondError(http.StatusConflict, err)
+				default:
+					w.RespondInternalError(err)
+				}
+
+				return
+			}
+
+			w.Respond(http.StatusOK, newCustomerResponse(cust))
+		},
+	}
+}
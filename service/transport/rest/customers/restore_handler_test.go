@@ -0,0 +1,134 @@
+package customers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerRestoreHandler(t *testing.T) {
+	t.Parallel()
+
+	activeID := uuid.New()
+	deletedID := uuid.New()
+	reusedID := uuid.New()
+
+	tests := []struct {
+		name   string
+		url    string
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB)
+	}{
+		{
+			name: "restore with invalid id returns error",
+			url:  "/customers/not-a-uuid/restore",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+			},
+		},
+		{
+			name: "restore with unknown id returns not found",
+			url:  "/customers/" + uuid.New().String() + "/restore",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name: "restore of a customer that is not deleted returns not found",
+			url:  "/customers/" + activeID.String() + "/restore",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name: "restore of a customer whose username has been reused returns conflict",
+			url:  "/customers/" + reusedID.String() + "/restore",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusConflict, resp.Code)
+				assert.Equal(t, "username is already taken", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name: "customers can be restored",
+			url:  "/customers/" + deletedID.String() + "/restore",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, deletedID.String(), data.Path("id").Data().(string))
+				assert.Equal(t, "deleted@example.org", data.Path("username").Data().(string))
+				assert.Equal(t, float64(2), data.Path("version").Data().(float64))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+
+			for id, fields := range map[uuid.UUID]map[string]interface{}{
+				activeID:  {"username": "active@example.org"},
+				deletedID: {"username": "deleted@example.org", "deleted_at": time.Now()},
+				reusedID:  {"username": "active@example.org", "deleted_at": time.Now()},
+			} {
+				fields["uuid"] = id
+				fields["password"] = "abc123"
+				fields["created_at"] = time.Now()
+				fields["updated_at"] = time.Now()
+
+				postgrestest.Insert(t, testEnv.DB(), "customers", fields)
+			}
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodPost,
+				tc.url,
+				customers.NewRestoreHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+				nil,
+				newAdminHeader(t, testEnv),
+				testEnv,
+			)
+
+			tc.assert(data, resp, testEnv.DB())
+		})
+	}
+}
+
+func TestCustomerRestoreHandlerRequiresPermission(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(t, testEnv.DB(), time.Now(), "customer@example.org")
+	deletedID := uuid.New()
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       deletedID,
+		"username":   "deleted@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+		"deleted_at": time.Now(),
+	})
+
+	_, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/customers/"+deletedID.String()+"/restore",
+		customers.NewRestoreHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+		nil,
+		resttest.AuthHeader(t, testEnv, ids[0]),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
@@ -0,0 +1,64 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// NewGrantRoleHandler creates a new handler that lets an admin grant a role to a customer.
+func NewGrantRoleHandler(repo customer.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	return newRoleHandler(http.MethodPut, repo.GrantRole, j, keys)
+}
+
+// NewRevokeRoleHandler creates a new handler that lets an admin revoke a role from a customer.
+func NewRevokeRoleHandler(repo customer.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	return newRoleHandler(http.MethodDelete, repo.RevokeRole, j, keys)
+}
+
+// newRoleHandler creates the handlers for /customers/{id}/roles/{role} as they only differ by method and
+// the change they make to the customer.
+func newRoleHandler(
+	method string,
+	change func(ctx context.Context, id uuid.UUID, role string) error,
+	j *app.JWT,
+	keys *apikey.Authenticator,
+) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}/roles/{role}").Methods(method)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManageRoles},
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if err := change(r.Context(), id, mux.Vars(r.Request)["role"]); err != nil {
+				switch {
+				case errors.Is(err, customer.ErrNotFound):
+					w.RespondError(http.StatusNotFound, errCustomerNotFound)
+				case errors.Is(err, customer.ErrUnknownRole):
+					w.RespondError(http.StatusNotFound, err)
+				default:
+					w.RespondInternalError(err)
+				}
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
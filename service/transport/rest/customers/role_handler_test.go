@@ -0,0 +1,82 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerRoleHandlers(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	ctx := context.Background()
+
+	adminID, customerID := uuid.New(), uuid.New()
+
+	for id, username := range map[uuid.UUID]string{adminID: "admin@example.org", customerID: "customer@example.org"} {
+		postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+			"uuid":       id,
+			"username":   username,
+			"password":   "abc123",
+			"created_at": time.Now(),
+			"updated_at": time.Now(),
+		})
+	}
+
+	if err := repo.GrantRole(ctx, adminID, customer.RoleAdmin); err != nil {
+		t.Fatalf("unable to grant role: %v", err)
+	}
+
+	request := func(method, url string, handler rest.Handler, as uuid.UUID) int {
+		t.Helper()
+
+		_, resp := resttest.RequestWithHeaders(t, method, url, handler, nil, resttest.AuthHeader(t, testEnv, as), testEnv)
+
+		return resp.Code
+	}
+
+	grant := customers.NewGrantRoleHandler(repo, testEnv.JWT(), newKeyAuthenticator(testEnv))
+	revoke := customers.NewRevokeRoleHandler(repo, testEnv.JWT(), newKeyAuthenticator(testEnv))
+	customerURL := "/customers/" + customerID.String() + "/roles/"
+
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPut, customerURL+customer.RoleAdmin, grant, customerID))
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPut, customerURL+"unknown", grant, adminID))
+	assert.Equal(
+		t,
+		http.StatusNotFound,
+		request(http.MethodPut, "/customers/"+uuid.New().String()+"/roles/"+customer.RoleAdmin, grant, adminID),
+	)
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPut, customerURL+customer.RoleAdmin, grant, adminID))
+
+	cust, err := repo.FindByID(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.True(t, cust.HasRole(customer.RoleAdmin))
+
+	// The customer is now an admin so they can revoke their own role.
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, customerURL+customer.RoleAdmin, revoke, customerID))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, customerURL+customer.RoleAdmin, revoke, customerID))
+
+	cust, err = repo.FindByID(ctx, customerID)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.False(t, cust.HasRole(customer.RoleAdmin))
+}
@@ -0,0 +1,38 @@
+package customers
+
+import (
+	"context"
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/domain/customer"
+)
+
+var errUserExists = errors.New("customers already exists with the given username")
+
+// usernameUniqueRule checks that no other customer has the username being validated. The
+// customer with the except id is ignored so that customers can keep their own username.
+type usernameUniqueRule struct {
+	storage customer.Repository
+	ctx     context.Context
+	except  uuid.UUID
+}
+
+func (r usernameUniqueRule) Validate(value interface{}) error {
+	value, isNil := validation.Indirect(value)
+	if isNil {
+		return nil
+	}
+
+	c, err := r.storage.FindByUsername(r.ctx, value.(string))
+	if err != nil {
+		return validation.NewInternalError(err)
+	}
+
+	if c != nil && c.ID() != r.except {
+		return errUserExists
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package customers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var (
+	errInvalidID        = errors.New("customer id must be a valid uuid")
+	errCustomerNotFound = errors.New("customer not found")
+)
+
+// parseID reads the customer id from the route variables of the request. It is also the rest.Handler Owner of
+// routes that customers can use on their own account without being granted customer.PermissionManageCustomers.
+func parseID(r rest.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r.Request)["id"])
+	if err != nil {
+		return uuid.Nil, errInvalidID
+	}
+
+	return id, nil
+}
+
+// NewShowHandler creates a new handler for fetching a single customer by id. Customers can fetch themselves
+// while fetching anybody else requires customer.PermissionManageCustomers.
+func NewShowHandler(repo customer.Repository, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}").Methods(http.MethodGet)
+		},
+		Middleware:  rest.RequireAuthentication(j, keys),
+		Permissions: []string{customer.PermissionManageCustomers},
+		Owner:       parseID,
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			cust, err := repo.FindByID(r.Context(), id)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+				return
+			}
+
+			w.Respond(http.StatusOK, newCustomerResponse(cust))
+		},
+	}
+}
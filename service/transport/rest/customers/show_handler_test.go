@@ -0,0 +1,255 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerShowHandler(t *testing.T) {
+	t.Parallel()
+
+	existingID := uuid.New()
+	deletedID := uuid.New()
+
+	tests := []struct {
+		name   string
+		url    string
+		setup  func(db *app.DB)
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder)
+	}{
+		{
+			name: "show with invalid id returns error",
+			url:  "/customers/not-a-uuid",
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "customer id must be a valid uuid", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name: "show with unknown id returns not found",
+			url:  "/customers/" + uuid.New().String(),
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+				assert.Equal(t, "customer not found", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name: "show with deleted customer returns not found",
+			url:  "/customers/" + deletedID.String(),
+			setup: func(db *app.DB) {
+				postgrestest.Insert(t, db, "customers", map[string]interface{}{
+					"uuid":       deletedID,
+					"username":   "deleted@example.org",
+					"password":   "abc123",
+					"created_at": time.Now(),
+					"updated_at": time.Now(),
+					"deleted_at": time.Now(),
+				})
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name: "customers can be shown",
+			url:  "/customers/" + existingID.String(),
+			setup: func(db *app.DB) {
+				query := db.QB().
+					Insert("customers").
+					Columns("uuid", "username", "password", "created_at", "updated_at").
+					Values(existingID, "existing@example.org", "abc123", time.Now(), time.Now())
+
+				sql, args, err := query.ToSql()
+				if err != nil {
+					t.Fatalf("unable to convert query to SQL: %v", err)
+				}
+
+				_, err = db.Conn().Exec(context.Background(), sql, args...)
+				if err != nil {
+					t.Fatalf("unable to execute query: %v", err)
+				}
+			},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, existingID.String(), data.Path("id").Data().(string))
+				assert.Equal(t, "existing@example.org", data.Path("username").Data().(string))
+				assert.Equal(t, float64(1), data.Path("version").Data().(float64))
+				assert.False(t, data.Exists("password"))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+
+			if tc.setup != nil {
+				tc.setup(testEnv.DB())
+			}
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodGet,
+				tc.url,
+				customers.NewShowHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+				nil,
+				newAdminHeader(t, testEnv),
+				testEnv,
+			)
+
+			tc.assert(data, resp)
+		})
+	}
+}
+
+func TestCustomerShowHandlerRequiresAuthentication(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+
+	data, resp := resttest.Request(
+		t,
+		http.MethodGet,
+		"/customers/"+uuid.New().String(),
+		customers.NewShowHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv)),
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(
+		t,
+		"authorization header must contain a bearer token or an api key",
+		data.Path("error.message").Data().(string),
+	)
+}
+
+func TestCustomerShowHandlerAuthorization(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(t, testEnv.DB(), time.Now(), "owner@example.org", "other@example.org")
+	handler := customers.NewShowHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv))
+	url := "/customers/" + ids[0].String()
+
+	show := func(header http.Header) int {
+		t.Helper()
+
+		_, resp := resttest.RequestWithHeaders(t, http.MethodGet, url, handler, nil, header, testEnv)
+
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusOK, show(resttest.AuthHeader(t, testEnv, ids[0])), "customers can show themselves")
+	assert.Equal(
+		t,
+		http.StatusForbidden,
+		show(resttest.AuthHeader(t, testEnv, ids[1])),
+		"customers can not show other customers",
+	)
+	assert.Equal(t, http.StatusOK, show(newAdminHeader(t, testEnv)), "admins can show other customers")
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/customers/not-a-uuid",
+		handler,
+		nil,
+		resttest.AuthHeader(t, testEnv, ids[1]),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, "malformed ids are rejected before permissions are checked")
+	assert.Equal(t, "customer id must be a valid uuid", data.Path("error.message").Data().(string))
+}
+
+func TestCustomerShowHandlerAcceptsAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	customerID := uuid.New()
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       customerID,
+		"username":   "partner@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	k, key, err := apikey.New(customerID, "partner integration", nil, nil)
+	if err != nil {
+		t.Fatalf("unable to create api key: %v", err)
+	}
+
+	if err := postgres.NewAPIKeyRepository(testEnv.DB()).Add(context.Background(), k); err != nil {
+		t.Fatalf("unable to add api key: %v", err)
+	}
+
+	handler := customers.NewShowHandler(postgres.NewCustomerRepository(testEnv.DB()), testEnv.JWT(), newKeyAuthenticator(testEnv))
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/customers/"+customerID.String(),
+		handler,
+		nil,
+		http.Header{"Authorization": []string{"ApiKey " + key}},
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+	assert.Equal(t, "partner@example.org", data.Path("username").Data().(string))
+
+	if err := postgres.NewAPIKeyRepository(testEnv.DB()).Revoke(context.Background(), customerID, k.ID()); err != nil {
+		t.Fatalf("unable to revoke api key: %v", err)
+	}
+
+	_, resp = resttest.RequestWithHeaders(
+		t,
+		http.MethodGet,
+		"/customers/"+customerID.String(),
+		handler,
+		nil,
+		http.Header{"Authorization": []string{"ApiKey " + key}},
+		testEnv,
+	)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+// newAdminHeader adds an admin to the test database and returns an Authorization header for them.
+func newAdminHeader(t *testing.T, e *app.Environment) http.Header {
+	t.Helper()
+
+	id := uuid.New()
+
+	postgrestest.Insert(t, e.DB(), "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   "admin-" + id.String() + "@example.org",
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	return resttest.AdminHeader(t, e, id)
+}
+
+// newKeyAuthenticator creates an apikey.Authenticator backed by the test database.
+func newKeyAuthenticator(e *app.Environment) *apikey.Authenticator {
+	return apikey.NewAuthenticator(postgres.NewAPIKeyRepository(e.DB()))
+}
@@ -0,0 +1,157 @@
+package customers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errIncorrectPassword = errors.New("current password is incorrect")
+
+// NewUpdateHandler creates a new handler for updating the mutable fields of a customer. Only the
+// fields present in the request are changed. The version of the customer that the client last saw
+// must be given so that concurrent updates are rejected rather than silently overwritten.
+// Customers can update themselves while updating anybody else requires
+// customer.PermissionManageCustomers. Changing the password requires the current password and
+// revokes every session of the customer so that a stolen token can not be used to keep access.
+func NewUpdateHandler(
+	repo customer.Repository,
+	hasher customer.PasswordHasher,
+	sessions session.Repository,
+	j *app.JWT,
+) rest.Handler {
+	type request struct {
+		Username        *string `json:"username"`
+		Password        *string `json:"password"`
+		CurrentPassword string  `json:"current_password"`
+		Version         *int    `json:"version"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/{id}").Methods(http.MethodPatch)
+		},
+		Middleware:  rest.RequireJWTAuthentication(j),
+		Permissions: []string{customer.PermissionManageCustomers},
+		Owner:       parseID,
+		Func: func(w rest.Responder, r rest.Request) {
+			id, err := parseID(r)
+			if err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			var req request
+
+			if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(
+					&req.Username,
+					validation.NilOrNotEmpty,
+					is.Email,
+					usernameUniqueRule{storage: repo, ctx: r.Context(), except: id},
+				),
+				validation.Field(
+					&req.Password,
+					validation.NilOrNotEmpty,
+					validation.RuneLength(customer.MinPasswordLength, customer.MaxPasswordLength),
+				),
+				validation.Field(&req.CurrentPassword, validation.Required.When(req.Password != nil)),
+				validation.Field(&req.Version, validation.NotNil),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			cust, err := repo.FindByID(r.Context(), id)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+				return
+			}
+
+			if cust.Version() != *req.Version {
+				w.RespondError(http.StatusConflict, customer.ErrVersionConflict)
+
+				return
+			}
+
+			if req.Username != nil {
+				if err := cust.ChangeUsername(*req.Username); err != nil {
+					respondChangeFailed(w, err)
+
+					return
+				}
+			}
+
+			if req.Password != nil {
+				if !cust.HasPassword(req.CurrentPassword, hasher) {
+					w.RespondError(http.StatusUnprocessableEntity, errIncorrectPassword)
+
+					return
+				}
+
+				if err := cust.ChangePassword(*req.Password, hasher); err != nil {
+					respondChangeFailed(w, err)
+
+					return
+				}
+			}
+
+			updated, err := repo.Update(r.Context(), cust)
+			if err != nil {
+				if errors.Is(err, customer.ErrVersionConflict) || errors.Is(err, customer.ErrUsernameTaken) {
+					w.RespondError(http.StatusConflict, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if req.Password != nil {
+				if err := sessions.RevokeCustomer(r.Context(), updated.ID()); err != nil {
+					w.RespondInternalError(err)
+
+					return
+				}
+			}
+
+			w.Respond(http.StatusOK, newCustomerResponse(updated))
+		},
+	}
+}
+
+// respondChangeFailed responds with the error returned when a customer.Customer can not be created or rejects
+// a change. Errors that describe an invalid value are the fault of the client, anything else is an internal error.
+func respondChangeFailed(w rest.Responder, err error) {
+	if errors.Is(err, customer.ErrEmptyUsername) || errors.Is(err, customer.ErrPasswordLength) {
+		w.RespondError(http.StatusUnprocessableEntity, err)
+
+		return
+	}
+
+	w.RespondInternalError(err)
+}
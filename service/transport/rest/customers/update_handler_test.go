@@ -0,0 +1,317 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/password"
+	"github.com/nickbryan/go-template/service/domain/session"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUpdateHandler(e *app.Environment) rest.Handler {
+	return customers.NewUpdateHandler(
+		postgres.NewCustomerRepository(e.DB()),
+		e.PasswordHasher(),
+		postgres.NewRefreshTokenRepository(e.DB()),
+		e.JWT(),
+	)
+}
+
+func hashPassword(t *testing.T, e *app.Environment, password string) string {
+	t.Helper()
+
+	hash, err := e.PasswordHasher().Hash(password)
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+
+	return hash
+}
+
+func TestCustomerUpdateHandler(t *testing.T) {
+	t.Parallel()
+
+	type payload map[string]interface{}
+
+	existingID := uuid.New()
+	existingURL := "/customers/" + existingID.String()
+
+	tests := []struct {
+		name   string
+		url    string
+		input  payload
+		assert func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB)
+	}{
+		{
+			name:  "update with invalid id returns error",
+			url:   "/customers/not-a-uuid",
+			input: payload{"username": "new@example.org", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "customer id must be a valid uuid", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name:  "update with unknown id returns not found",
+			url:   "/customers/" + uuid.New().String(),
+			input: payload{"username": "new@example.org", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			name:  "update without version returns error",
+			url:   existingURL,
+			input: payload{"username": "new@example.org"},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "is required", data.Path("error.validation_errors.version").Data().(string))
+			},
+		},
+		{
+			name:  "update with invalid email for username returns error",
+			url:   existingURL,
+			input: payload{"username": "this is not an email", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"must be a valid email address",
+					data.Path("error.validation_errors.username").Data().(string),
+				)
+			},
+		},
+		{
+			name:  "update with password too short returns error",
+			url:   existingURL,
+			input: payload{"password": "123", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"the length must be between 6 and 256",
+					data.Path("error.validation_errors.password").Data().(string),
+				)
+			},
+		},
+		{
+			name:  "update with password too short in characters but not bytes returns error",
+			url:   existingURL,
+			input: payload{"password": "\u00e9\u00e9\u00e9", "current_password": "S3cr3t!", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"the length must be between 6 and 256",
+					data.Path("error.validation_errors.password").Data().(string),
+				)
+			},
+		},
+		{
+			name:  "update password without the current password returns error",
+			url:   existingURL,
+			input: payload{"password": "N3wS3cr3t", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(t, "cannot be blank", data.Path("error.validation_errors.current_password").Data().(string))
+			},
+		},
+		{
+			name:  "update password with incorrect current password returns error",
+			url:   existingURL,
+			input: payload{"password": "N3wS3cr3t", "current_password": "wrong", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+				assert.Equal(t, "current password is incorrect", data.Path("error.message").Data().(string))
+			},
+		},
+		{
+			name:  "update to username of another customer returns error",
+			url:   existingURL,
+			input: payload{"username": "taken@example.org", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusBadRequest, resp.Code)
+				assert.Equal(
+					t,
+					"customers already exists with the given username",
+					data.Path("error.validation_errors.username").Data().(string),
+				)
+			},
+		},
+		{
+			name:  "update with stale version returns conflict",
+			url:   existingURL,
+			input: payload{"username": "new@example.org", "version": 1},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusConflict, resp.Code)
+				postgrestest.AssertDatabaseHas(t, db, "customers", map[string]string{
+					"uuid":     existingID.String(),
+					"username": "existing@example.org",
+					"version":  "2",
+				})
+			},
+		},
+		{
+			name:  "update can keep the customers own username",
+			url:   existingURL,
+			input: payload{"username": "existing@example.org", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, _ *app.DB) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, float64(3), data.Path("version").Data().(float64))
+			},
+		},
+		{
+			name:  "customers username can be updated",
+			url:   existingURL,
+			input: payload{"username": "new@example.org", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, "new@example.org", data.Path("username").Data().(string))
+				assert.Equal(t, float64(3), data.Path("version").Data().(float64))
+				postgrestest.AssertDatabaseHas(t, db, "customers", map[string]string{
+					"uuid":     existingID.String(),
+					"username": "new@example.org",
+					"version":  "3",
+				})
+			},
+		},
+		{
+			name:  "customers password can be updated",
+			url:   existingURL,
+			input: payload{"password": "N3wS3cr3t", "current_password": "S3cr3t!", "version": 2},
+			assert: func(data *gabs.Container, resp *httptest.ResponseRecorder, db *app.DB) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+
+				cust, err := postgres.NewCustomerRepository(db).FindByID(context.Background(), existingID)
+				if err != nil {
+					t.Fatalf("unable to find customer: %v", err)
+				}
+
+				// Hashes describe their own parameters so any hasher that knows the algorithm can verify them.
+				assert.True(t, cust.HasPassword("N3wS3cr3t", password.NewHasher(password.Argon2id{}, password.Bcrypt{})))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testEnv := app.NewTestEnvironment(t, true)
+
+			postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+				"uuid":       existingID,
+				"username":   "existing@example.org",
+				"password":   hashPassword(t, testEnv, "S3cr3t!"),
+				"version":    2,
+				"created_at": time.Now(),
+				"updated_at": time.Now(),
+			})
+			ids := seedCustomers(t, testEnv.DB(), time.Now(), "taken@example.org")
+
+			data, resp := resttest.RequestWithHeaders(
+				t,
+				http.MethodPatch,
+				tc.url,
+				newUpdateHandler(testEnv),
+				tc.input,
+				resttest.AdminHeader(t, testEnv, ids[0]),
+				testEnv,
+			)
+
+			tc.assert(data, resp, testEnv.DB())
+		})
+	}
+}
+
+func TestCustomerUpdateHandlerAuthorization(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	ids := seedCustomers(t, testEnv.DB(), time.Now(), "owner@example.org", "other@example.org")
+	handler := newUpdateHandler(testEnv)
+	url := "/customers/" + ids[0].String()
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPatch,
+		url,
+		handler,
+		map[string]interface{}{"username": "stolen@example.org", "version": 1},
+		resttest.AuthHeader(t, testEnv, ids[1]),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusForbidden, resp.Code, data)
+	postgrestest.AssertDatabaseHas(t, testEnv.DB(), "customers", map[string]string{
+		"uuid":     ids[0].String(),
+		"username": "owner@example.org",
+	})
+
+	data, resp = resttest.RequestWithHeaders(
+		t,
+		http.MethodPatch,
+		url,
+		handler,
+		map[string]interface{}{"username": "renamed@example.org", "version": 1},
+		resttest.AuthHeader(t, testEnv, ids[0]),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+}
+
+func TestCustomerUpdateHandlerPasswordChangeRevokesSessions(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	sessions := postgres.NewRefreshTokenRepository(testEnv.DB())
+	id := uuid.New()
+
+	postgrestest.Insert(t, testEnv.DB(), "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   "owner@example.org",
+		"password":   hashPassword(t, testEnv, "S3cr3t!"),
+		"version":    1,
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	token, _, err := session.New(id, time.Hour)
+	if err != nil {
+		t.Fatalf("unable to create refresh token: %v", err)
+	}
+
+	if err := sessions.Add(context.Background(), token); err != nil {
+		t.Fatalf("unable to add refresh token: %v", err)
+	}
+
+	data, resp := resttest.RequestWithHeaders(
+		t,
+		http.MethodPatch,
+		"/customers/"+id.String(),
+		newUpdateHandler(testEnv),
+		map[string]interface{}{"password": "N3wS3cr3t", "current_password": "S3cr3t!", "version": 1},
+		resttest.AuthHeader(t, testEnv, id),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusOK, resp.Code, data)
+
+	revoked, err := sessions.FindByHash(context.Background(), token.Hash())
+	if err != nil {
+		t.Fatalf("unable to find refresh token: %v", err)
+	}
+
+	assert.NotNil(t, revoked.RevokedAt())
+}
@@ -0,0 +1,133 @@
+package customers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/domain/verification"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var (
+	errInvalidVerificationToken = errors.New("verification token is invalid or has expired")
+	errAlreadyVerified          = errors.New("customer has already been verified")
+)
+
+// NewVerifyHandler creates a new handler that verifies the customer a verification token was sent to.
+// GET requests read the token from the query string so that the link in the email can be followed
+// directly, POST requests read it from the body.
+func NewVerifyHandler(tokens verification.Repository) rest.Handler {
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/verify").Methods(http.MethodGet, http.MethodPost)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			var req request
+
+			if r.Method == http.MethodGet {
+				req.Token = r.URL.Query().Get("token")
+			} else if err := r.Decode(&req); err != nil {
+				w.RespondError(http.StatusBadRequest, err)
+
+				return
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Token, validation.Required),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			token, err := tokens.FindByHash(r.Context(), secret.Hash(req.Token))
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if token == nil || !token.IsUsable(time.Now()) {
+				w.RespondError(http.StatusBadRequest, errInvalidVerificationToken)
+
+				return
+			}
+
+			if err := tokens.Verify(r.Context(), token); err != nil {
+				// The customer may have been deleted since the token was sent.
+				if errors.Is(err, verification.ErrTokenUsed) || errors.Is(err, customer.ErrNotFound) {
+					w.RespondError(http.StatusBadRequest, errInvalidVerificationToken)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+}
+
+// NewResendVerificationHandler creates a new handler that emails a new verification token to the
+// authenticated customer. Customers are throttled so that the endpoint can not be used to flood an inbox.
+func NewResendVerificationHandler(repo customer.Repository, sender *verification.Sender, j *app.JWT) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/customers/verify/resend").Methods(http.MethodPost)
+		},
+		Middleware: rest.RequireJWTAuthentication(j),
+		Func: func(w rest.Responder, r rest.Request) {
+			customerID, _ := r.CustomerID()
+
+			cust, err := repo.FindByID(r.Context(), customerID)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			if cust == nil {
+				w.RespondError(http.StatusNotFound, errCustomerNotFound)
+
+				return
+			}
+
+			if cust.IsVerified() {
+				w.RespondError(http.StatusConflict, errAlreadyVerified)
+
+				return
+			}
+
+			wait, err := sender.Resend(r.Context(), cust)
+			if err != nil {
+				if errors.Is(err, verification.ErrResendTooSoon) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					w.RespondError(http.StatusTooManyRequests, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/mail/mailtest"
+	"github.com/nickbryan/go-template/service/app/secret"
+	"github.com/nickbryan/go-template/service/domain/verification"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres/postgrestest"
+	"github.com/nickbryan/go-template/service/transport/rest/customers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// seededUsername is the username given to customers inserted by seedUnverifiedCustomer.
+func seededUsername(id uuid.UUID) string {
+	return id.String() + "@example.org"
+}
+
+// seedUnverifiedCustomer inserts a customer that has not verified their username.
+func seedUnverifiedCustomer(t *testing.T, db *app.DB) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+
+	postgrestest.Insert(t, db, "customers", map[string]interface{}{
+		"uuid":       id,
+		"username":   seededUsername(id),
+		"password":   "abc123",
+		"created_at": time.Now(),
+		"updated_at": time.Now(),
+	})
+
+	return id
+}
+
+// issueVerification adds a verification token for a customer inserted by seedUnverifiedCustomer and returns
+// its secret.
+func issueVerification(t *testing.T, repo verification.Repository, customerID uuid.UUID, ttl time.Duration) string {
+	t.Helper()
+
+	token, s, err := verification.New(customerID, seededUsername(customerID), ttl)
+	if err != nil {
+		t.Fatalf("unable to create verification token: %v", err)
+	}
+
+	if err := repo.Add(context.Background(), token); err != nil {
+		t.Fatalf("unable to add verification token: %v", err)
+	}
+
+	return s
+}
+
+func TestCustomerVerifyHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	tokens := postgres.NewVerificationRepository(testEnv.DB())
+	handler := customers.NewVerifyHandler(tokens)
+
+	isVerified := func(id uuid.UUID) bool {
+		cust, err := repo.FindByID(context.Background(), id)
+		if err != nil {
+			t.Fatalf("unable to find customer: %v", err)
+		}
+
+		return cust.IsVerified()
+	}
+
+	expiredID := seedUnverifiedCustomer(t, testEnv.DB())
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/customers/verify", handler, map[string]string{
+		"token": issueVerification(t, tokens, expiredID, -time.Minute),
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+	assert.Equal(t, "verification token is invalid or has expired", data.Path("error.message").Data().(string))
+	assert.False(t, isVerified(expiredID))
+
+	postID := seedUnverifiedCustomer(t, testEnv.DB())
+	s := issueVerification(t, tokens, postID, time.Hour)
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/customers/verify", handler, map[string]string{
+		"token": s,
+	}, testEnv)
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+	assert.True(t, isVerified(postID))
+
+	// Tokens are single use.
+	data, resp = resttest.RequestWithData(t, http.MethodPost, "/customers/verify", handler, map[string]string{
+		"token": s,
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+
+	getID := seedUnverifiedCustomer(t, testEnv.DB())
+	query := url.Values{"token": []string{issueVerification(t, tokens, getID, time.Hour)}}
+	data, resp = resttest.Request(t, http.MethodGet, "/customers/verify?"+query.Encode(), handler, testEnv)
+	assert.Equal(t, http.StatusNoContent, resp.Code, data)
+	assert.True(t, isVerified(getID))
+}
+
+func TestCustomerVerifyHandlerRejectsTokensForAPreviousUsername(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	repo := postgres.NewCustomerRepository(testEnv.DB())
+	tokens := postgres.NewVerificationRepository(testEnv.DB())
+
+	id := seedUnverifiedCustomer(t, testEnv.DB())
+	s := issueVerification(t, tokens, id, time.Hour)
+
+	cust, err := repo.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	if err := cust.ChangeUsername("changed@example.org"); err != nil {
+		t.Fatalf("unable to change username: %v", err)
+	}
+
+	if _, err := repo.Update(context.Background(), cust); err != nil {
+		t.Fatalf("unable to update customer: %v", err)
+	}
+
+	handler := customers.NewVerifyHandler(tokens)
+	data, resp := resttest.RequestWithData(t, http.MethodPost, "/customers/verify", handler, map[string]string{
+		"token": s,
+	}, testEnv)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, data)
+	assert.Equal(t, "verification token is invalid or has expired", data.Path("error.message").Data().(string))
+
+	cust, err = repo.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unable to find customer: %v", err)
+	}
+
+	assert.False(t, cust.IsVerified())
+}
+
+func TestCustomerResendVerificationHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	tokens := postgres.NewVerificationRepository(testEnv.DB())
+	mailer := &mailtest.Mailer{}
+	handler := customers.NewResendVerificationHandler(
+		postgres.NewCustomerRepository(testEnv.DB()),
+		verification.NewSender(tokens, mailer, "http://localhost/customers/verify", time.Hour, time.Minute),
+		testEnv.JWT(),
+	)
+
+	customerID := seedUnverifiedCustomer(t, testEnv.DB())
+	header := resttest.AuthHeader(t, testEnv, customerID)
+
+	data, resp := resttest.RequestWithHeaders(t, http.MethodPost, "/customers/verify/resend", handler, nil, header, testEnv)
+	assert.Equal(t, http.StatusAccepted, resp.Code, data)
+	assert.Len(t, mailer.Messages(), 1)
+
+	// A second request within the resend interval is throttled.
+	data, resp = resttest.RequestWithHeaders(t, http.MethodPost, "/customers/verify/resend", handler, nil, header, testEnv)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code, data)
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	assert.Len(t, mailer.Messages(), 1)
+
+	verifiedID := seedUnverifiedCustomer(t, testEnv.DB())
+	token, err := tokens.FindByHash(context.Background(), secret.Hash(issueVerification(t, tokens, verifiedID, time.Hour)))
+	if err != nil {
+		t.Fatalf("unable to find verification token: %v", err)
+	}
+
+	if err := tokens.Verify(context.Background(), token); err != nil {
+		t.Fatalf("unable to verify customer: %v", err)
+	}
+
+	data, resp = resttest.RequestWithHeaders(
+		t,
+		http.MethodPost,
+		"/customers/verify/resend",
+		handler,
+		nil,
+		resttest.AuthHeader(t, testEnv, verifiedID),
+		testEnv,
+	)
+	assert.Equal(t, http.StatusConflict, resp.Code, data)
+}
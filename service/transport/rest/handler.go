@@ -1,13 +1,12 @@
 package rest
 
 import (
-	"errors"
 	"net/http"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/nickbryan/go-template/service/app"
-	"go.uber.org/zap"
 )
 
 // Responder wraps a http.ResponseWriter so that we can add our own helper methods.
@@ -22,6 +21,10 @@ type Responder interface {
 	// A http.StatusInternalServerError will be written if setting of the json values fails.
 	RespondError(status int, err error)
 
+	// RespondInternalError will log the given error and write a generic http.StatusInternalServerError
+	// message to the http.ResponseWriter so that we do not leak internal details to the client.
+	RespondInternalError(err error)
+
 	// RespondValidationFailed will write the give error message to the http.ResponseWriter as formatted JSON.
 	// A http.StatusInternalServerError will be written if setting of the json values fails.
 	RespondValidationFailed(errors validation.Errors)
@@ -38,52 +41,40 @@ type Handler struct {
 	// Middleware allows wrapping the Func in middleware handlers.
 	Middleware func(next ServiceFunc) ServiceFunc
 
+	// Permissions that the authenticated customer must have been granted to use the handler. They are
+	// checked after the Middleware has authenticated the request.
+	Permissions []string
+
+	// Owner returns the id of the customer that owns the resource in the request. When it is set the owner can
+	// use the handler without the Permissions, which are then only required to act on other customers. If the
+	// request does not identify an owner, such as when an id is malformed, the error is responded to with a 400
+	// before any permissions are checked.
+	Owner func(r Request) (uuid.UUID, error)
+
 	// Func will be registered with the router.
 	Func ServiceFunc
 }
 
-// AddRoute adds the handler's route the to the router.
-func (h Handler) AddRoute(r *mux.Router, e *app.Environment) {
+// AddRoute adds the handler's route the to the router. The Authorizer enforces the Permissions of the handler.
+func (h Handler) AddRoute(r *mux.Router, e *app.Environment, a Authorizer) {
 	fnc := h.Func
 
+	if len(h.Permissions) > 0 {
+		fnc = requirePermissions(a, h)(fnc)
+	}
+
 	if h.Middleware != nil {
 		fnc = h.Middleware(fnc)
 	}
 
 	h.Route(r.NewRoute().HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		recoverPanicMiddleware(fnc, e)(
-			&responder{
-				ResponseWriter: w,
-				logger:         e.Logger(),
-			},
-			Request{r},
-		)
-	}))
-}
+		logger := app.ContextLogger(r.Context(), e.Logger())
+		resp := responderFor(w, logger)
 
-// ErrUnknown will be logged when the panic recovery has an unknown type.
-var ErrUnknown = errors.New("unknown error")
-
-func recoverPanicMiddleware(next ServiceFunc, e *app.Environment) ServiceFunc {
-	return func(w Responder, r Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				var err error
-
-				switch e := rec.(type) {
-				case string:
-					err = errors.New(e)
-				case error:
-					err = e
-				default:
-					err = ErrUnknown
-				}
-
-				w.WriteHeader(http.StatusInternalServerError)
-				e.Logger().Error("application panicked", zap.Error(err))
-			}
-		}()
-
-		next(w, r)
-	}
+		if route := mux.CurrentRoute(r); route != nil {
+			resp.route, _ = route.GetPathTemplate()
+		}
+
+		fnc(resp, Request{Request: r, logger: logger})
+	}))
 }
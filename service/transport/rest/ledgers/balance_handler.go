@@ -0,0 +1,42 @@
+package ledgers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+// currency is the currency that every money.Amount is held in.
+const currency = "GBP"
+
+// NewBalanceHandler creates a new handler for fetching the balance of the authenticated customer in pence.
+func NewBalanceHandler(l *ledger.Ledger, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type response struct {
+		Balance  money.Amount `json:"balance"`
+		Currency string       `json:"currency"`
+	}
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/ledger/balance").Methods(http.MethodGet)
+		},
+		Middleware: rest.RequireAuthentication(j, keys),
+		Func: func(w rest.Responder, r rest.Request) {
+			customerID, _ := r.CustomerID()
+
+			balance, err := l.Balance(r.Context(), customerID)
+			if err != nil {
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			w.Respond(http.StatusOK, response{Balance: balance, Currency: currency})
+		},
+	}
+}
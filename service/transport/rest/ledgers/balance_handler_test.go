@@ -0,0 +1,58 @@
+package ledgers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/ledgers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	l := ledger.NewLedger(postgres.NewLedgerRepository(testEnv.DB()))
+	keys := apikey.NewAuthenticator(postgres.NewAPIKeyRepository(testEnv.DB()))
+	customerID := uuid.New()
+
+	balance := func(as uuid.UUID) float64 {
+		t.Helper()
+
+		data, resp := resttest.RequestWithHeaders(
+			t,
+			http.MethodGet,
+			"/ledger/balance",
+			ledgers.NewBalanceHandler(l, testEnv.JWT(), keys),
+			nil,
+			resttest.AuthHeader(t, testEnv, as),
+			testEnv,
+		)
+
+		assert.Equal(t, http.StatusOK, resp.Code, data)
+		assert.Equal(t, "GBP", data.Path("currency").Data().(string))
+
+		return data.Path("balance").Data().(float64)
+	}
+
+	assert.Equal(t, float64(0), balance(customerID), "customers without an account have nothing")
+
+	if _, err := l.Credit(context.Background(), customerID, 5*money.Pound, "top up"); err != nil {
+		t.Fatalf("unable to credit customer: %v", err)
+	}
+
+	if _, err := l.Debit(context.Background(), customerID, 120*money.Penny, "purchase"); err != nil {
+		t.Fatalf("unable to debit customer: %v", err)
+	}
+
+	assert.Equal(t, float64(380), balance(customerID))
+	assert.Equal(t, float64(0), balance(uuid.New()), "customers can only see their own balance")
+}
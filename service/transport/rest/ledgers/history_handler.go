@@ -0,0 +1,113 @@
+package ledgers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/transport/rest"
+)
+
+var errLimitOutOfRange = fmt.Errorf("must be between 1 and %d", ledger.MaxHistoryLimit)
+
+// NewHistoryHandler creates a new handler for paginating through the transactions of the authenticated
+// customer, newest first. Credits have a positive amount and debits a negative one.
+func NewHistoryHandler(l *ledger.Ledger, j *app.JWT, keys *apikey.Authenticator) rest.Handler {
+	type request struct {
+		Limit  string `json:"limit"`
+		Cursor string `json:"cursor"`
+	}
+
+	type transactionResponse struct {
+		EntryID     string       `json:"entry_id"`
+		Description string       `json:"description"`
+		Amount      money.Amount `json:"amount"`
+		Currency    string       `json:"currency"`
+		CreatedAt   time.Time    `json:"created_at"`
+	}
+
+	type response struct {
+		Data       []transactionResponse `json:"data"`
+		NextCursor string                `json:"next_cursor,omitempty"`
+	}
+
+	limitInRange := validation.By(func(value interface{}) error {
+		limit, err := strconv.Atoi(value.(string))
+		if err != nil || limit < 1 || limit > ledger.MaxHistoryLimit {
+			return errLimitOutOfRange
+		}
+
+		return nil
+	})
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/ledger/transactions").Methods(http.MethodGet)
+		},
+		Middleware: rest.RequireAuthentication(j, keys),
+		Func: func(w rest.Responder, r rest.Request) {
+			q := r.URL.Query()
+
+			req := request{
+				Limit:  q.Get("limit"),
+				Cursor: q.Get("cursor"),
+			}
+
+			if errs := app.Validate(&req,
+				validation.Field(&req.Limit, is.Int, limitInRange),
+			); errs != nil {
+				w.RespondValidationFailed(errs)
+
+				return
+			}
+
+			opts := ledger.HistoryOptions{Cursor: req.Cursor}
+
+			// The limit has been validated above so we can safely ignore the conversion error.
+			if req.Limit != "" {
+				opts.Limit, _ = strconv.Atoi(req.Limit)
+			}
+
+			customerID, _ := r.CustomerID()
+
+			page, err := l.History(r.Context(), customerID, opts)
+			if err != nil {
+				if errors.Is(err, ledger.ErrInvalidCursor) {
+					w.RespondError(http.StatusBadRequest, err)
+
+					return
+				}
+
+				w.RespondInternalError(err)
+
+				return
+			}
+
+			resp := response{
+				Data:       make([]transactionResponse, 0, len(page.Lines)),
+				NextCursor: page.NextCursor,
+			}
+
+			for _, line := range page.Lines {
+				resp.Data = append(resp.Data, transactionResponse{
+					EntryID:     line.EntryID.String(),
+					Description: line.Description,
+					Amount:      line.Amount,
+					Currency:    currency,
+					CreatedAt:   line.CreatedAt,
+				})
+			}
+
+			w.Respond(http.StatusOK, resp)
+		},
+	}
+}
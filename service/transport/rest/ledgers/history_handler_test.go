@@ -0,0 +1,69 @@
+package ledgers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/app/money"
+	"github.com/nickbryan/go-template/service/domain/apikey"
+	"github.com/nickbryan/go-template/service/domain/ledger"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
+	"github.com/nickbryan/go-template/service/transport/rest/ledgers"
+	"github.com/nickbryan/go-template/service/transport/rest/resttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	t.Parallel()
+
+	testEnv := app.NewTestEnvironment(t, true)
+	l := ledger.NewLedger(postgres.NewLedgerRepository(testEnv.DB()))
+	keys := apikey.NewAuthenticator(postgres.NewAPIKeyRepository(testEnv.DB()))
+	customerID := uuid.New()
+
+	if _, err := l.Credit(context.Background(), customerID, 5*money.Pound, "top up"); err != nil {
+		t.Fatalf("unable to credit customer: %v", err)
+	}
+
+	if _, err := l.Debit(context.Background(), customerID, 120*money.Penny, "purchase"); err != nil {
+		t.Fatalf("unable to debit customer: %v", err)
+	}
+
+	history := func(query string) (*gabs.Container, int) {
+		t.Helper()
+
+		data, resp := resttest.RequestWithHeaders(
+			t,
+			http.MethodGet,
+			"/ledger/transactions"+query,
+			ledgers.NewHistoryHandler(l, testEnv.JWT(), keys),
+			nil,
+			resttest.AuthHeader(t, testEnv, customerID),
+			testEnv,
+		)
+
+		return data, resp.Code
+	}
+
+	data, code := history("?limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "must be between 1 and 100", data.Path("error.validation_errors.limit").Data().(string))
+
+	_, code = history("?cursor=not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	first, code := history("?limit=1")
+	assert.Equal(t, http.StatusOK, code, first)
+	assert.Equal(t, "purchase", first.Path("data").Index(0).Path("description").Data().(string))
+	assert.Equal(t, float64(-120), first.Path("data").Index(0).Path("amount").Data().(float64))
+
+	second, code := history("?limit=1&cursor=" + first.Path("next_cursor").Data().(string))
+	assert.Equal(t, http.StatusOK, code, second)
+	assert.Equal(t, "top up", second.Path("data").Index(0).Path("description").Data().(string))
+	assert.Equal(t, float64(500), second.Path("data").Index(0).Path("amount").Data().(float64))
+	assert.False(t, second.Exists("next_cursor"))
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewScrapeHandler returns a handler for Prometheus to scrape the metrics gathered by the prometheus.Gatherer.
+// The handler returns status 200 and the metrics in the Prometheus text format.
+func NewScrapeHandler(g prometheus.Gatherer) rest.Handler {
+	h := promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path("/metrics").Methods(http.MethodGet)
+		},
+		Func: func(w rest.Responder, r rest.Request) {
+			h.ServeHTTP(w, r.Request)
+		},
+	}
+}
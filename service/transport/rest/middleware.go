@@ -0,0 +1,252 @@
+package rest
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps the http.Handler of a Server so that it runs for every request, including those that do not
+// match a route. Middleware that only applies to some routes belongs in the Handler.Middleware instead.
+type Middleware func(next http.Handler) http.Handler
+
+// DefaultMiddleware is the standard set of Middleware in the order that it should be used, logging to the
+// logger, recording metrics with the registry and tracing with the tracer provider of the app.Environment. The
+// request id, client IP and trace are added first so that the log entries written by the others carry them,
+// and panics are recovered last so that the requests that panicked are logged, measured and traced with the
+// status written by the recovery.
+func DefaultMiddleware(e *app.Environment, accessLog AccessLogOptions, trustedProxies []*net.IPNet) []Middleware {
+	return []Middleware{
+		RequestID(),
+		ResolveClientIP(trustedProxies),
+		Trace(e.TracerProvider()),
+		LogRequests(e.Logger(), accessLog),
+		RecordMetrics(e.Metrics()),
+		RecoverPanics(e.Logger()),
+	}
+}
+
+// ErrUnknown will be logged when the panic recovery has an unknown type.
+var ErrUnknown = errors.New("unknown error")
+
+// RecoverPanics logs a panic in the next http.Handler and responds with a http.StatusInternalServerError
+// instead of letting the http.Server drop the connection. http.ErrAbortHandler is re-panicked as it is used
+// to abort a response on purpose.
+func RecoverPanics(logger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				var err error
+
+				switch e := rec.(type) {
+				case string:
+					err = errors.New(e)
+				case error:
+					err = e
+				default:
+					err = ErrUnknown
+				}
+
+				w.WriteHeader(http.StatusInternalServerError)
+				app.ContextLogger(r.Context(), logger).Error("application panicked", zap.Error(err))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestID identifies every request by the id in its RequestIDHeader so that it can be correlated with the
+// requests of the client, or by a new id if it has none or the id is not valid. The id is echoed in the
+// RequestIDHeader of the response and added to the context of the request, where it can be retrieved with
+// Request.RequestID, and to the fields of every entry logged within it.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.New().String()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := WithRequestID(r.Context(), id)
+			ctx = app.WithLogFields(ctx, zap.String("request_id", id))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AccessLogOptions control which requests are written to the access log by LogRequests.
+type AccessLogOptions struct {
+	// SampleRate is the fraction of requests, between 0 and 1, that are logged. Requests that fail with a
+	// server error are always logged.
+	SampleRate float64
+
+	// Exclude lists the paths or route templates of requests that are never logged, such as health checks.
+	Exclude []string
+}
+
+// LogRequests writes an access log entry for every request once it has been handled. Requests are logged by
+// their route template, rather than their path, so that entries for the same route can be grouped together.
+// Requests that do not match a route have an empty route.
+func LogRequests(logger *zap.Logger, opts AccessLogOptions) Middleware {
+	excluded := make(map[string]bool, len(opts.Exclude))
+	for _, path := range opts.Exclude {
+		excluded[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			resp := responderFor(w, app.ContextLogger(r.Context(), logger))
+
+			next.ServeHTTP(resp, r)
+
+			status := resp.writtenStatus()
+
+			if excluded[r.URL.Path] || excluded[resp.route] {
+				return
+			}
+
+			if status < http.StatusInternalServerError && rand.Float64() >= opts.SampleRate { //nolint:gosec
+				return
+			}
+
+			app.ContextLogger(r.Context(), logger).Info(
+				"request handled",
+				zap.String("method", r.Method),
+				zap.String("route", resp.route),
+				zap.Int("status", status),
+				zap.Int("bytes", resp.size),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("client_ip", clientIP(r)),
+				zap.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// RecordMetrics counts the requests handled and observes how long they took, labelled by route template, method
+// and status. The metrics are registered with the prometheus.Registerer. Requests that do not match a route have
+// an empty route so that requests for unknown paths do not create new series.
+func RecordMetrics(registerer prometheus.Registerer) Middleware {
+	labels := []string{"route", "method", "status"}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "The number of requests handled.",
+	}, labels)
+
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "How long requests took to handle.",
+		Buckets: prometheus.DefBuckets,
+	}, labels)
+
+	requests = registerOrExisting(registerer, requests).(*prometheus.CounterVec)
+	durations = registerOrExisting(registerer, durations).(*prometheus.HistogramVec)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			resp := wrapResponder(w)
+
+			next.ServeHTTP(resp, r)
+
+			values := []string{resp.route, r.Method, strconv.Itoa(resp.writtenStatus())}
+			requests.WithLabelValues(values...).Inc()
+			durations.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// registerOrExisting registers the collector with the prometheus.Registerer. If an identical collector has
+// already been registered, such as by another Server sharing the registerer, that collector is returned instead
+// so that both count into the same metrics. Any other error is a programming mistake so it panics like
+// prometheus.MustRegister.
+func registerOrExisting(registerer prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	err := registerer.Register(c)
+	if err == nil {
+		return c
+	}
+
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		return registered.ExistingCollector
+	}
+
+	panic(err)
+}
+
+// tracerName identifies the spans started by the rest package.
+const tracerName = "github.com/nickbryan/go-template/service/transport/rest"
+
+// Trace starts a span for every request, continuing the trace of the W3C traceparent header when the request
+// has one. The span is named after the route template once the request has been routed. The trace and span ids
+// are added to the log fields of the request context so that the log entries of a trace can be found.
+func Trace(tp trace.TracerProvider) Middleware {
+	tracer := tp.Tracer(tracerName)
+	propagator := propagation.TraceContext{}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(
+				ctx,
+				"HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...),
+			)
+
+			defer span.End()
+
+			if sc := span.SpanContext(); sc.IsValid() {
+				ctx = app.WithLogFields(
+					ctx,
+					zap.String("trace_id", sc.TraceID().String()),
+					zap.String("span_id", sc.SpanID().String()),
+				)
+			}
+
+			if id := RequestIDFromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+
+			resp := wrapResponder(w)
+
+			next.ServeHTTP(resp, r.WithContext(ctx))
+
+			if resp.route != "" {
+				span.SetName(r.Method + " " + resp.route)
+				span.SetAttributes(semconv.HTTPRouteKey.String(resp.route))
+			}
+
+			status := resp.writtenStatus()
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/transport/rest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// serve sends a GET request for the path through the Server and returns the recorded response.
+func serve(t *testing.T, s *rest.Server, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		t.Fatalf("unable to create request: %v", err)
+	}
+
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	s.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func testRoute(path string, fnc rest.ServiceFunc) rest.Handler {
+	return rest.Handler{
+		Route: func(r *mux.Route) {
+			r.Path(path).Methods(http.MethodGet)
+		},
+		Func: fnc,
+	}
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		handlerFunc rest.ServiceFunc
+		assert      func(resp *httptest.ResponseRecorder, logs *observer.ObservedLogs)
+	}{
+		{
+			name: "with string panic",
+			handlerFunc: func(w rest.Responder, r rest.Request) {
+				panic("something really bad happened")
+			},
+			assert: func(resp *httptest.ResponseRecorder, logs *observer.ObservedLogs) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+				assert.Equal(t, 1, logs.Len(), logs.All())
+				assert.Equal(t, "application panicked", logs.All()[0].Message)
+				assert.Equal(t, "error", logs.All()[0].Context[0].Key)
+				assert.Equal(t, "something really bad happened", logs.All()[0].Context[0].Interface.(error).Error())
+			},
+		},
+		{
+			name: "with error panic",
+			handlerFunc: func(w rest.Responder, r rest.Request) {
+				panic(errors.New("some really bad error"))
+			},
+			assert: func(resp *httptest.ResponseRecorder, logs *observer.ObservedLogs) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+				assert.Equal(t, 1, logs.Len(), logs.All())
+				assert.Equal(t, "error", logs.All()[0].Context[0].Key)
+				assert.Equal(t, "some really bad error", logs.All()[0].Context[0].Interface.(error).Error())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.DebugLevel)
+			logger := zap.New(core)
+
+			testEnv := app.NewTestEnvironmentWithLogger(t, logger, false)
+
+			s := rest.NewServer(testEnv, nil)
+			s.Use(rest.RecoverPanics(logger))
+			s.RegisterHandlers(testRoute("/test-panic-recovery", tc.handlerFunc))
+
+			resp := serve(t, s, "/test-panic-recovery", nil)
+
+			if err := logger.Sync(); err != nil {
+				t.Fatalf("logger sync failed: %v", err)
+			}
+
+			tc.assert(resp, logs)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header    string
+		generated bool
+	}{
+		"with a request id":               {header: "req-123"},
+		"without a request id":            {header: "", generated: true},
+		"with an oversized request id":    {header: strings.Repeat("a", 129), generated: true},
+		"with an unprintable request id":  {header: "req 123", generated: true},
+		"with a request id of max length": {header: strings.Repeat("a", 128)},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.DebugLevel)
+			logger := zap.New(core)
+			testEnv := app.NewTestEnvironmentWithLogger(t, logger, false)
+
+			var requestID string
+
+			s := rest.NewServer(testEnv, nil)
+			s.Use(rest.RequestID())
+			s.RegisterHandlers(testRoute("/test-request-id", func(w rest.Responder, r rest.Request) {
+				requestID = r.RequestID()
+				r.Logger().Info("handling request")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			resp := serve(t, s, "/test-request-id", http.Header{rest.RequestIDHeader: []string{tc.header}})
+
+			if tc.generated {
+				_, err := uuid.Parse(requestID)
+				assert.NoError(t, err, "a new request id is generated")
+			} else {
+				assert.Equal(t, tc.header, requestID)
+			}
+
+			assert.Equal(t, requestID, resp.Header().Get(rest.RequestIDHeader), "the request id is echoed")
+
+			if assert.Equal(t, 1, logs.Len()) {
+				assert.Equal(t, requestID, logs.All()[0].ContextMap()["request_id"], "the request logger carries the id")
+			}
+		})
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	t.Parallel()
+
+	// record returns Middleware that appends its name to the calls before and after the next http.Handler.
+	record := func(calls *[]string, name string) rest.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				*calls = append(*calls, name)
+				next.ServeHTTP(w, r)
+				*calls = append(*calls, name+" done")
+			})
+		}
+	}
+
+	t.Run("runs in the order it is added", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		s := rest.NewServer(app.NewTestEnvironment(t, false), nil)
+		s.Use(record(&calls, "first"), record(&calls, "second"))
+		s.Use(record(&calls, "third"))
+		s.RegisterHandlers(testRoute("/test-middleware", func(w rest.Responder, r rest.Request) {
+			calls = append(calls, "handler")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		resp := serve(t, s, "/test-middleware", nil)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Equal(
+			t,
+			[]string{"first", "second", "third", "handler", "third done", "second done", "first done"},
+			calls,
+		)
+	})
+
+	t.Run("runs for requests that do not match a route", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		s := rest.NewServer(app.NewTestEnvironment(t, false), nil)
+		s.Use(record(&calls, "middleware"))
+		s.RegisterHandlers(testRoute("/test-middleware", func(w rest.Responder, r rest.Request) {}))
+
+		resp := serve(t, s, "/unknown", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/test-middleware", nil)
+		if err != nil {
+			t.Fatalf("unable to create request: %v", err)
+		}
+
+		notAllowed := httptest.NewRecorder()
+		s.ServeHTTP(notAllowed, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, notAllowed.Code)
+
+		assert.Equal(t, []string{"middleware", "middleware done", "middleware", "middleware done"}, calls)
+	})
+
+	t.Run("logs requests with their request id", func(t *testing.T) {
+		t.Parallel()
+
+		core, logs := observer.New(zap.DebugLevel)
+		logger := zap.New(core)
+
+		e := app.NewTestEnvironmentWithLogger(t, logger, false)
+
+		s := rest.NewServer(e, nil)
+		s.Use(rest.DefaultMiddleware(e, rest.AccessLogOptions{SampleRate: 1}, nil)...)
+		s.RegisterHandlers(testRoute("/test-panic-recovery", func(w rest.Responder, r rest.Request) {
+			panic("something really bad happened")
+		}))
+
+		resp := serve(t, s, "/test-panic-recovery", http.Header{rest.RequestIDHeader: []string{"req-123"}})
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		handled := logs.FilterMessage("request handled").All()
+		if assert.Len(t, handled, 1) {
+			assert.Equal(t, "/test-panic-recovery", handled[0].ContextMap()["route"])
+			assert.Equal(t, "req-123", handled[0].ContextMap()["request_id"])
+			assert.Contains(t, handled[0].ContextMap(), "trace_id")
+		}
+	})
+}
+
+func TestLogRequests(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(t *testing.T, opts rest.AccessLogOptions) (*rest.Server, *observer.ObservedLogs) {
+		t.Helper()
+
+		core, logs := observer.New(zap.InfoLevel)
+		logger := zap.New(core)
+
+		s := rest.NewServer(app.NewTestEnvironmentWithLogger(t, logger, false), nil)
+		s.Use(rest.LogRequests(logger, opts))
+		s.RegisterHandlers(
+			testRoute("/health", func(w rest.Responder, r rest.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+			testRoute("/customers/{id}", func(w rest.Responder, r rest.Request) {
+				w.Respond(http.StatusOK, map[string]string{"id": mux.Vars(r.Request)["id"]})
+			}),
+			testRoute("/fail", func(w rest.Responder, r rest.Request) {
+				w.RespondInternalError(errors.New("something went wrong"))
+			}),
+		)
+
+		return s, logs
+	}
+
+	t.Run("logs the route template, status and size of the response", func(t *testing.T) {
+		t.Parallel()
+
+		s, logs := newServer(t, rest.AccessLogOptions{SampleRate: 1})
+
+		resp := serve(t, s, "/customers/123", http.Header{"User-Agent": []string{"test-agent"}})
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		handled := logs.FilterMessage("request handled").All()
+		if assert.Len(t, handled, 1) {
+			fields := handled[0].ContextMap()
+			assert.Equal(t, http.MethodGet, fields["method"])
+			assert.Equal(t, "/customers/{id}", fields["route"])
+			assert.Equal(t, int64(http.StatusOK), fields["status"])
+			assert.Equal(t, int64(resp.Body.Len()), fields["bytes"])
+			assert.Equal(t, "test-agent", fields["user_agent"])
+			assert.Contains(t, fields, "client_ip")
+			assert.Contains(t, fields, "duration")
+		}
+	})
+
+	t.Run("logs requests that do not match a route", func(t *testing.T) {
+		t.Parallel()
+
+		s, logs := newServer(t, rest.AccessLogOptions{SampleRate: 1})
+
+		resp := serve(t, s, "/unknown", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		handled := logs.FilterMessage("request handled").All()
+		if assert.Len(t, handled, 1) {
+			assert.Equal(t, "", handled[0].ContextMap()["route"])
+			assert.Equal(t, int64(http.StatusNotFound), handled[0].ContextMap()["status"])
+		}
+	})
+
+	t.Run("skips excluded routes", func(t *testing.T) {
+		t.Parallel()
+
+		s, logs := newServer(t, rest.AccessLogOptions{SampleRate: 1, Exclude: []string{"/health", "/customers/{id}"}})
+
+		serve(t, s, "/health", nil)
+		serve(t, s, "/customers/123", nil)
+
+		assert.Equal(t, 0, logs.FilterMessage("request handled").Len())
+	})
+
+	t.Run("always logs server errors when sampling", func(t *testing.T) {
+		t.Parallel()
+
+		s, logs := newServer(t, rest.AccessLogOptions{SampleRate: 0})
+
+		serve(t, s, "/customers/123", nil)
+		serve(t, s, "/fail", nil)
+
+		handled := logs.FilterMessage("request handled").All()
+		if assert.Len(t, handled, 1) {
+			assert.Equal(t, "/fail", handled[0].ContextMap()["route"])
+			assert.Equal(t, int64(http.StatusInternalServerError), handled[0].ContextMap()["status"])
+		}
+	})
+}
+
+func TestRecordMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	s := rest.NewServer(app.NewTestEnvironment(t, false), nil)
+	s.Use(rest.RecordMetrics(registry), rest.RecoverPanics(zap.NewNop()))
+	s.RegisterHandlers(
+		testRoute("/customers/{id}", func(w rest.Responder, r rest.Request) {
+			w.Respond(http.StatusOK, nil)
+		}),
+		testRoute("/panic", func(w rest.Responder, r rest.Request) {
+			panic("something really bad happened")
+		}),
+	)
+
+	serve(t, s, "/customers/1", nil)
+	serve(t, s, "/customers/2", nil)
+	serve(t, s, "/panic", nil)
+	serve(t, s, "/unknown", nil)
+
+	expected := `
+# HELP http_requests_total The number of requests handled.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="",status="404"} 1
+http_requests_total{method="GET",route="/customers/{id}",status="200"} 2
+http_requests_total{method="GET",route="/panic",status="500"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"))
+
+	count, err := testutil.GatherAndCount(registry, "http_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count, "one histogram is observed per route, method and status")
+}
+
+func TestRecordMetricsSharesRegisterer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	newServer := func() *rest.Server {
+		s := rest.NewServer(app.NewTestEnvironment(t, false), nil)
+		s.Use(rest.RecordMetrics(registry))
+		s.RegisterHandlers(testRoute("/customers/{id}", func(w rest.Responder, r rest.Request) {
+			w.Respond(http.StatusOK, nil)
+		}))
+
+		return s
+	}
+
+	first := newServer()
+	second := newServer()
+
+	serve(t, first, "/customers/1", nil)
+	serve(t, second, "/customers/2", nil)
+
+	expected := `
+# HELP http_requests_total The number of requests handled.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/customers/{id}",status="200"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"))
+}
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(t *testing.T) (*rest.Server, *tracetest.SpanRecorder, *observer.ObservedLogs) {
+		t.Helper()
+
+		core, logs := observer.New(zap.InfoLevel)
+		recorder := tracetest.NewSpanRecorder()
+
+		s := rest.NewServer(app.NewTestEnvironmentWithLogger(t, zap.New(core), false), nil)
+		s.Use(rest.RequestID(), rest.Trace(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+		s.RegisterHandlers(
+			testRoute("/customers/{id}", func(w rest.Responder, r rest.Request) {
+				r.Logger().Info("handling customer")
+				w.Respond(http.StatusOK, nil)
+			}),
+			testRoute("/fail", func(w rest.Responder, r rest.Request) {
+				w.RespondInternalError(errors.New("something went wrong"))
+			}),
+		)
+
+		return s, recorder, logs
+	}
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+
+		return attrs
+	}
+
+	t.Run("continues the trace of the traceparent header", func(t *testing.T) {
+		t.Parallel()
+
+		s, recorder, logs := newServer(t)
+
+		serve(t, s, "/customers/123", http.Header{
+			"Traceparent":        []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			rest.RequestIDHeader: []string{"req-123"},
+		})
+
+		spans := recorder.Ended()
+		if !assert.Len(t, spans, 1) {
+			return
+		}
+
+		span := spans[0]
+		assert.Equal(t, "GET /customers/{id}", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.True(t, span.Parent().IsRemote())
+
+		attrs := attributes(span)
+		assert.Equal(t, "/customers/{id}", attrs["http.route"].AsString())
+		assert.Equal(t, int64(http.StatusOK), attrs["http.status_code"].AsInt64())
+		assert.Equal(t, "req-123", attrs["http.request_id"].AsString())
+
+		handled := logs.FilterMessage("handling customer").All()
+		if assert.Len(t, handled, 1) {
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handled[0].ContextMap()["trace_id"])
+			assert.Equal(t, span.SpanContext().SpanID().String(), handled[0].ContextMap()["span_id"])
+		}
+	})
+
+	t.Run("starts a new trace without a traceparent header", func(t *testing.T) {
+		t.Parallel()
+
+		s, recorder, _ := newServer(t)
+
+		serve(t, s, "/customers/123", nil)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.True(t, spans[0].SpanContext().IsValid())
+			assert.False(t, spans[0].Parent().IsValid())
+		}
+	})
+
+	t.Run("names requests that do not match a route by their method", func(t *testing.T) {
+		t.Parallel()
+
+		s, recorder, _ := newServer(t)
+
+		serve(t, s, "/unknown", nil)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, "HTTP GET", spans[0].Name())
+			assert.Equal(t, int64(http.StatusNotFound), attributes(spans[0])["http.status_code"].AsInt64())
+			assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		}
+	})
+
+	t.Run("marks server errors as failed", func(t *testing.T) {
+		t.Parallel()
+
+		s, recorder, _ := newServer(t)
+
+		serve(t, s, "/fail", nil)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, codes.Error, spans[0].Status().Code)
+		}
+	})
+}
@@ -1,14 +1,71 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nickbryan/go-template/service/app"
+	"go.uber.org/zap"
 )
 
+// RequestIDHeader carries the id that identifies a request across the services that handle it.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength stops clients from filling our logs and audit entries with oversized ids.
+const maxRequestIDLength = 128
+
+// WithRequestID returns a copy of the context holding the id of the request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the id of the request, which is empty if the request did not have one.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+
+	return id
+}
+
+// validRequestID reports whether an id sent by a client is short enough to be useful and only holds printable
+// ASCII characters, so that it can be safely written to logs and response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Request wraps the http.Request so that we can add custom methods.
 type Request struct {
 	*http.Request
+	logger *zap.Logger
+}
+
+// Logger returns the logger for the request. Every entry it writes carries the id of the request.
+func (r Request) Logger() *zap.Logger {
+	if r.logger == nil {
+		return app.ContextLogger(r.Context(), zap.L())
+	}
+
+	return r.logger
+}
+
+// withContext returns a copy of the Request with its context changed to ctx.
+func (r Request) withContext(ctx context.Context) Request {
+	return Request{Request: r.Request.WithContext(ctx), logger: r.logger}
 }
 
 // Decode de-serialises the JSON body of the request into the passed destination object.
@@ -20,3 +77,130 @@ func (r Request) Decode(dest interface{}) error {
 
 	return nil
 }
+
+// CustomerID returns the id of the customer that authenticated the request. The bool
+// will be false if the request has not passed through an authentication middleware.
+func (r Request) CustomerID() (uuid.UUID, bool) {
+	return CustomerIDFromContext(r.Context())
+}
+
+// RequestID returns the id of the request. It is empty if the request did not pass through the RequestID
+// Middleware.
+func (r Request) RequestID() string {
+	return RequestIDFromContext(r.Context())
+}
+
+// ClientIP returns the IP address of the client that made the request. The X-Forwarded-For header is only
+// read for requests that ResolveClientIP received from a trusted proxy as any client can set it.
+func (r Request) ClientIP() string {
+	return clientIP(r.Request)
+}
+
+// ErrInvalidTrustedProxy is returned by ParseTrustedProxies for addresses that are not an IP or CIDR.
+var ErrInvalidTrustedProxy = errors.New("trusted proxy must be an ip address or cidr")
+
+// ParseTrustedProxies parses the IP addresses and CIDR ranges of the proxies that are trusted to set the
+// X-Forwarded-For header, such as the load balancers in front of the Server.
+func ParseTrustedProxies(addrs []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(addrs))
+
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTrustedProxy, addr)
+		}
+
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+// ResolveClientIP finds the IP address of the client that made each request so that it can be read with
+// Request.ClientIP. When the request comes from one of the trusted proxies the X-Forwarded-For header is
+// read from right to left and the first address that is not a trusted proxy is the client. Addresses left
+// of it could have been set by the client so they are ignored. Without trusted proxies the header is never
+// read and the client is the remote address of the connection.
+func ResolveClientIP(trusted []*net.IPNet) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := forwardedFor(remoteIP(r), r.Header.Values("X-Forwarded-For"), trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey, ip)))
+		})
+	}
+}
+
+// forwardedFor returns the address of the client from the X-Forwarded-For values of a request from remote.
+func forwardedFor(remote string, values []string, trusted []*net.IPNet) string {
+	if !isTrusted(remote, trusted) {
+		return remote
+	}
+
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	client := remote
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// A malformed address can not be trusted so the last proxy that added a valid one is the client.
+			break
+		}
+
+		client = hop
+
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+
+	return client
+}
+
+func isTrusted(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns the address found by ResolveClientIP, or the remote address if the request did not pass
+// through it.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
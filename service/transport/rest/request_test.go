@@ -3,8 +3,10 @@ package rest_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -77,3 +79,113 @@ func TestRequestDecode(t *testing.T) {
 		assert.Contains(t, err.Error(), "unable to decode request: ")
 	})
 }
+
+func TestRequestClientIP(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"192.0.2.1:1234":   "192.0.2.1",
+		"[2001:db8::1]:80": "2001:db8::1",
+		"192.0.2.1":        "192.0.2.1",
+	}
+
+	for remoteAddr, expected := range tests {
+		remoteAddr, expected := remoteAddr, expected
+		t.Run(remoteAddr, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("unable to create request: %v", err)
+			}
+
+			r.RemoteAddr = remoteAddr
+			r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+			assert.Equal(t, expected, rest.Request{Request: r}.ClientIP())
+		})
+	}
+}
+
+func TestResolveClientIP(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := rest.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("unable to parse trusted proxies: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{
+			name:         "forwarded for is ignored from untrusted clients",
+			remoteAddr:   "198.51.100.1:1234",
+			forwardedFor: []string{"203.0.113.7"},
+			expected:     "198.51.100.1",
+		},
+		{
+			name:       "requests from a trusted proxy without forwarded for use the proxy",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:         "the client is the first untrusted address from the right",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.7, 10.0.0.2"},
+			expected:     "203.0.113.7",
+		},
+		{
+			name:         "addresses set by the client are ignored",
+			remoteAddr:   "192.0.2.10:1234",
+			forwardedFor: []string{"1.2.3.4, 203.0.113.7", "10.0.0.2"},
+			expected:     "203.0.113.7",
+		},
+		{
+			name:         "malformed addresses are not trusted",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.7, not-an-ip, 10.0.0.2"},
+			expected:     "10.0.0.2",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+
+			for _, value := range tc.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			var ip string
+
+			rest.ResolveClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = rest.Request{Request: r}.ClientIP()
+			})).ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tc.expected, ip)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	proxies, err := rest.ParseTrustedProxies([]string{"192.0.2.10", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("unable to parse trusted proxies: %v", err)
+	}
+
+	assert.Equal(t, "192.0.2.10/32", proxies[0].String())
+	assert.Equal(t, "2001:db8::/32", proxies[1].String())
+
+	_, err = rest.ParseTrustedProxies([]string{"not-a-proxy"})
+	assert.True(t, errors.Is(err, rest.ErrInvalidTrustedProxy))
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/Jeffail/gabs"
@@ -9,9 +10,69 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrInternal is written to the client in place of errors that should not be exposed.
+var ErrInternal = errors.New("an internal error occurred")
+
 type responder struct {
 	http.ResponseWriter
 	logger *zap.Logger
+	status int
+	size   int
+	route  string
+}
+
+// responderFor returns w if it is already a responder created by Middleware earlier in the chain so that the
+// status, size and route recorded while handling the request are visible to the Middleware. Otherwise a new
+// responder wraps w. Either way the responder logs to the given logger.
+func responderFor(w http.ResponseWriter, logger *zap.Logger) *responder {
+	if resp, ok := w.(*responder); ok {
+		resp.logger = logger
+
+		return resp
+	}
+
+	return &responder{ResponseWriter: w, logger: logger}
+}
+
+// wrapResponder returns w if it is already a responder, keeping its logger, so that Middleware that only records
+// the status, size and route does not replace the logger set by Middleware earlier in the chain. Otherwise a new
+// responder wraps w that discards its logs until a later responderFor sets the logger.
+func wrapResponder(w http.ResponseWriter) *responder {
+	if resp, ok := w.(*responder); ok {
+		return resp
+	}
+
+	return &responder{ResponseWriter: w, logger: zap.NewNop()}
+}
+
+// WriteHeader records the first status written so that it can be logged once the request has been handled.
+func (r *responder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written so that it can be logged once the request has been handled.
+func (r *responder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+
+	return n, err
+}
+
+// writtenStatus is the status written to the client, which is http.StatusOK if the handler did not write one.
+func (r *responder) writtenStatus() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+
+	return r.status
 }
 
 func (r *responder) Respond(status int, data interface{}) {
@@ -27,11 +88,15 @@ func (r *responder) Respond(status int, data interface{}) {
 }
 
 func (r *responder) RespondError(status int, err error) {
-	body := gabs.New()
-
 	r.logger.Error("responding application error", zap.Error(err), zap.Int("status_code", status))
 
-	if _, err := body.SetP(err.Error(), "error.message"); err != nil {
+	r.respondErrorMessage(status, err.Error())
+}
+
+func (r *responder) respondErrorMessage(status int, message string) {
+	body := gabs.New()
+
+	if _, err := body.SetP(message, "error.message"); err != nil {
 		r.logger.Error("unable to set json body when responding error message", zap.Error(err))
 		r.WriteHeader(http.StatusInternalServerError)
 
@@ -41,6 +106,12 @@ func (r *responder) RespondError(status int, err error) {
 	r.Respond(status, body.Data())
 }
 
+func (r *responder) RespondInternalError(err error) {
+	r.logger.Error("responding internal error", zap.Error(err))
+
+	r.respondErrorMessage(http.StatusInternalServerError, ErrInternal.Error())
+}
+
 func (r *responder) RespondValidationFailed(errors validation.Errors) {
 	body := gabs.New()
 
@@ -10,9 +10,12 @@ import (
 	"testing"
 
 	"github.com/Jeffail/gabs"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/nickbryan/go-template/service/app"
+	"github.com/nickbryan/go-template/service/domain/customer"
+	"github.com/nickbryan/go-template/service/infrastructure/postgres"
 	"github.com/nickbryan/go-template/service/transport/rest"
 )
 
@@ -28,9 +31,7 @@ func Request(
 	return RequestWithData(t, method, url, handler, nil, e)
 }
 
-// RequestWithData returns the decoded json response as ResponseData along with the httptest.ResponseRecorder.
-// If any errors are returned during the execution of the request the test will me marked as failed and exit
-// immediately.
+// RequestWithData wraps RequestWithHeaders passing nil as the headers.
 func RequestWithData(
 	t *testing.T,
 	method, url string,
@@ -40,6 +41,48 @@ func RequestWithData(
 ) (*gabs.Container, *httptest.ResponseRecorder) {
 	t.Helper()
 
+	return RequestWithHeaders(t, method, url, handler, data, nil, e)
+}
+
+// AuthHeader issues an access token for the given customer id and returns it as an Authorization header.
+func AuthHeader(t *testing.T, e *app.Environment, customerID uuid.UUID) http.Header {
+	t.Helper()
+
+	token, err := e.JWT().Issue(customerID.String())
+	if err != nil {
+		assert.FailNow(t, fmt.Sprintf("unable to issue access token: %v", err))
+	}
+
+	return http.Header{"Authorization": []string{"Bearer " + token}}
+}
+
+// AdminHeader grants customer.RoleAdmin to the existing customer with the given id and returns an Authorization
+// header for them.
+func AdminHeader(t *testing.T, e *app.Environment, customerID uuid.UUID) http.Header {
+	t.Helper()
+
+	repo := postgres.NewCustomerRepository(e.DB())
+
+	if err := repo.GrantRole(context.Background(), customerID, customer.RoleAdmin); err != nil {
+		assert.FailNow(t, fmt.Sprintf("unable to grant admin role: %v", err))
+	}
+
+	return AuthHeader(t, e, customerID)
+}
+
+// RequestWithHeaders returns the decoded json response as ResponseData along with the httptest.ResponseRecorder.
+// If any errors are returned during the execution of the request the test will me marked as failed and exit
+// immediately. The permissions of the handler are checked against the roles granted to customers in the database.
+func RequestWithHeaders(
+	t *testing.T,
+	method, url string,
+	handler rest.Handler,
+	data interface{},
+	header http.Header,
+	e *app.Environment,
+) (*gabs.Container, *httptest.ResponseRecorder) {
+	t.Helper()
+
 	body, err := json.Marshal(data)
 	if err != nil {
 		assert.FailNow(t, fmt.Sprintf("unable to marshal input data: %v", err))
@@ -54,10 +97,16 @@ func RequestWithData(
 		assert.FailNow(t, fmt.Sprintf("unable to create request: %v", err))
 	}
 
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
 	resp := httptest.NewRecorder()
 
 	r := mux.NewRouter()
-	handler.AddRoute(r, e)
+	handler.AddRoute(r, e, customer.NewAuthorizer(postgres.NewCustomerRepository(e.DB())))
 	r.ServeHTTP(resp, req)
 
 	if resp.Body.Len() == 0 {
@@ -7,87 +7,119 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/gabs"
 	"github.com/gorilla/mux"
 	"github.com/nickbryan/go-template/service/app"
+	"go.uber.org/zap"
 )
 
 // Server defines a HTTP server for handling Rest requests.
 type Server struct {
 	environment *app.Environment
+	authorizer  Authorizer
 	router      *mux.Router
+	middleware  []Middleware
+	handler     http.Handler
+	internal    *http.Server
 }
 
-// NewServer initialises a new Server with a router.
-func NewServer(e *app.Environment) *Server {
+// NewServer initialises a new Server with a router. The Authorizer enforces the Permissions of each Handler.
+func NewServer(e *app.Environment, a Authorizer) *Server {
 	router := mux.NewRouter()
 
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		msg := gabs.New()
 
+		logger := app.ContextLogger(r.Context(), e.Logger())
+
 		if _, err := msg.SetP("resource not found", "error.message"); err != nil {
-			e.Logger().Error(fmt.Sprintf("set json path failed in not found handler: %s", err))
+			logger.Error(fmt.Sprintf("set json path failed in not found handler: %s", err))
 			w.WriteHeader(http.StatusInternalServerError)
 
 			return
 		}
 
-		(&responder{
-			ResponseWriter: w,
-			logger:         e.Logger(),
-		}).Respond(http.StatusNotFound, msg.Data())
+		responderFor(w, logger).Respond(http.StatusNotFound, msg.Data())
 	})
 
 	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		msg := gabs.New()
 
+		logger := app.ContextLogger(r.Context(), e.Logger())
+
 		if _, err := msg.SetP(fmt.Sprintf("method %s is not allowed", r.Method), "error.message"); err != nil {
-			e.Logger().Error(fmt.Sprintf("set json path failed in method not allowed handler: %s", err))
+			logger.Error(fmt.Sprintf("set json path failed in method not allowed handler: %s", err))
 			w.WriteHeader(http.StatusInternalServerError)
 
 			return
 		}
 
-		(&responder{
-			ResponseWriter: w,
-			logger:         e.Logger(),
-		}).Respond(http.StatusMethodNotAllowed, msg.Data())
+		responderFor(w, logger).Respond(http.StatusMethodNotAllowed, msg.Data())
 	})
 
 	return &Server{
-		e,
-		router,
+		environment: e,
+		authorizer:  a,
+		router:      router,
+		handler:     router,
 	}
 }
 
+// Use adds Middleware that runs for every request, including those that do not match a route. Middleware runs
+// in the order it is added so the first Middleware wraps all of the others and the router.
+func (s *Server) Use(middleware ...Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+
+	s.handler = s.router
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		s.handler = s.middleware[i](s.handler)
+	}
+}
+
+// ListenInternal serves the handlers on a separate listener at the address, which is started and shut down
+// along with the Server. It is used for endpoints, such as metrics, that should not be exposed alongside the
+// api. The Middleware added with Use does not run for the internal handlers.
+func (s *Server) ListenInternal(address string, handlers ...Handler) {
+	router := mux.NewRouter()
+	for _, h := range handlers {
+		h.AddRoute(router, s.environment, s.authorizer)
+	}
+
+	s.internal = s.httpServer(address, router)
+}
+
 // Start the server and listen for incoming requests.
 func (s *Server) Start() error {
 	conf := s.environment.Config()
 
-	srv := &http.Server{
-		Addr:         conf.Server.Address,
-		WriteTimeout: conf.Server.WriteTimeout * time.Second,
-		ReadTimeout:  conf.Server.ReadTimeout * time.Second,
-		IdleTimeout:  conf.Server.IdleTimeout * time.Second,
-		Handler:      s,
+	servers := []*http.Server{s.httpServer(conf.Server.Address, s)}
+	if s.internal != nil {
+		servers = append(servers, s.internal)
 	}
 
-	// Here we start the web server listing for connections and serving responses. When the server
+	// Here we start the web servers listing for connections and serving responses. When a server
 	// closes we may get back an error so we create a channel that allows us to receive that error
 	// to be reported on later. If the error is http.ErrServerClosed then we ignore it as we expect
-	// that to happen at some point. We start this in a separate go routine to allow us to block on
+	// that to happen at some point. We start each in a separate go routine to allow us to block on
 	// an os.Interrupt signal later.
-	errChan := make(chan error, 1)
+	errChan := make(chan error, len(servers))
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			errChan <- fmt.Errorf("ann error occured on ListenAndServe: %w", err)
-		}
+	var wg sync.WaitGroup
+
+	for _, srv := range servers {
+		wg.Add(1)
 
-		close(errChan)
-	}()
+		go func(srv *http.Server) {
+			defer wg.Done()
+
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errChan <- fmt.Errorf("ann error occured on ListenAndServe of %s: %w", srv.Addr, err)
+			}
+		}(srv)
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
@@ -99,26 +131,55 @@ func (s *Server) Start() error {
 	ctx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout*time.Second)
 	defer cancel()
 
-	// Shutting down the server should trigger our http.ErrServerClosed that we ignore
-	// in the above go routine.
-	if err := srv.Shutdown(ctx); err != nil {
-		return fmt.Errorf("an error occurred on server shutdown: %w", err)
+	// Shutting down the servers should trigger our http.ErrServerClosed that we ignore
+	// in the above go routines. Every server is shut down even if an earlier one fails to.
+	var shutdownErrs []error
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			shutdownErrs = append(shutdownErrs, fmt.Errorf("an error occurred on shutdown of %s: %w", srv.Addr, err))
+		}
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	// We can only return one error so any others from shutting down are logged rather than lost.
+	if len(shutdownErrs) > 0 {
+		for _, err := range shutdownErrs[1:] {
+			s.environment.Logger().Error("unable to shut down server", zap.Error(err))
+		}
+
+		return shutdownErrs[0]
 	}
 
 	// Here we return the result of our error channel from earlier. If there was no error then we will receive nil
-	// otherwise we will receive the specified error.
+	// otherwise we will receive the first error.
 	return <-errChan
 }
 
-// ServeHTTP requests via the internal router.
+// httpServer creates a http.Server listening on the address with the configured timeouts.
+func (s *Server) httpServer(address string, handler http.Handler) *http.Server {
+	conf := s.environment.Config()
+
+	return &http.Server{
+		Addr:         address,
+		WriteTimeout: conf.Server.WriteTimeout * time.Second,
+		ReadTimeout:  conf.Server.ReadTimeout * time.Second,
+		IdleTimeout:  conf.Server.IdleTimeout * time.Second,
+		Handler:      handler,
+	}
+}
+
+// ServeHTTP requests via the Middleware and the internal router.
 // This is what allows us to use our Server struct as the http.Server Handler in the Start method.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r)
 }
 
 // RegisterHandlers with the router. This allows a Handler to define their route with the router.
 func (s *Server) RegisterHandlers(handlers ...Handler) {
 	for _, h := range handlers {
-		h.AddRoute(s.router, s.environment)
+		h.AddRoute(s.router, s.environment, s.authorizer)
 	}
 }
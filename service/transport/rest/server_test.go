@@ -26,7 +26,7 @@ func TestServer(t *testing.T) {
 		called := false
 
 		testEnv := app.NewTestEnvironment(t, false)
-		s := rest.NewServer(testEnv)
+		s := rest.NewServer(testEnv, nil)
 		s.RegisterHandlers(testHandler(&called))
 
 		assert.True(t, called, "the RegisterRoutes method was not called on mock")